@@ -22,32 +22,45 @@ func NewClient(timeout time.Duration) Client {
 	}
 }
 
+// HTTPError is returned when a feed responds with anything but 200 OK.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("non-OK HTTP status: %s", e.Status)
+}
+
+// FetchResult describes a single feed request. StatusCode and Bytes are set
+// whenever a response was received, even if the feed couldn't be parsed.
+type FetchResult struct {
+	Feed       *RSSFeed
+	StatusCode int
+	Bytes      int
+}
+
 func (c *Client) FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+	result, err := c.Fetch(ctx, feedURL)
 
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/rss+xml")
-	req.Header.Set("User-Agent", "gator")
+	return result.Feed, nil
+}
 
-	res, err := c.httpClient.Do(req)
+func (c *Client) Fetch(ctx context.Context, feedURL string) (FetchResult, error) {
+	res, rawData, err := c.get(ctx, feedURL)
 
 	if err != nil {
-		return nil, err
-	}
-
-	if res == nil || res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("non-OK HTTP status: %s", res.Status)
+		return FetchResult{}, err
 	}
 
-	defer res.Body.Close()
-
-	rawData, err := io.ReadAll(res.Body)
+	result := FetchResult{StatusCode: res.StatusCode, Bytes: len(rawData)}
 
-	if err != nil {
-		return nil, err
+	if res.StatusCode != http.StatusOK {
+		return result, &HTTPError{StatusCode: res.StatusCode, Status: res.Status}
 	}
 
 	var rssFeed RSSFeed
@@ -55,7 +68,7 @@ func (c *Client) FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error
 	err = xml.Unmarshal(rawData, &rssFeed)
 
 	if err != nil {
-		return nil, err
+		return result, err
 	}
 
 	// Decode escaped HTML entities
@@ -67,5 +80,35 @@ func (c *Client) FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error
 		rssFeed.Channel.Item[i] = item
 	}
 
-	return &rssFeed, nil
+	result.Feed = &rssFeed
+	return result, nil
+}
+
+// get performs a feed request and returns the response together with its
+// fully read body.
+func (c *Client) get(ctx context.Context, feedURL string) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/rss+xml")
+	req.Header.Set("User-Agent", "gator")
+
+	res, err := c.httpClient.Do(req)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer res.Body.Close()
+
+	rawData, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return res, rawData, nil
 }
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// dateLayouts lists the timestamp formats found in the wild in RSS and Atom
+// feeds, most common first.
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC3339,
+	time.RFC3339Nano,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+var ErrUnparseableDate = errors.New("unparseable date")
+
+// ParseDate parses a feed timestamp using any of the supported layouts.
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrUnparseableDate
+}
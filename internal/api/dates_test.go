@@ -0,0 +1,41 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	want := time.Date(2026, time.October, 5, 10, 0, 0, 0, time.UTC)
+	for _, value := range []string{
+		"Mon, 05 Oct 2026 10:00:00 +0000",
+		"Mon, 05 Oct 2026 10:00:00 UTC",
+		"05 Oct 26 10:00 +0000",
+		"2026-10-05T10:00:00Z",
+		"2026-10-05T12:00:00+02:00",
+		"Mon, 5 Oct 2026 10:00:00 +0000",
+		"5 Oct 2026 10:00:00 +0000",
+		"2026-10-05T10:00:00",
+		"  Mon, 05 Oct 2026 10:00:00 +0000\n",
+	} {
+		got, err := ParseDate(value)
+		if err != nil {
+			t.Errorf("ParseDate(%q): %v", value, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("ParseDate(%q) = %s, want %s", value, got, want)
+		}
+	}
+
+	if got, err := ParseDate("2026-10-05"); err != nil || !got.Equal(time.Date(2026, time.October, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseDate of a bare date = %s, %v", got, err)
+	}
+
+	for _, value := range []string{"", "yesterday", "05/10/2026"} {
+		if _, err := ParseDate(value); !errors.Is(err, ErrUnparseableDate) {
+			t.Errorf("ParseDate(%q): err = %v, want ErrUnparseableDate", value, err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Key identifies an item within its feed across fetches. It is the item's
+// GUID when it has one, falling back to a hash of its link and title.
+func (i RSSItem) Key() string {
+	if guid := strings.TrimSpace(i.GUID); guid != "" {
+		return guid
+	}
+	return "sha256:" + hashFields(i.Link, i.Title)
+}
+
+// ContentHash changes whenever any of the stored fields of an item change.
+func (i RSSItem) ContentHash() string {
+	return hashFields(i.Title, i.Link, i.Description, i.PubDate)
+}
+
+func hashFields(fields ...string) string {
+	h := sha256.New()
+	for _, field := range fields {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,55 @@
+package api
+
+import "testing"
+
+func TestRSSItemKey(t *testing.T) {
+	item := RSSItem{Title: "One", Link: "http://example.com/1", GUID: " tag:example.com,2026:1 \n"}
+	if got := item.Key(); got != "tag:example.com,2026:1" {
+		t.Errorf("Key() = %q, want the trimmed GUID", got)
+	}
+
+	// Without a GUID, items are keyed by their link and title.
+	item.GUID = "  "
+	key := item.Key()
+	if len(key) != len("sha256:")+64 || key[:len("sha256:")] != "sha256:" {
+		t.Errorf("Key() = %q, want a sha256 of the link and title", key)
+	}
+	if other := (RSSItem{Title: "One", Link: "http://example.com/1", Description: "Edited"}); other.Key() != key {
+		t.Error("Key() changed with the description")
+	}
+	for _, other := range []RSSItem{
+		{Title: "Two", Link: "http://example.com/1"},
+		{Title: "One", Link: "http://example.com/2"},
+		{Title: "", Link: "http://example.com/1One"},
+	} {
+		if other.Key() == key {
+			t.Errorf("Key() of %+v = the key of %+v", other, item)
+		}
+	}
+}
+
+func TestRSSItemContentHash(t *testing.T) {
+	item := RSSItem{Title: "One", Link: "http://example.com/1", Description: "About one", PubDate: "Mon, 05 Oct 2026 10:00:00 +0000", GUID: "1"}
+	hash := item.ContentHash()
+	if same := item; same.ContentHash() != hash {
+		t.Error("ContentHash() differs for the same item")
+	}
+
+	changed := []RSSItem{item, item, item, item}
+	changed[0].Title = "One, edited"
+	changed[1].Link = "http://example.com/one"
+	changed[2].Description = "About one, edited"
+	changed[3].PubDate = "Tue, 06 Oct 2026 10:00:00 +0000"
+	for _, other := range changed {
+		if other.ContentHash() == hash {
+			t.Errorf("ContentHash() did not change for %+v", other)
+		}
+	}
+
+	// The GUID is the key, not part of the content.
+	other := item
+	other.GUID = "2"
+	if other.ContentHash() != hash {
+		t.Error("ContentHash() changed with the GUID")
+	}
+}
@@ -0,0 +1,336 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// cacheHeaders are the response headers that control conditional requests
+// and caching of a feed.
+var cacheHeaders = []string{"ETag", "Last-Modified", "Cache-Control", "Expires", "Age"}
+
+type Header struct {
+	Name  string
+	Value string
+}
+
+type ItemRef struct {
+	Index int
+	Line  int
+	Title string
+}
+
+type DateIssue struct {
+	ItemRef
+	Value string
+}
+
+type DuplicateLink struct {
+	Link  string
+	Items []ItemRef
+}
+
+type XMLError struct {
+	Line    int
+	Message string
+}
+
+// ValidationReport describes everything found while fetching and parsing a
+// feed. Problems are reported rather than returned as errors so that a single
+// run surfaces all of them at once.
+type ValidationReport struct {
+	URL            string
+	Status         string
+	StatusCode     int
+	ContentType    string
+	Format         string
+	Encoding       string
+	HeaderEncoding string
+	CacheHeaders   []Header
+	Size           int
+	ItemCount      int
+	MissingLinks   []ItemRef
+	MissingDates   []ItemRef
+	MissingGUIDs   []ItemRef
+	BadDates       []DateIssue
+	DuplicateLinks []DuplicateLink
+	XMLErrors      []XMLError
+	Warnings       []string
+}
+
+// HasProblems reports whether the feed has anything that would prevent or
+// degrade ingestion. Warnings are advisories and don't count: plenty of
+// healthy feeds are served without caching headers, for example.
+func (r *ValidationReport) HasProblems() bool {
+	return r.StatusCode != http.StatusOK ||
+		len(r.XMLErrors) > 0 ||
+		len(r.MissingLinks) > 0 ||
+		len(r.MissingDates) > 0 ||
+		len(r.MissingGUIDs) > 0 ||
+		len(r.BadDates) > 0 ||
+		len(r.DuplicateLinks) > 0
+}
+
+type feedLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Text string `xml:",chardata"`
+}
+
+// feedEntry captures the fields of both RSS items and Atom entries.
+type feedEntry struct {
+	Title     string     `xml:"title"`
+	Links     []feedLink `xml:"link"`
+	GUID      string     `xml:"guid"`
+	ID        string     `xml:"id"`
+	PubDate   string     `xml:"pubDate"`
+	DCDate    string     `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+}
+
+func (e feedEntry) link() string {
+	for _, l := range e.Links {
+		if text := strings.TrimSpace(l.Text); text != "" {
+			return text
+		}
+		if l.Href != "" && (l.Rel == "" || l.Rel == "alternate") {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+func (e feedEntry) guid() string {
+	if e.GUID != "" {
+		return strings.TrimSpace(e.GUID)
+	}
+	return strings.TrimSpace(e.ID)
+}
+
+func (e feedEntry) date() string {
+	for _, d := range []string{e.PubDate, e.Published, e.Updated, e.DCDate} {
+		if d = strings.TrimSpace(d); d != "" {
+			return d
+		}
+	}
+	return ""
+}
+
+// ValidateFeed fetches a feed and inspects it for problems. An error is only
+// returned when the feed could not be retrieved at all.
+func (c *Client) ValidateFeed(ctx context.Context, feedURL string) (*ValidationReport, error) {
+	res, rawData, err := c.get(ctx, feedURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ValidationReport{
+		URL:         feedURL,
+		Status:      res.Status,
+		StatusCode:  res.StatusCode,
+		ContentType: res.Header.Get("Content-Type"),
+		Size:        len(rawData),
+	}
+
+	for _, name := range cacheHeaders {
+		if value := res.Header.Get(name); value != "" {
+			report.CacheHeaders = append(report.CacheHeaders, Header{Name: name, Value: value})
+		}
+	}
+	if res.Header.Get("ETag") == "" && res.Header.Get("Last-Modified") == "" {
+		report.Warnings = append(report.Warnings, "no ETag or Last-Modified header, conditional requests are not possible")
+	}
+
+	if _, params, err := mime.ParseMediaType(report.ContentType); err == nil {
+		report.HeaderEncoding = params["charset"]
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return report, nil
+	}
+
+	inspectDocument(report, rawData)
+
+	return report, nil
+}
+
+func inspectDocument(report *ValidationReport, rawData []byte) {
+	decoder := xml.NewDecoder(bytes.NewReader(rawData))
+	decoder.Strict = true
+	// The structure of the document is what matters here, so non UTF-8
+	// content is passed through undecoded instead of aborting the parse.
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var entries []feedEntry
+	var refs []ItemRef
+
+tokens:
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			report.addXMLError(decoder, err)
+			break
+		}
+
+		switch t := token.(type) {
+		case xml.ProcInst:
+			if t.Target == "xml" {
+				report.Encoding = procInstParam(string(t.Inst), "encoding")
+			}
+		case xml.StartElement:
+			if report.Format == "" {
+				report.Format = detectFormat(t)
+				continue
+			}
+			if t.Name.Local != "item" && t.Name.Local != "entry" {
+				continue
+			}
+			line, _ := decoder.InputPos()
+			var entry feedEntry
+			if err := decoder.DecodeElement(&entry, &t); err != nil {
+				report.addXMLError(decoder, err)
+				break tokens
+			}
+			entries = append(entries, entry)
+			refs = append(refs, ItemRef{Index: len(entries), Line: line, Title: strings.TrimSpace(entry.Title)})
+		}
+	}
+
+	if report.Format == "" {
+		report.Format = "unknown"
+	}
+	if report.Encoding == "" {
+		report.Encoding = "UTF-8"
+	}
+
+	report.checkEncoding(rawData)
+	report.checkEntries(entries, refs)
+
+	if !strings.HasPrefix(report.Format, "RSS 2") && !strings.HasPrefix(report.Format, "RSS 0.9") {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%s feeds are not ingested by the aggregator yet", report.Format))
+	}
+}
+
+func (r *ValidationReport) addXMLError(decoder *xml.Decoder, err error) {
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		r.XMLErrors = append(r.XMLErrors, XMLError{Line: syntaxErr.Line, Message: syntaxErr.Msg})
+		return
+	}
+	line, _ := decoder.InputPos()
+	r.XMLErrors = append(r.XMLErrors, XMLError{Line: line, Message: err.Error()})
+}
+
+func (r *ValidationReport) checkEncoding(rawData []byte) {
+	declared := strings.ToUpper(r.Encoding)
+	if r.HeaderEncoding != "" && !strings.EqualFold(r.HeaderEncoding, r.Encoding) {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("Content-Type charset %q does not match XML declaration %q", r.HeaderEncoding, r.Encoding))
+	}
+	if declared == "UTF-8" || declared == "UTF8" {
+		if !utf8.Valid(rawData) {
+			r.Warnings = append(r.Warnings, "document is declared as UTF-8 but contains invalid UTF-8 sequences")
+		}
+	} else {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("document uses %s, text is stored without transcoding", r.Encoding))
+	}
+}
+
+func (r *ValidationReport) checkEntries(entries []feedEntry, refs []ItemRef) {
+	r.ItemCount = len(entries)
+	seenLinks := make(map[string]int)
+
+	for i, entry := range entries {
+		ref := refs[i]
+
+		link := entry.link()
+		if link == "" {
+			r.MissingLinks = append(r.MissingLinks, ref)
+		} else if first, ok := seenLinks[link]; ok {
+			r.addDuplicate(link, refs[first], ref)
+		} else {
+			seenLinks[link] = i
+		}
+
+		if entry.guid() == "" {
+			r.MissingGUIDs = append(r.MissingGUIDs, ref)
+		}
+
+		date := entry.date()
+		if date == "" {
+			r.MissingDates = append(r.MissingDates, ref)
+		} else if _, err := ParseDate(date); err != nil {
+			r.BadDates = append(r.BadDates, DateIssue{ItemRef: ref, Value: date})
+		}
+	}
+}
+
+func (r *ValidationReport) addDuplicate(link string, first, ref ItemRef) {
+	for i, dup := range r.DuplicateLinks {
+		if dup.Link == link {
+			r.DuplicateLinks[i].Items = append(r.DuplicateLinks[i].Items, ref)
+			return
+		}
+	}
+	r.DuplicateLinks = append(r.DuplicateLinks, DuplicateLink{Link: link, Items: []ItemRef{first, ref}})
+}
+
+func detectFormat(root xml.StartElement) string {
+	switch root.Name.Local {
+	case "rss":
+		for _, attr := range root.Attr {
+			if attr.Name.Local == "version" {
+				return "RSS " + attr.Value
+			}
+		}
+		return "RSS (no version)"
+	case "RDF":
+		return "RSS 1.0 (RDF)"
+	case "feed":
+		if root.Name.Space == atomNamespace {
+			return "Atom 1.0"
+		}
+		return "Atom (unknown namespace)"
+	default:
+		return fmt.Sprintf("unknown (<%s>)", root.Name.Local)
+	}
+}
+
+// procInstParam extracts a pseudo-attribute such as encoding="..." from the
+// body of an XML processing instruction.
+func procInstParam(inst, param string) string {
+	idx := strings.Index(inst, param)
+	if idx == -1 {
+		return ""
+	}
+	rest := strings.TrimLeft(inst[idx+len(param):], " \t\r\n")
+	if !strings.HasPrefix(rest, "=") {
+		return ""
+	}
+	rest = strings.TrimLeft(rest[1:], " \t\r\n")
+	if rest == "" || (rest[0] != '\'' && rest[0] != '"') {
+		return ""
+	}
+	quote := rest[0]
+	end := strings.IndexByte(rest[1:], quote)
+	if end == -1 {
+		return ""
+	}
+	return rest[1 : end+1]
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestInspectDocument(t *testing.T) {
+	doc := strings.Join([]string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<rss version="2.0"><channel><title>Test</title>`,
+		`<item><title>One</title><link>http://example.com/1</link><guid>1</guid><pubDate>Mon, 05 Oct 2026 10:00:00 +0000</pubDate></item>`,
+		`<item><title>Two</title><guid>2</guid><pubDate>yesterday</pubDate></item>`,
+		`<item><title>Three</title><link>http://example.com/1</link></item>`,
+		`</channel></rss>`,
+	}, "\n")
+
+	report := &ValidationReport{StatusCode: http.StatusOK}
+	inspectDocument(report, []byte(doc))
+
+	if report.Format != "RSS 2.0" || report.Encoding != "UTF-8" || report.ItemCount != 3 {
+		t.Errorf("format = %q, encoding = %q, items = %d, want RSS 2.0, UTF-8 and 3", report.Format, report.Encoding, report.ItemCount)
+	}
+
+	one := ItemRef{Index: 1, Line: 3, Title: "One"}
+	two := ItemRef{Index: 2, Line: 4, Title: "Two"}
+	three := ItemRef{Index: 3, Line: 5, Title: "Three"}
+	if !slices.Equal(report.MissingLinks, []ItemRef{two}) {
+		t.Errorf("missing links = %+v, want item 2", report.MissingLinks)
+	}
+	if !slices.Equal(report.MissingGUIDs, []ItemRef{three}) {
+		t.Errorf("missing GUIDs = %+v, want item 3", report.MissingGUIDs)
+	}
+	if !slices.Equal(report.MissingDates, []ItemRef{three}) {
+		t.Errorf("missing dates = %+v, want item 3", report.MissingDates)
+	}
+	if !slices.Equal(report.BadDates, []DateIssue{{ItemRef: two, Value: "yesterday"}}) {
+		t.Errorf("bad dates = %+v, want item 2", report.BadDates)
+	}
+	if len(report.DuplicateLinks) != 1 || !slices.Equal(report.DuplicateLinks[0].Items, []ItemRef{one, three}) {
+		t.Errorf("duplicate links = %+v, want items 1 and 3", report.DuplicateLinks)
+	}
+	if len(report.XMLErrors) != 0 || len(report.Warnings) != 0 {
+		t.Errorf("unexpected XML errors %+v or warnings %q", report.XMLErrors, report.Warnings)
+	}
+	if !report.HasProblems() {
+		t.Error("HasProblems = false for a feed with missing fields")
+	}
+}
+
+func TestInspectDocumentXMLError(t *testing.T) {
+	doc := "<rss version=\"2.0\"><channel>\n<item><title>One</title>\n</channel></rss>"
+
+	report := &ValidationReport{StatusCode: http.StatusOK}
+	inspectDocument(report, []byte(doc))
+
+	if len(report.XMLErrors) != 1 || report.XMLErrors[0].Line != 3 {
+		t.Errorf("XML errors = %+v, want one on line 3", report.XMLErrors)
+	}
+	if !report.HasProblems() {
+		t.Error("HasProblems = false for malformed XML")
+	}
+}
+
+func TestInspectDocumentWarnings(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="ISO-8859-1"?>` +
+		`<feed xmlns="http://www.w3.org/2005/Atom"><title>Test</title>` +
+		`<entry><title>One</title><link href="http://example.com/1"/><id>1</id><updated>2026-10-05T10:00:00Z</updated></entry>` +
+		`</feed>`
+
+	report := &ValidationReport{StatusCode: http.StatusOK, HeaderEncoding: "utf-8"}
+	inspectDocument(report, []byte(doc))
+
+	if report.Format != "Atom 1.0" || report.Encoding != "ISO-8859-1" {
+		t.Errorf("format = %q, encoding = %q, want Atom 1.0 and ISO-8859-1", report.Format, report.Encoding)
+	}
+	if len(report.MissingLinks) != 0 || len(report.MissingGUIDs) != 0 || len(report.MissingDates) != 0 {
+		t.Errorf("Atom entry fields not recognized: %+v", report)
+	}
+	for _, want := range []string{"does not match XML declaration", "without transcoding", "Atom 1.0 feeds are not ingested"} {
+		if !slices.ContainsFunc(report.Warnings, func(w string) bool { return strings.Contains(w, want) }) {
+			t.Errorf("warnings %q miss %q", report.Warnings, want)
+		}
+	}
+	if report.HasProblems() {
+		t.Error("HasProblems = true for a feed with warnings only")
+	}
+}
+
+func TestInspectDocumentInvalidUTF8(t *testing.T) {
+	doc := "<rss version=\"2.0\"><channel><item><title>Caf\xe9</title><link>http://example.com/1</link><guid>1</guid><pubDate>2026-10-05</pubDate></item></channel></rss>"
+
+	report := &ValidationReport{StatusCode: http.StatusOK}
+	inspectDocument(report, []byte(doc))
+
+	if !slices.ContainsFunc(report.Warnings, func(w string) bool { return strings.Contains(w, "invalid UTF-8") }) {
+		t.Errorf("warnings %q miss the invalid UTF-8", report.Warnings)
+	}
+}
+
+func TestProcInstParam(t *testing.T) {
+	for _, tt := range []struct {
+		inst string
+		want string
+	}{
+		{`version="1.0" encoding="UTF-8"`, "UTF-8"},
+		{`version='1.0' encoding = 'ISO-8859-1'`, "ISO-8859-1"},
+		{`version="1.0"`, ""},
+		{`version="1.0" encoding=UTF-8`, ""},
+		{`version="1.0" encoding="UTF-8`, ""},
+	} {
+		if got := procInstParam(tt.inst, "encoding"); got != tt.want {
+			t.Errorf("procInstParam(%q) = %q, want %q", tt.inst, got, tt.want)
+		}
+	}
+}
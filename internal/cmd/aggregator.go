@@ -0,0 +1,778 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Pizzu/gator/internal/api"
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/Pizzu/gator/internal/metrics"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	defaultConcurrency = 4
+	defaultPerHost     = 2
+	defaultLease       = 2 * time.Minute
+	maxIdlePoll        = 30 * time.Second
+
+	defaultShutdownTimeout = 10 * time.Second
+	defaultOneShotInterval = time.Hour // for never fetched feeds, when no interval is given
+	defaultDisableAfter    = 10
+	leaseReleaseTimeout    = 5 * time.Second
+	queueStatsTimeout      = 2 * time.Second
+)
+
+// errStorage marks scrape errors caused by the database rather than the feed.
+var errStorage = errors.New("storage error")
+
+// errLeaseLost is returned by a scrape that outlived its lease on the feed,
+// which another process may be fetching by now.
+var errLeaseLost = errors.New("lease on the feed was lost")
+
+// Handler aggregator
+func handlerAggregator(ctx context.Context, s *state, cmd command) error {
+	usage := fmt.Errorf("usage: %s [--once | --feed <url|name> | --following] [--concurrency n] [--per-host n] [--lease duration] [--shutdown-timeout duration] [--disable-after n] [--metrics-addr addr] [--health-addr addr] [--stale-after duration] [--no-jobs] <time_between_reqs>", cmd.Name)
+
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	once := flags.Bool("once", false, "fetch every due feed once and exit")
+	feedRef := flags.String("feed", "", "fetch a single feed, by url or name, right away and exit")
+	following := flags.Bool("following", false, "fetch every feed followed by the current user right away and exit")
+	concurrency := flags.Int("concurrency", defaultConcurrency, "number of feeds fetched in parallel")
+	perHost := flags.Int("per-host", defaultPerHost, "maximum parallel fetches against a single host")
+	lease := flags.Duration("lease", defaultLease, "how long a claimed feed stays reserved for this process")
+	shutdownTimeout := flags.Duration("shutdown-timeout", defaultShutdownTimeout, "how long in-flight fetches may run after a stop signal")
+	disableAfter := flags.Int("disable-after", defaultDisableAfter, "consecutive failures before a feed is disabled, 0 to never disable")
+	metricsAddr := flags.String("metrics-addr", "", "address to serve Prometheus metrics on, e.g. :9090")
+	healthAddr := flags.String("health-addr", "", "address to serve /healthz and /readyz on, e.g. :8080")
+	noJobs := flags.Bool("no-jobs", false, "don't run scheduled maintenance jobs")
+	staleAfter := flags.Duration("stale-after", 0, "report unhealthy when no feed was scraped successfully for this long, 0 to disable")
+
+	if err := flags.Parse(cmd.Args); err != nil {
+		return usage
+	}
+	if *concurrency < 1 || *perHost < 1 || *lease < time.Second || *shutdownTimeout < 0 || *disableAfter < 0 || *staleAfter < 0 {
+		return usage
+	}
+
+	modes := 0
+	for _, set := range []bool{*once, *feedRef != "", *following} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return errors.New("--once, --feed and --following can't be combined")
+	}
+	oneShot := modes == 1
+
+	// One-shot runs only need the interval to schedule the next fetch of
+	// feeds without their own, so it is optional for them. Without one,
+	// feeds keep the schedule of their last fetch, see baseInterval.
+	var timeBetweenRequests time.Duration
+	switch {
+	case flags.NArg() == 1:
+		d, err := time.ParseDuration(flags.Arg(0))
+		if err != nil {
+			return fmt.Errorf("invalid duration: %w", err)
+		}
+		// Feed intervals are stored in whole seconds, and a zero interval
+		// would have idle workers poll for due feeds without pause.
+		if d < time.Second {
+			return usage
+		}
+		timeBetweenRequests = d
+	case flags.NArg() > 1 || !oneShot:
+		return usage
+	}
+
+	var targets []database.Feed
+	var err error
+	switch {
+	case *feedRef != "":
+		targets, err = findFeed(ctx, s, *feedRef)
+	case *following:
+		targets, err = followedFeeds(ctx, s)
+	}
+	if err != nil {
+		return err
+	}
+
+	registry := prometheus.NewRegistry()
+	m := metrics.NewAggregator(registry, queueStats(s))
+	m.Workers.Set(float64(*concurrency))
+
+	agg := newAggregator(s, timeBetweenRequests, *perHost, *lease, m)
+	agg.disableAfter = *disableAfter
+	// A scrape never outlives its lease, so a loop that has been quiet for
+	// longer than a lease plus an idle poll is stuck.
+	agg.health = newHealthMonitor(s, *lease+agg.idlePoll, *staleAfter)
+
+	// Metrics and health checks share a server when given the same address.
+	muxes := make(map[string]*http.ServeMux)
+	muxFor := func(addr string) *http.ServeMux {
+		if muxes[addr] == nil {
+			muxes[addr] = http.NewServeMux()
+		}
+		return muxes[addr]
+	}
+	if *metricsAddr != "" {
+		muxFor(*metricsAddr).Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	}
+	if *healthAddr != "" {
+		agg.health.register(muxFor(*healthAddr))
+	}
+	for addr, mux := range muxes {
+		go serveHTTP(ctx, s, addr, mux)
+	}
+
+	switch {
+	case *once:
+		s.logger.Info("Collecting due feeds once...", "workers", *concurrency, "instance", agg.instanceID)
+	case oneShot:
+		s.logger.Info("Collecting feeds...", "feeds", len(targets), "workers", *concurrency, "instance", agg.instanceID)
+	default:
+		s.logger.Info("Collecting feeds...", "interval", timeBetweenRequests, "workers", *concurrency, "instance", agg.instanceID)
+	}
+
+	// In-flight scrapes run on their own context so that a stop signal only
+	// prevents new claims. They are aborted once the shutdown deadline passes.
+	workCtx, abortWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer abortWork()
+	agg.hooks = newHookDispatcher(workCtx, s)
+
+	stopAbort := context.AfterFunc(ctx, func() {
+		s.logger.Info("Shutting down, waiting for in-flight fetches...", "timeout", *shutdownTimeout)
+		time.AfterFunc(*shutdownTimeout, abortWork)
+	})
+	defer stopAbort()
+
+	// Maintenance jobs only run alongside the continuous aggregator, and
+	// only on the instance elected leader.
+	if !oneShot {
+		elector := newLeaderElector(s, agg.instanceID)
+		agg.health.leader = elector
+
+		elected := make(chan struct{})
+		go func() {
+			defer close(elected)
+			elector.run(ctx)
+		}()
+		defer func() { <-elected }()
+
+		if !*noJobs {
+			scheduler, err := startScheduler(workCtx, s, agg.instanceID, elector)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				<-scheduler.Stop().Done()
+			}()
+		}
+	}
+
+	queue := make(chan database.Feed)
+	go func() {
+		defer close(queue)
+		for _, feed := range targets {
+			select {
+			case queue <- feed:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if oneShot && !*once {
+				agg.workQueue(ctx, workCtx, queue)
+			} else {
+				agg.work(ctx, workCtx, *once)
+			}
+		}()
+	}
+	wg.Wait()
+	agg.hooks.wait()
+
+	agg.logSummary()
+
+	if oneShot && agg.failed.Load() > 0 {
+		return fmt.Errorf("%d feeds failed to fetch", agg.failed.Load())
+	}
+
+	return nil
+}
+
+// queueStats reads the size and lag of the fetch queue for the metrics
+// endpoint.
+func queueStats(s *state) metrics.QueueStats {
+	return func() (float64, float64) {
+		ctx, cancel := context.WithTimeout(context.Background(), queueStatsTimeout)
+		defer cancel()
+
+		stats, err := s.db.GetFetchQueueStats(ctx)
+		if err != nil {
+			s.logger.Error("Couldn't get fetch queue stats", "error", err)
+			return 0, 0
+		}
+		return float64(stats.DueFeeds), stats.OldestDueSeconds
+	}
+}
+
+// findFeed looks a feed up by url, falling back to its name.
+func findFeed(ctx context.Context, s *state, ref string) ([]database.Feed, error) {
+	feed, err := s.db.GetFeedByUrl(ctx, ref)
+	if err == nil {
+		return []database.Feed{feed}, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("couldn't find feed: %w", err)
+	}
+
+	feeds, err := s.db.GetFeedsByName(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't find feed: %w", err)
+	}
+
+	switch len(feeds) {
+	case 0:
+		return nil, fmt.Errorf("no feed with url or name %q", ref)
+	case 1:
+		return feeds, nil
+	default:
+		return nil, fmt.Errorf("%d feeds are named %q, use the url instead", len(feeds), ref)
+	}
+}
+
+// followedFeeds returns the feeds followed by the logged in user.
+func followedFeeds(ctx context.Context, s *state) ([]database.Feed, error) {
+	user, err := currentUser(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	follows, err := s.db.GetFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get followed feeds: %w", err)
+	}
+
+	feeds := make([]database.Feed, 0, len(follows))
+	for _, follow := range follows {
+		feeds = append(feeds, database.Feed{ID: follow.FeedID, Name: follow.FeedName})
+	}
+	return feeds, nil
+}
+
+// aggregator hands due feeds out to a pool of workers. A feed is due once its
+// next_fetch_at has passed; interval is used for feeds without their own.
+// Feeds are leased in the database, so several aggregator processes can
+// share the same feeds table.
+type aggregator struct {
+	s          *state
+	instanceID string
+	interval   time.Duration
+	lease      time.Duration
+	idlePoll   time.Duration
+	// disableAfter is the number of consecutive failures after which a
+	// feed stops being fetched, or 0 to keep retrying forever.
+	disableAfter int
+	hosts        *hostLimiter
+	metrics      *metrics.Aggregator
+	health       *healthMonitor
+	hooks        *hookDispatcher
+	startedAt    time.Time
+
+	fetched  atomic.Int64
+	failed   atomic.Int64
+	aborted  atomic.Int64
+	newPosts atomic.Int64
+}
+
+func newAggregator(s *state, interval time.Duration, perHost int, lease time.Duration, m *metrics.Aggregator) *aggregator {
+	return &aggregator{
+		s:          s,
+		metrics:    m,
+		instanceID: newInstanceID(),
+		interval:   interval,
+		lease:      lease,
+		idlePoll:   min(interval, maxIdlePoll),
+		hosts:      newHostLimiter(perHost),
+		startedAt:  time.Now(),
+	}
+}
+
+// newInstanceID identifies this process as the owner of its feed leases.
+func newInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.NewString()[:8])
+}
+
+// work claims and scrapes due feeds until ctx is cancelled, or until no feed
+// is due when once is set. The scrape itself runs on workCtx, which outlives
+// ctx by the shutdown deadline.
+func (a *aggregator) work(ctx, workCtx context.Context, once bool) {
+	for ctx.Err() == nil {
+		a.health.beat()
+		feed, err := a.claimFeed(ctx)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			if once {
+				return
+			}
+			sleepContext(ctx, a.idlePoll)
+			continue
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				a.s.logger.Error("Couldn't fetch next feed", "error", err)
+				sleepContext(ctx, a.idlePoll)
+			}
+			continue
+		}
+
+		a.process(workCtx, feed)
+	}
+}
+
+// workQueue scrapes the given feeds right away, whether they are due or not.
+func (a *aggregator) workQueue(ctx, workCtx context.Context, queue <-chan database.Feed) {
+	for target := range queue {
+		if ctx.Err() != nil {
+			return
+		}
+		a.health.beat()
+
+		feed, err := a.s.db.ClaimFeed(ctx, database.ClaimFeedParams{
+			ID:           target.ID,
+			LeaseOwner:   a.instanceID,
+			LeaseSeconds: durationSeconds(a.lease),
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			a.failed.Add(1)
+			if errors.Is(err, sql.ErrNoRows) {
+				err = errors.New("it is being fetched by another process")
+			}
+			a.s.logger.Error("Couldn't claim feed", "feed_id", target.ID, "feed", target.Name, "error", err)
+			continue
+		}
+
+		a.process(workCtx, feed)
+	}
+}
+
+func (a *aggregator) process(ctx context.Context, feed database.Feed) {
+	a.metrics.WorkersBusy.Inc()
+	defer a.metrics.WorkersBusy.Dec()
+	defer a.health.beat()
+
+	var result scrapeResult
+	startedAt := time.Now().UTC()
+
+	release, err := a.hosts.acquire(ctx, feed.Url)
+	if err == nil {
+		startedAt = time.Now().UTC()
+		result, err = scrapeFeed(ctx, a.s, feed, a.interval)
+		release()
+		a.newPosts.Add(int64(result.NewPosts))
+	}
+
+	a.metrics.BytesDownloaded.Add(float64(result.Bytes))
+
+	if ctx.Err() != nil {
+		// The scrape was cut short by shutdown, so hand the feed straight
+		// back instead of waiting for the lease to expire.
+		a.aborted.Add(1)
+		a.metrics.Fetches.WithLabelValues("aborted").Inc()
+		a.releaseLease(feed)
+		return
+	}
+
+	if errors.Is(err, errLeaseLost) {
+		// Another process owns the feed now and records its own fetch.
+		a.aborted.Add(1)
+		a.metrics.Fetches.WithLabelValues("aborted").Inc()
+		a.s.logger.Warn("Lost lease on feed, its posts were not saved",
+			"feed_id", feed.ID, "feed", feed.Name, "url", feed.Url, "duration", result.Duration, "lease", a.lease)
+		return
+	}
+
+	a.metrics.FetchDuration.Observe(time.Since(startedAt).Seconds())
+	a.logFetch(ctx, feed, startedAt, result, err)
+
+	// Recording the outcome also gives up the lease; successful scrapes do
+	// so as part of saving their posts. If the process dies before getting
+	// here, the lease expires and another worker reclaims the feed.
+	if err != nil {
+		a.failed.Add(1)
+		a.metrics.Fetches.WithLabelValues("failure").Inc()
+		a.metrics.Failures.WithLabelValues(failureReason(err)).Inc()
+		a.recordFailure(ctx, feed, result, err)
+		return
+	}
+
+	a.fetched.Add(1)
+	a.health.scraped()
+	// The first fetch of a feed brings in its whole history, which isn't
+	// news to anyone, so hooks only fire once the feed has been fetched.
+	if feed.LastSuccessAt.Valid {
+		a.hooks.dispatch(ctx, feed, result.Inserted)
+	}
+	a.metrics.Fetches.WithLabelValues("success").Inc()
+	a.metrics.PostsInserted.Add(float64(result.NewPosts))
+	a.metrics.PostsUpdated.Add(float64(result.UpdatedPosts))
+}
+
+// failureReason buckets a scrape error for the failures metric.
+func failureReason(err error) string {
+	var httpErr *api.HTTPError
+	var syntaxErr *xml.SyntaxError
+	var unmarshalErr xml.UnmarshalError
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, errStorage):
+		return "database"
+	case errors.As(err, &httpErr):
+		return fmt.Sprintf("http_%dxx", httpErr.StatusCode/100)
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	case errors.As(err, &syntaxErr), errors.As(err, &unmarshalErr):
+		return "parse"
+	default:
+		return "other"
+	}
+}
+
+// recordFailure stores the error on the feed and pushes its next fetch back
+// exponentially, disabling the feed once it has failed too many times in a row.
+func (a *aggregator) recordFailure(ctx context.Context, feed database.Feed, result scrapeResult, fetchErr error) {
+	failures := int(feed.ConsecutiveFailures) + 1
+	disable := a.disableAfter > 0 && failures >= a.disableAfter
+
+	_, err := a.s.db.MarkFeedFailed(ctx, database.MarkFeedFailedParams{
+		ID:             feed.ID,
+		BackoffSeconds: durationSeconds(failureBackoff(feed, a.interval, failures)),
+		LastError:      fetchErr.Error(),
+		HttpStatus:     result.httpStatus(),
+		Disable:        disable,
+		LeaseOwner:     feed.LeaseOwner.String,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		a.s.logger.Warn("Lost lease on feed, its failure was not saved",
+			"feed_id", feed.ID, "feed", feed.Name, "url", feed.Url, "lease", a.lease, "error", fetchErr)
+		return
+	}
+	if err != nil {
+		a.s.logger.Error("Couldn't save failed feed", "feed_id", feed.ID, "error", err)
+	}
+
+	a.s.logger.Error("Error while fetching feed",
+		"feed_id", feed.ID, "feed", feed.Name, "url", feed.Url, "status", result.HTTPStatus,
+		"duration", result.Duration, "failures", failures, "reason", failureReason(fetchErr), "error", fetchErr)
+	if disable {
+		a.s.logger.Warn("Feed disabled after too many consecutive failures, re-enable it with: feeds enable <url>",
+			"feed_id", feed.ID, "feed", feed.Name, "url", feed.Url, "failures", failures)
+	}
+}
+
+// logFetch appends the outcome of a scrape to the fetch log.
+func (a *aggregator) logFetch(ctx context.Context, feed database.Feed, startedAt time.Time, result scrapeResult, fetchErr error) {
+	fetchLog := database.CreateFetchLogParams{
+		ID:            uuid.New(),
+		FeedID:        feed.ID,
+		StartedAt:     startedAt,
+		DurationMs:    int32(time.Since(startedAt) / time.Millisecond),
+		HttpStatus:    result.httpStatus(),
+		BytesReceived: int64(result.Bytes),
+		ItemsParsed:   int32(result.Items),
+		NewPosts:      int32(result.NewPosts),
+	}
+	if fetchErr != nil {
+		fetchLog.Error = sql.NullString{String: fetchErr.Error(), Valid: true}
+	}
+
+	if err := a.s.db.CreateFetchLog(ctx, fetchLog); err != nil {
+		a.s.logger.Error("Couldn't write fetch log", "feed_id", feed.ID, "error", err)
+	}
+}
+
+// claimFeed leases the next due feed. Rows locked by other processes are
+// skipped, so concurrent claims never return the same feed.
+func (a *aggregator) claimFeed(ctx context.Context) (database.Feed, error) {
+	return a.s.db.ClaimNextFeedToFetch(ctx, database.ClaimNextFeedToFetchParams{
+		LeaseOwner:   a.instanceID,
+		LeaseSeconds: durationSeconds(a.lease),
+	})
+}
+
+func (a *aggregator) releaseLease(feed database.Feed) {
+	ctx, cancel := context.WithTimeout(context.Background(), leaseReleaseTimeout)
+	defer cancel()
+
+	err := a.s.db.ReleaseFeedLease(ctx, database.ReleaseFeedLeaseParams{
+		ID:         feed.ID,
+		LeaseOwner: sql.NullString{String: a.instanceID, Valid: true},
+	})
+	if err != nil {
+		a.s.logger.Error("Couldn't release lease on feed", "feed_id", feed.ID, "feed", feed.Name, "error", err)
+	}
+}
+
+func (a *aggregator) logSummary() {
+	a.s.logger.Info("Aggregator stopped",
+		"uptime", time.Since(a.startedAt).Round(time.Second),
+		"fetched", a.fetched.Load(),
+		"failed", a.failed.Load(),
+		"aborted", a.aborted.Load(),
+		"new_posts", a.newPosts.Load())
+}
+
+// sleepContext waits for d or until ctx is cancelled, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// hostLimiter caps the number of concurrent requests made against a single
+// host, however many workers are running.
+type hostLimiter struct {
+	mu    sync.Mutex
+	limit int
+	slots map[string]chan struct{}
+}
+
+func newHostLimiter(limit int) *hostLimiter {
+	return &hostLimiter{
+		limit: limit,
+		slots: make(map[string]chan struct{}),
+	}
+}
+
+func (h *hostLimiter) acquire(ctx context.Context, feedURL string) (release func(), err error) {
+	host := feedURL
+	if parsed, err := url.Parse(feedURL); err == nil && parsed.Host != "" {
+		host = strings.ToLower(parsed.Host)
+	}
+
+	h.mu.Lock()
+	slot, ok := h.slots[host]
+	if !ok {
+		slot = make(chan struct{}, h.limit)
+		h.slots[host] = slot
+	}
+	h.mu.Unlock()
+
+	select {
+	case slot <- struct{}{}:
+		return func() { <-slot }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// scrapeResult summarises a single scrape for the fetch log and the
+// feed's schedule.
+type scrapeResult struct {
+	HTTPStatus   int
+	Bytes        int
+	Items        int
+	NewPosts     int
+	UpdatedPosts int
+	Duration     time.Duration
+	// Inserted holds the posts seen for the first time, for the hooks.
+	Inserted []database.Post
+}
+
+func (r scrapeResult) httpStatus() sql.NullInt32 {
+	return sql.NullInt32{Int32: int32(r.HTTPStatus), Valid: r.HTTPStatus != 0}
+}
+
+// scrapeFeed fetches a feed and saves its posts. Posts are written in a
+// single transaction together with the feed's next fetch time, so a feed is
+// only marked fetched once all of its posts are stored. The result is filled
+// in as far as the scrape got, even when an error is returned.
+func scrapeFeed(ctx context.Context, s *state, feed database.Feed, defaultInterval time.Duration) (scrapeResult, error) {
+	startedAt := time.Now()
+	fetched, err := s.client.Fetch(ctx, feed.Url)
+
+	result := scrapeResult{HTTPStatus: fetched.StatusCode, Bytes: fetched.Bytes}
+
+	if err != nil {
+		result.Duration = time.Since(startedAt)
+		return result, err
+	}
+
+	items := uniqueItems(fetched.Feed.Channel.Item)
+	result.Items = len(fetched.Feed.Channel.Item)
+
+	err = s.db.InTx(ctx, func(q database.Querier) error {
+		if err := adoptLegacyPosts(ctx, q, feed, items); err != nil {
+			return err
+		}
+
+		inserted, updatedPosts, err := savePosts(ctx, q, feed, items)
+		if err != nil {
+			return fmt.Errorf("couldn't save posts: %w", err)
+		}
+		result.Inserted = inserted
+		result.NewPosts, result.UpdatedPosts = len(inserted), updatedPosts
+
+		_, err = q.MarkFeedFetched(ctx, database.MarkFeedFetchedParams{
+			ID:                  feed.ID,
+			PollIntervalSeconds: durationSeconds(nextPollInterval(feed, defaultInterval, len(inserted))),
+			HttpStatus:          int32(result.HTTPStatus),
+			LeaseOwner:          feed.LeaseOwner.String,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// Rolling back leaves the posts to the process holding the
+			// lease now.
+			return errLeaseLost
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't save fetched feed: %w", err)
+		}
+		return nil
+	})
+	result.Duration = time.Since(startedAt)
+	if errors.Is(err, errLeaseLost) {
+		result.Inserted, result.NewPosts, result.UpdatedPosts = nil, 0, 0
+		return result, err
+	}
+	if err != nil {
+		result.Inserted, result.NewPosts, result.UpdatedPosts = nil, 0, 0
+		return result, fmt.Errorf("%w: %w", errStorage, err)
+	}
+
+	s.logger.Info("Feed collected",
+		"feed_id", feed.ID, "feed", feed.Name, "url", feed.Url, "status", result.HTTPStatus,
+		"duration", result.Duration, "items", result.Items, "new_posts", result.NewPosts, "updated_posts", result.UpdatedPosts)
+	return result, nil
+}
+
+// uniqueItems drops items repeating the key of an earlier item, which a
+// single upsert statement cannot handle.
+func uniqueItems(items []api.RSSItem) []api.RSSItem {
+	seen := make(map[string]bool, len(items))
+	unique := make([]api.RSSItem, 0, len(items))
+	for _, item := range items {
+		if key := item.Key(); !seen[key] {
+			seen[key] = true
+			unique = append(unique, item)
+		}
+	}
+	return unique
+}
+
+// adoptLegacyPosts gives posts saved before items were keyed by GUID the key
+// of the item with the same url, the first time it shows up again.
+func adoptLegacyPosts(ctx context.Context, q database.Querier, feed database.Feed, items []api.RSSItem) error {
+	legacyUrls, err := q.GetLegacyPostUrls(ctx, feed.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get legacy posts: %w", err)
+	}
+	if len(legacyUrls) == 0 {
+		return nil
+	}
+
+	legacy := make(map[string]bool, len(legacyUrls))
+	for _, url := range legacyUrls {
+		legacy[url] = true
+	}
+
+	for _, item := range items {
+		if !legacy[item.Link] {
+			continue
+		}
+		err := q.AdoptLegacyPost(ctx, database.AdoptLegacyPostParams{
+			FeedID: feed.ID,
+			Url:    item.Link,
+			Guid:   sql.NullString{String: item.Key(), Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't adopt legacy post: %w", err)
+		}
+		delete(legacy, item.Link)
+	}
+	return nil
+}
+
+// savePosts upserts all items in one statement. Unchanged posts are left
+// alone; it returns the inserted posts and how many were updated.
+func savePosts(ctx context.Context, q database.Querier, feed database.Feed, items []api.RSSItem) ([]database.Post, int, error) {
+	if len(items) == 0 {
+		return nil, 0, nil
+	}
+
+	params := database.UpsertPostsParams{
+		CreatedAt: time.Now().UTC(),
+		FeedID:    feed.ID,
+	}
+	ids := make(map[uuid.UUID]bool, len(items))
+
+	for _, item := range items {
+		publishedAt := ""
+		if t, err := api.ParseDate(item.PubDate); err == nil {
+			publishedAt = t.UTC().Format(time.RFC3339)
+		}
+
+		id := uuid.New()
+		ids[id] = true
+
+		params.Ids = append(params.Ids, id)
+		params.Titles = append(params.Titles, item.Title)
+		params.Urls = append(params.Urls, item.Link)
+		params.Descriptions = append(params.Descriptions, item.Description)
+		params.PublishedAts = append(params.PublishedAts, publishedAt)
+		params.Guids = append(params.Guids, item.Key())
+		params.ContentHashes = append(params.ContentHashes, item.ContentHash())
+	}
+
+	saved, err := q.UpsertPosts(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Inserted rows keep the id generated here, updated ones return their
+	// original id.
+	var inserted []database.Post
+	for _, post := range saved {
+		if ids[post.ID] {
+			inserted = append(inserted, post)
+		}
+	}
+	return inserted, len(saved) - len(inserted), nil
+}
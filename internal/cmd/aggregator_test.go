@@ -0,0 +1,808 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Pizzu/gator/internal/api"
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/Pizzu/gator/internal/hooks"
+	"github.com/Pizzu/gator/internal/metrics"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestHandlerAggregatorOnce(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	server := newFeedServer(t,
+		testItem{title: "One", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Two", guid: "2", pubDate: "Tue, 06 Oct 2026 10:00:00 +0000"},
+	)
+	feed := env.addFeed(t, "Blog", server.URL)
+
+	if _, err := env.run(t, handlerAggregator, "agg", "--once"); err != nil {
+		t.Fatalf("agg --once: %v", err)
+	}
+
+	posts, _ := env.store.GetPostsForUser(context.Background(), database.GetPostsForUserParams{UserID: user.ID, PageSize: 10})
+	if len(posts) != 2 || posts[0].Title != "Two" {
+		t.Errorf("posts = %+v, want Two and One", posts)
+	}
+
+	fetched, _ := env.store.GetFeedByUrl(context.Background(), feed.Url)
+	if !fetched.LastSuccessAt.Valid || !fetched.NextFetchAt.Valid || fetched.LeaseOwner.Valid {
+		t.Errorf("feed not marked fetched: %+v", fetched)
+	}
+	if fetched.LastHttpStatus.Int32 != http.StatusOK {
+		t.Errorf("last HTTP status = %d, want 200", fetched.LastHttpStatus.Int32)
+	}
+
+	// The feed is not due again until the interval passed.
+	if _, err := env.run(t, handlerAggregator, "agg", "--once"); err != nil {
+		t.Fatalf("second agg --once: %v", err)
+	}
+	entries, _ := env.store.GetFetchLogForFeed(context.Background(), database.GetFetchLogForFeedParams{FeedID: feed.ID, Limit: 10})
+	if len(entries) != 1 || entries[0].NewPosts != 2 || entries[0].ItemsParsed != 2 {
+		t.Errorf("fetch log = %+v, want one fetch with 2 new posts", entries)
+	}
+}
+
+func TestHandlerAggregatorFeed(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	server := newFeedServer(t, testItem{title: "One", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"})
+	env.addFeed(t, "Blog", server.URL)
+
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", "Blog"); err != nil {
+		t.Fatalf("agg --feed: %v", err)
+	}
+
+	server.setItems(
+		testItem{title: "One, edited", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Two", guid: "2", pubDate: "Tue, 06 Oct 2026 10:00:00 +0000"},
+	)
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", server.URL); err != nil {
+		t.Fatalf("agg --feed: %v", err)
+	}
+
+	posts, _ := env.store.GetPostsForUser(context.Background(), database.GetPostsForUserParams{UserID: user.ID, PageSize: 10})
+	if len(posts) != 2 {
+		t.Fatalf("got %d posts, want 2", len(posts))
+	}
+	if posts[1].Title != "One, edited" {
+		t.Errorf("post title = %q, want the edited title", posts[1].Title)
+	}
+	if logs := env.logs.String(); !strings.Contains(logs, "new_posts=1 updated_posts=1") {
+		t.Errorf("second fetch should report 1 new and 1 updated post:\n%s", logs)
+	}
+
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", "Missing"); err == nil {
+		t.Error("agg --feed with an unknown feed succeeded")
+	}
+}
+
+func TestUniqueItems(t *testing.T) {
+	items := []api.RSSItem{
+		{Title: "One", Link: "http://example.com/1", GUID: "1"},
+		{Title: "Two", Link: "http://example.com/2", GUID: "2"},
+		{Title: "One, again", Link: "http://example.com/1", GUID: "1"},
+		{Title: "No guid", Link: "http://example.com/a"},
+		{Title: "No guid", Link: "http://example.com/a"},
+	}
+
+	unique := uniqueItems(items)
+	var titles []string
+	for _, item := range unique {
+		titles = append(titles, item.Title)
+	}
+	if got := strings.Join(titles, ","); got != "One,Two,No guid" {
+		t.Errorf("uniqueItems kept %s, want the first item of each key", got)
+	}
+}
+
+func TestHandlerAggregatorDuplicateItems(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	server := newFeedServer(t,
+		testItem{title: "One", guid: "1"},
+		testItem{title: "One, repeated", guid: "1"},
+	)
+	env.addFeed(t, "Blog", server.URL)
+
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", server.URL); err != nil {
+		t.Fatalf("agg --feed: %v", err)
+	}
+	posts, _ := env.store.GetPostsForUser(context.Background(), database.GetPostsForUserParams{UserID: user.ID, PageSize: 10})
+	if len(posts) != 1 || posts[0].Title != "One" {
+		t.Errorf("posts = %+v, want the first of the repeated items", posts)
+	}
+}
+
+func TestHandlerAggregatorAdoptsLegacyPosts(t *testing.T) {
+	// Posts saved before items had keys have no guid, which only a real
+	// database can hold.
+	env := newSQLiteEnv(t)
+	ctx := context.Background()
+	if _, err := env.run(t, handlerMigrate, "migrate", "up"); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	if _, err := env.run(t, handlerRegister, "register", "alan"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	server := newFeedServer(t, testItem{title: "One", guid: "1"}, testItem{title: "Two", guid: "2"})
+	if _, err := env.run(t, middlewareLoggedIn(handlerAddFeed), "addfeed", "Blog", server.URL); err != nil {
+		t.Fatalf("addfeed: %v", err)
+	}
+	feed, err := env.s.db.GetFeedByUrl(ctx, server.URL)
+	if err != nil {
+		t.Fatalf("get feed: %v", err)
+	}
+
+	now := time.Now().UTC()
+	_, err = env.s.conn.ExecContext(ctx,
+		"INSERT INTO posts (id, created_at, updated_at, title, url, feed_id) VALUES (?, ?, ?, ?, ?, ?)",
+		uuid.New(), now, now, "One", "http://example.com/1", feed.ID)
+	if err != nil {
+		t.Fatalf("insert legacy post: %v", err)
+	}
+
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", server.URL); err != nil {
+		t.Fatalf("agg --feed: %v", err)
+	}
+	if logs := env.logs.String(); !strings.Contains(logs, "new_posts=1 updated_posts=1") {
+		t.Errorf("the legacy post should be updated, not saved again:\n%s", logs)
+	}
+	if urls, err := env.s.db.GetLegacyPostUrls(ctx, feed.ID); err != nil || len(urls) != 0 {
+		t.Errorf("legacy posts left = %v, %v, want none", urls, err)
+	}
+	var count int
+	if err := env.s.conn.QueryRowContext(ctx, "SELECT count(*) FROM posts WHERE url = ?", "http://example.com/1").Scan(&count); err != nil || count != 1 {
+		t.Errorf("posts for the legacy url = %d, %v, want 1", count, err)
+	}
+}
+
+func TestSavePosts(t *testing.T) {
+	// UpsertPosts is a single statement on Postgres, one per post on SQLite
+	// and plain Go in the memory store; all report the same counts.
+	for name, setup := range map[string]func(t *testing.T) (database.Store, database.Feed){
+		"memory": func(t *testing.T) (database.Store, database.Feed) {
+			env := newTestEnv(t)
+			env.register(t, "alan")
+			return env.store, env.addFeed(t, "Blog", "https://example.com/feed.xml")
+		},
+		"sqlite": func(t *testing.T) (database.Store, database.Feed) {
+			env := newSQLiteEnv(t)
+			for _, step := range []struct {
+				handler func(context.Context, *state, command) error
+				args    []string
+			}{
+				{handlerMigrate, []string{"migrate", "up"}},
+				{handlerRegister, []string{"register", "alan"}},
+				{middlewareLoggedIn(handlerAddFeed), []string{"addfeed", "Blog", "https://example.com/feed.xml"}},
+			} {
+				if _, err := env.run(t, step.handler, step.args[0], step.args[1:]...); err != nil {
+					t.Fatalf("%v: %v", step.args, err)
+				}
+			}
+			feed, err := env.s.db.GetFeedByUrl(context.Background(), "https://example.com/feed.xml")
+			if err != nil {
+				t.Fatalf("get feed: %v", err)
+			}
+			return env.s.db, feed
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			store, feed := setup(t)
+			ctx := context.Background()
+			save := func(items ...testItem) ([]database.Post, int) {
+				t.Helper()
+				var inserted []database.Post
+				var updated int
+				err := store.InTx(ctx, func(q database.Querier) error {
+					var err error
+					inserted, updated, err = savePosts(ctx, q, feed, rssItems(items))
+					return err
+				})
+				if err != nil {
+					t.Fatalf("save posts: %v", err)
+				}
+				return inserted, updated
+			}
+
+			inserted, updated := save(
+				testItem{title: "One", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"},
+				testItem{title: "Two", guid: "2", pubDate: "not a date"},
+			)
+			if len(inserted) != 2 || updated != 0 {
+				t.Fatalf("first save: %d inserted, %d updated, want 2 and 0", len(inserted), updated)
+			}
+			for _, post := range inserted {
+				if post.PublishedAt.Valid != (post.Title == "One") {
+					t.Errorf("post %s published_at = %+v", post.Title, post.PublishedAt)
+				}
+			}
+
+			inserted, updated = save(
+				testItem{title: "One", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"},
+				testItem{title: "Two, edited", guid: "2", pubDate: "not a date"},
+				testItem{title: "Three", guid: "3"},
+			)
+			if len(inserted) != 1 || inserted[0].Title != "Three" || updated != 1 {
+				t.Errorf("second save: inserted %+v and %d updated, want Three and 1", inserted, updated)
+			}
+
+			if inserted, updated := save(); inserted != nil || updated != 0 {
+				t.Errorf("saving no items: %+v, %d", inserted, updated)
+			}
+
+			// Nothing is saved when the transaction fails.
+			errFailed := errors.New("failed")
+			err := store.InTx(ctx, func(q database.Querier) error {
+				if _, _, err := savePosts(ctx, q, feed, rssItems([]testItem{{title: "Four", guid: "4"}})); err != nil {
+					return err
+				}
+				return errFailed
+			})
+			if !errors.Is(err, errFailed) {
+				t.Fatalf("failed transaction: err = %v", err)
+			}
+			if inserted, _ := save(testItem{title: "Four", guid: "4"}); len(inserted) != 1 {
+				t.Errorf("post of a rolled back transaction was kept: inserted %+v", inserted)
+			}
+		})
+	}
+}
+
+func TestHandlerAggregatorKeepsSchedule(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	server := newFeedServer(t, testItem{title: "One", guid: "1"})
+	feed := env.addFeed(t, "Blog", server.URL)
+
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", server.URL, "2h"); err != nil {
+		t.Fatalf("agg --feed 2h: %v", err)
+	}
+
+	// Without an interval, the feed keeps the one it was fetched with.
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", server.URL); err != nil {
+		t.Fatalf("agg --feed: %v", err)
+	}
+	fetched, _ := env.store.GetFeedByUrl(context.Background(), feed.Url)
+	if fetched.PollIntervalSeconds.Int32 != 7200 {
+		t.Errorf("poll interval = %ds, want the 2h of the previous run", fetched.PollIntervalSeconds.Int32)
+	}
+	if next := time.Until(fetched.NextFetchAt.Time); next < time.Hour+59*time.Minute {
+		t.Errorf("next fetch in %s, want 2h", next)
+	}
+}
+
+func TestHandlerAggregatorConcurrency(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+
+	// Every feed answers once all of them are being fetched, so they must
+	// be fetched in parallel.
+	const feeds = 3
+	var mu sync.Mutex
+	inFlight := 0
+	allIn := make(chan struct{})
+	for i := range feeds {
+		server := newFeedServer(t, testItem{title: fmt.Sprintf("Post %d", i), guid: "1"})
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			if inFlight++; inFlight == feeds {
+				close(allIn)
+			}
+			mu.Unlock()
+			select {
+			case <-allIn:
+			case <-time.After(5 * time.Second):
+				t.Error("feeds were not fetched in parallel")
+			}
+			server.Config.Handler.ServeHTTP(w, r)
+		}))
+		t.Cleanup(proxy.Close)
+		env.addFeed(t, fmt.Sprintf("Feed %d", i), proxy.URL)
+	}
+
+	if _, err := env.run(t, handlerAggregator, "agg", "--concurrency", strconv.Itoa(feeds), "--once"); err != nil {
+		t.Fatalf("agg --once: %v", err)
+	}
+	if logs := env.logs.String(); strings.Count(logs, "Feed collected") != feeds {
+		t.Errorf("not every feed was collected:\n%s", logs)
+	}
+}
+
+func TestHostLimiter(t *testing.T) {
+	limiter := newHostLimiter(2)
+	ctx := context.Background()
+
+	var releases []func()
+	for range 2 {
+		release, err := limiter.acquire(ctx, "https://example.com/feed.xml")
+		if err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		releases = append(releases, release)
+	}
+
+	// Other hosts have their own slots.
+	release, err := limiter.acquire(ctx, "https://EXAMPLE.org/feed.xml")
+	if err != nil {
+		t.Fatalf("acquire another host: %v", err)
+	}
+	release()
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(timeout, "https://example.com/other.xml"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire over the limit: err = %v, want context.DeadlineExceeded", err)
+	}
+
+	releases[0]()
+	release, err = limiter.acquire(ctx, "https://example.com/other.xml")
+	if err != nil {
+		t.Fatalf("acquire after a release: %v", err)
+	}
+	release()
+	releases[1]()
+}
+
+func TestHandlerAggregatorFollowing(t *testing.T) {
+	env := newTestEnv(t)
+	alan := env.register(t, "alan")
+	followed := newFeedServer(t, testItem{title: "Followed", guid: "1"})
+	env.addFeed(t, "Followed", followed.URL)
+
+	env.register(t, "bob")
+	other := newFeedServer(t, testItem{title: "Other", guid: "1"})
+	otherFeed := env.addFeed(t, "Other", other.URL)
+
+	if _, err := env.run(t, handlerLogin, "login", "alan"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.run(t, handlerAggregator, "agg", "--following"); err != nil {
+		t.Fatalf("agg --following: %v", err)
+	}
+
+	posts, _ := env.store.GetPostsForUser(context.Background(), database.GetPostsForUserParams{UserID: alan.ID, PageSize: 10})
+	if len(posts) != 1 || posts[0].Title != "Followed" {
+		t.Errorf("posts = %+v, want the followed feed's post", posts)
+	}
+	if fetched, _ := env.store.GetFeedByUrl(context.Background(), otherFeed.Url); fetched.LastFetchedAt.Valid {
+		t.Error("agg --following fetched a feed alan doesn't follow")
+	}
+}
+
+func TestFindFeed(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	blog := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	env.addFeed(t, "News", "https://example.com/news.xml")
+	env.addFeed(t, "News", "https://example.org/news.xml")
+	ctx := context.Background()
+
+	for _, ref := range []string{blog.Url, "Blog"} {
+		feeds, err := findFeed(ctx, env.s, ref)
+		if err != nil || len(feeds) != 1 || feeds[0].ID != blog.ID {
+			t.Errorf("findFeed(%q) = %+v, %v, want the Blog feed", ref, feeds, err)
+		}
+	}
+	if feeds, err := findFeed(ctx, env.s, "https://example.org/news.xml"); err != nil || len(feeds) != 1 {
+		t.Errorf("findFeed by the url of a feed sharing its name = %+v, %v", feeds, err)
+	}
+	if _, err := findFeed(ctx, env.s, "News"); err == nil || !strings.Contains(err.Error(), "2 feeds are named") {
+		t.Errorf("findFeed of an ambiguous name: err = %v", err)
+	}
+	if _, err := findFeed(ctx, env.s, "Missing"); err == nil {
+		t.Error("findFeed of an unknown feed succeeded")
+	}
+}
+
+func TestHandlerAggregatorFeedNotDue(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	server := newFeedServer(t, testItem{title: "One", guid: "1"})
+	feed := env.addFeed(t, "Blog", server.URL)
+
+	if _, err := env.run(t, handlerAggregator, "agg", "--once"); err != nil {
+		t.Fatalf("agg --once: %v", err)
+	}
+
+	// --once skips the feed until it is due again, --feed fetches it now.
+	server.setItems(testItem{title: "One", guid: "1"}, testItem{title: "Two", guid: "2"})
+	if _, err := env.run(t, handlerAggregator, "agg", "--once"); err != nil {
+		t.Fatalf("second agg --once: %v", err)
+	}
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", feed.Url); err != nil {
+		t.Fatalf("agg --feed: %v", err)
+	}
+	entries, _ := env.store.GetFetchLogForFeed(context.Background(), database.GetFetchLogForFeedParams{FeedID: feed.ID, Limit: 10})
+	if len(entries) != 2 || entries[0].NewPosts != 1 {
+		t.Errorf("fetch log = %+v, want the --once fetch and the --feed fetch", entries)
+	}
+}
+
+func TestHandlerAggregatorFollowingLoggedOut(t *testing.T) {
+	env := newTestEnv(t)
+	if _, err := env.run(t, handlerAggregator, "agg", "--following"); err == nil {
+		t.Error("agg --following without a logged in user succeeded")
+	}
+}
+
+func TestHandlerAggregatorFailure(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	server := newFeedServer(t)
+	server.setStatus(http.StatusInternalServerError)
+	feed := env.addFeed(t, "Blog", server.URL)
+
+	_, err := env.run(t, handlerAggregator, "agg", "--disable-after", "1", "--once")
+	if err == nil || !strings.Contains(err.Error(), "1 feeds failed") {
+		t.Fatalf("agg --once: err = %v, want a failed feed", err)
+	}
+
+	failed, _ := env.store.GetFeedByUrl(context.Background(), feed.Url)
+	if failed.ConsecutiveFailures != 1 || !failed.DisabledAt.Valid || failed.LastHttpStatus.Int32 != http.StatusInternalServerError {
+		t.Errorf("feed not marked failed and disabled: %+v", failed)
+	}
+	entries, _ := env.store.GetFetchLogForFeed(context.Background(), database.GetFetchLogForFeedParams{FeedID: feed.ID, Limit: 10})
+	if len(entries) != 1 || !entries[0].Error.Valid {
+		t.Errorf("fetch log = %+v, want one failed fetch", entries)
+	}
+}
+
+func TestFailureReason(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("couldn't save posts: %w", errStorage), "database"},
+		{fmt.Errorf("couldn't fetch feed: %w", &api.HTTPError{StatusCode: 404, Status: "404 Not Found"}), "http_4xx"},
+		{&api.HTTPError{StatusCode: 503, Status: "503 Service Unavailable"}, "http_5xx"},
+		{fmt.Errorf("couldn't fetch feed: %w", context.DeadlineExceeded), "timeout"},
+		{&net.DNSError{Err: "i/o timeout", IsTimeout: true}, "timeout"},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, "network"},
+		{&xml.SyntaxError{Msg: "unexpected EOF", Line: 1}, "parse"},
+		{xml.UnmarshalError("unknown element"), "parse"},
+		{errors.New("something else"), "other"},
+	} {
+		if got := failureReason(tc.err); got != tc.want {
+			t.Errorf("failureReason(%v) = %s, want %s", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestAggregatorMetrics(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	ok := newFeedServer(t, testItem{title: "One", guid: "1"}, testItem{title: "Two", guid: "2"})
+	env.addFeed(t, "Blog", ok.URL)
+	failing := newFeedServer(t)
+	failing.setStatus(http.StatusBadGateway)
+	env.addFeed(t, "Down", failing.URL)
+
+	registry := prometheus.NewRegistry()
+	m := metrics.NewAggregator(registry, func() (float64, float64) { return 0, 0 })
+	agg := newAggregator(env.s, time.Hour, 2, time.Minute, m)
+	agg.hooks = newHookDispatcher(context.Background(), env.s)
+	defer agg.hooks.wait()
+	agg.health = newHealthMonitor(env.s, time.Minute, time.Hour)
+	agg.work(context.Background(), context.Background(), true)
+
+	for _, tc := range []struct {
+		name string
+		got  prometheus.Collector
+		want float64
+	}{
+		{"success fetches", m.Fetches.WithLabelValues("success"), 1},
+		{"failed fetches", m.Fetches.WithLabelValues("failure"), 1},
+		{"5xx failures", m.Failures.WithLabelValues("http_5xx"), 1},
+		{"inserted posts", m.PostsInserted, 2},
+		{"busy workers", m.WorkersBusy, 0},
+	} {
+		if got := metricValueForTest(t, tc.got); got != tc.want {
+			t.Errorf("%s = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if got := metricValueForTest(t, m.BytesDownloaded); got == 0 {
+		t.Error("no downloaded bytes were counted")
+	}
+}
+
+// metricValueForTest reads the value of a counter or gauge.
+func metricValueForTest(t *testing.T, c prometheus.Collector) float64 {
+	t.Helper()
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil || len(families) != 1 {
+		t.Fatalf("gather metric: %v", err)
+	}
+	metric := families[0].GetMetric()[0]
+	if metric.Counter != nil {
+		return metric.GetCounter().GetValue()
+	}
+	return metric.GetGauge().GetValue()
+}
+
+func TestHandlerAggregatorLeasedFeed(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	server := newFeedServer(t, testItem{title: "One", guid: "1"})
+	feed := env.addFeed(t, "Blog", server.URL)
+	ctx := context.Background()
+
+	if _, err := env.store.ClaimFeed(ctx, database.ClaimFeedParams{ID: feed.ID, LeaseOwner: "other", LeaseSeconds: 60}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A feed leased by another process is left to it.
+	if _, err := env.run(t, handlerAggregator, "agg", "--once"); err != nil {
+		t.Fatalf("agg --once: %v", err)
+	}
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", server.URL); err == nil {
+		t.Error("agg --feed on a leased feed succeeded")
+	}
+	if posts, _ := env.store.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: user.ID, PageSize: 10}); len(posts) != 0 {
+		t.Errorf("a leased feed was fetched: %+v", posts)
+	}
+	if logs := env.logs.String(); !strings.Contains(logs, "it is being fetched by another process") {
+		t.Errorf("agg --feed should report the feed is leased:\n%s", logs)
+	}
+
+	// Once the lease expires, the feed is claimed again.
+	env.store.Now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, err := env.run(t, handlerAggregator, "agg", "--once"); err != nil {
+		t.Fatalf("agg --once after the lease expired: %v", err)
+	}
+	if posts, _ := env.store.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: user.ID, PageSize: 10}); len(posts) != 1 {
+		t.Errorf("got %d posts, want the feed fetched once its lease expired", len(posts))
+	}
+	if fetched, _ := env.store.GetFeedByUrl(ctx, feed.Url); fetched.LeaseOwner.Valid || fetched.LeaseExpiresAt.Valid {
+		t.Errorf("lease not given up after fetching: %+v", fetched)
+	}
+}
+
+func TestHandlerAggregatorLostLease(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	server := newFeedServer(t, testItem{title: "One", guid: "1"})
+	ctx := context.Background()
+
+	// The proxy hands the feed's lease to another process while the
+	// aggregator is fetching it.
+	var feed database.Feed
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claimed, _ := env.store.GetFeedByUrl(ctx, feed.Url)
+		env.store.ReleaseFeedLease(ctx, database.ReleaseFeedLeaseParams{ID: feed.ID, LeaseOwner: claimed.LeaseOwner})
+		if _, err := env.store.ClaimFeed(ctx, database.ClaimFeedParams{ID: feed.ID, LeaseOwner: "other", LeaseSeconds: 60}); err != nil {
+			t.Errorf("claim feed: %v", err)
+		}
+		server.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+	feed = env.addFeed(t, "Blog", proxy.URL)
+
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", proxy.URL); err != nil {
+		t.Fatalf("agg --feed: %v", err)
+	}
+	if posts, _ := env.store.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: user.ID, PageSize: 10}); len(posts) != 0 {
+		t.Errorf("posts of a fetch that lost its lease were saved: %+v", posts)
+	}
+	if fetched, _ := env.store.GetFeedByUrl(ctx, feed.Url); fetched.LastSuccessAt.Valid || fetched.LeaseOwner.String != "other" {
+		t.Errorf("feed marked fetched without holding its lease: %+v", fetched)
+	}
+
+	env.store.ReleaseFeedLease(ctx, database.ReleaseFeedLeaseParams{ID: feed.ID, LeaseOwner: sql.NullString{String: "other", Valid: true}})
+	server.setStatus(http.StatusInternalServerError)
+	env.run(t, handlerAggregator, "agg", "--feed", proxy.URL)
+	if fetched, _ := env.store.GetFeedByUrl(ctx, feed.Url); fetched.ConsecutiveFailures != 0 || fetched.LeaseOwner.String != "other" {
+		t.Errorf("feed marked failed without holding its lease: %+v", fetched)
+	}
+
+	logs := env.logs.String()
+	for _, want := range []string{"Lost lease on feed, its posts were not saved", "Lost lease on feed, its failure was not saved"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("logs miss %q:\n%s", want, logs)
+		}
+	}
+}
+
+// startAggregatorForTest runs agg in the background until ctx is
+// cancelled. The returned channel receives agg's error.
+func startAggregatorForTest(t *testing.T, env *testEnv, ctx context.Context, args ...string) <-chan error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() {
+		done <- handlerAggregator(ctx, env.s, command{Name: "agg", Args: args})
+	}()
+	return done
+}
+
+func TestHandlerAggregatorShutdown(t *testing.T) {
+	// The continuous aggregator elects a leader, which the in-memory store
+	// can't, so this runs on SQLite.
+	env := newSQLiteEnv(t)
+	if _, err := env.run(t, handlerMigrate, "migrate", "up"); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	if _, err := env.run(t, handlerRegister, "register", "alan"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	server := newFeedServer(t, testItem{title: "One", guid: "1"})
+
+	fetching := make(chan struct{}, 1)
+	finish := make(chan struct{})
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetching <- struct{}{}
+		select {
+		case <-finish:
+			server.Config.Handler.ServeHTTP(w, r)
+		case <-r.Context().Done():
+		}
+	}))
+	defer proxy.Close()
+	if _, err := env.run(t, middlewareLoggedIn(handlerAddFeed), "addfeed", "Blog", proxy.URL); err != nil {
+		t.Fatalf("addfeed: %v", err)
+	}
+
+	// A fetch still running when the shutdown timeout passes is aborted
+	// and its lease handed back.
+	ctx, stop := context.WithCancel(context.Background())
+	done := startAggregatorForTest(t, env, ctx, "--no-jobs", "--shutdown-timeout", "10ms", "1h")
+	<-fetching
+	stop()
+	if err := <-done; err != nil {
+		t.Fatalf("agg: %v", err)
+	}
+	aborted, _ := env.s.db.GetFeedByUrl(context.Background(), proxy.URL)
+	if aborted.LastFetchedAt.Valid || aborted.LeaseOwner.Valid {
+		t.Errorf("aborted fetch not handed back: %+v", aborted)
+	}
+	if logs := env.logs.String(); !strings.Contains(logs, "Shutting down") || !strings.Contains(logs, "aborted=1") {
+		t.Errorf("logs miss the aborted shutdown:\n%s", logs)
+	}
+
+	// One that finishes within the timeout is saved.
+	ctx, stop = context.WithCancel(context.Background())
+	done = startAggregatorForTest(t, env, ctx, "--no-jobs", "--shutdown-timeout", "5s", "1h")
+	<-fetching
+	stop()
+	close(finish)
+	if err := <-done; err != nil {
+		t.Fatalf("agg: %v", err)
+	}
+	if logs := env.logs.String(); !strings.Contains(logs, "new_posts=1") {
+		t.Errorf("the fetch finished during shutdown should be saved:\n%s", logs)
+	}
+}
+
+func TestHandlerAggregatorRecovers(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	server := newFeedServer(t, testItem{title: "One", guid: "1"})
+	server.setStatus(http.StatusServiceUnavailable)
+	feed := env.addFeed(t, "Blog", server.URL)
+
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", server.URL, "1h"); err == nil {
+		t.Fatal("agg --feed on a failing feed succeeded")
+	}
+	failed, _ := env.store.GetFeedByUrl(context.Background(), feed.Url)
+	if failed.ConsecutiveFailures != 1 || !failed.LastError.Valid || failed.DisabledAt.Valid {
+		t.Errorf("feed not marked failed: %+v", failed)
+	}
+	// The first failure doubles the interval.
+	if next := time.Until(failed.NextFetchAt.Time); next < 119*time.Minute || next > 2*time.Hour {
+		t.Errorf("next fetch in %s, want 2h", next)
+	}
+
+	server.setStatus(http.StatusOK)
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", server.URL, "1h"); err != nil {
+		t.Fatalf("agg --feed: %v", err)
+	}
+	recovered, _ := env.store.GetFeedByUrl(context.Background(), feed.Url)
+	if recovered.ConsecutiveFailures != 0 || recovered.LastError.Valid || recovered.LastHttpStatus.Int32 != http.StatusOK {
+		t.Errorf("feed health not reset after a successful fetch: %+v", recovered)
+	}
+}
+
+func TestHandlerAggregatorUsage(t *testing.T) {
+	env := newTestEnv(t)
+
+	for _, args := range [][]string{
+		{},
+		{"soon"},
+		{"--once", "--following"},
+		{"--concurrency", "0", "--once"},
+		{"--lease", "10ms", "--once"},
+		{"--once", "1m", "extra"},
+		{"0s"},
+		{"-1m"},
+		{"500ms"},
+		{"--once", "0s"},
+	} {
+		if _, err := env.run(t, handlerAggregator, "agg", args...); err == nil {
+			t.Errorf("agg %v succeeded", args)
+		}
+	}
+}
+
+func TestHandlerAggregatorHooks(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	server := newFeedServer(t, testItem{title: "One", guid: "1"}, testItem{title: "Two", guid: "2"})
+	env.addFeed(t, "Blog", server.URL)
+
+	var mu sync.Mutex
+	var received []hooks.Payload
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload hooks.Payload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode webhook payload: %v", err)
+		}
+		mu.Lock()
+		received = append(received, payload)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+
+	hook, err := env.store.CreateHook(context.Background(), database.CreateHookParams{
+		ID:     uuid.New(),
+		UserID: user.ID,
+		Kind:   "webhook",
+		Target: webhook.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first fetch of the feed doesn't fire hooks for its history.
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", server.URL); err != nil {
+		t.Fatalf("agg --feed: %v", err)
+	}
+	mu.Lock()
+	if len(received) != 0 {
+		t.Errorf("webhook received %d payloads on the first fetch, want none", len(received))
+	}
+	mu.Unlock()
+
+	server.setItems(
+		testItem{title: "One", guid: "1"},
+		testItem{title: "Two", guid: "2"},
+		testItem{title: "Three", guid: "3"},
+		testItem{title: "Four", guid: "4"},
+	)
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", server.URL); err != nil {
+		t.Fatalf("agg --feed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("webhook received %d payloads, want 2", len(received))
+	}
+	for _, payload := range received {
+		if payload.Event != hooks.EventPostCreated || payload.User != "alan" || payload.Feed.Name != "Blog" {
+			t.Errorf("unexpected payload %+v", payload)
+		}
+	}
+
+	rows, _ := env.store.GetHooksForUser(context.Background(), user.ID)
+	if len(rows) != 1 || rows[0].ID != hook.ID || !rows[0].LastDeliveredAt.Valid {
+		t.Errorf("hook not marked delivered: %+v", rows)
+	}
+}
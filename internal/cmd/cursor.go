@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// listPageSize is how many rows the listings that print everything fetch
+// at a time.
+const listPageSize = 100
+
+// cursor is a position in a listing paginated with a keyset: the sort time
+// and ID of the row that the next page starts after.
+type cursor struct {
+	at time.Time
+	id uuid.UUID
+}
+
+func (c cursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.at.UTC().Format(time.RFC3339Nano) + "/" + c.id.String()))
+}
+
+func parseCursor(value string) (cursor, error) {
+	invalid := fmt.Errorf("invalid cursor: %s", value)
+
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor{}, invalid
+	}
+	at, id, ok := strings.Cut(string(decoded), "/")
+	if !ok {
+		return cursor{}, invalid
+	}
+
+	var c cursor
+	if c.at, err = time.Parse(time.RFC3339Nano, at); err != nil {
+		return cursor{}, invalid
+	}
+	if c.id, err = uuid.Parse(id); err != nil {
+		return cursor{}, invalid
+	}
+	return c, nil
+}
+
+// postCursor returns the cursor of a post, which is sorted by when it was
+// published, or added when it has no publication date.
+func postCursor(publishedAt sql.NullTime, createdAt time.Time, id uuid.UUID) cursor {
+	if publishedAt.Valid {
+		return cursor{at: publishedAt.Time, id: id}
+	}
+	return cursor{at: createdAt, id: id}
+}
+
+func (c cursor) nullTime() sql.NullTime {
+	return sql.NullTime{Time: c.at, Valid: true}
+}
+
+func (c cursor) nullID() uuid.NullUUID {
+	return uuid.NullUUID{UUID: c.id, Valid: true}
+}
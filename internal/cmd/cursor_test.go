@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursor(t *testing.T) {
+	want := cursor{at: time.Date(2026, 10, 2, 10, 0, 0, 123456000, time.UTC), id: uuid.New()}
+
+	got, err := parseCursor(want.String())
+	if err != nil {
+		t.Fatalf("parse cursor: %v", err)
+	}
+	if !got.at.Equal(want.at) || got.id != want.id {
+		t.Errorf("cursor = %+v, want %+v", got, want)
+	}
+
+	for _, value := range []string{"", "!!!", "bm90LWEtY3Vyc29y", want.String()[:10]} {
+		if _, err := parseCursor(value); err == nil {
+			t.Errorf("parsing cursor %q succeeded", value)
+		}
+	}
+}
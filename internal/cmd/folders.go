@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func handlerFolders(ctx context.Context, s *state, cmd command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return handlerListFolders(ctx, s, cmd, user)
+	}
+
+	sub := command{Name: cmd.Name + " " + cmd.Args[0], Args: cmd.Args[1:]}
+	switch cmd.Args[0] {
+	case "add":
+		return handlerAddFolder(ctx, s, sub, user)
+	case "rename":
+		return handlerRenameFolder(ctx, s, sub, user)
+	case "remove":
+		return handlerRemoveFolder(ctx, s, sub, user)
+	case "move":
+		return handlerMoveFeed(ctx, s, sub, user)
+	default:
+		return fmt.Errorf("usage: %s [add | rename | remove | move]", cmd.Name)
+	}
+}
+
+func handlerListFolders(ctx context.Context, s *state, _ command, user database.User) error {
+	folders, err := s.db.GetFoldersForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get folders: %w", err)
+	}
+
+	if len(folders) == 0 {
+		fmt.Println("No folders, add one with: folders add <name>")
+		return nil
+	}
+
+	for _, folder := range folders {
+		fmt.Printf("* %s: %d feeds, %d unread\n", folder.Name, folder.FeedCount, folder.UnreadCount)
+	}
+	return nil
+}
+
+func handlerAddFolder(ctx context.Context, s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 1 || cmd.Args[0] == "" {
+		return fmt.Errorf("usage: %s <name>", cmd.Name)
+	}
+
+	now := time.Now().UTC()
+	folder, err := s.db.CreateFolder(ctx, database.CreateFolderParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		Name:      cmd.Args[0],
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't create folder: %w", err)
+	}
+
+	s.logger.Info("Created folder", "user", user.Name, "folder", folder.Name)
+	return nil
+}
+
+func handlerRenameFolder(ctx context.Context, s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 2 || cmd.Args[1] == "" {
+		return fmt.Errorf("usage: %s <name> <new-name>", cmd.Name)
+	}
+
+	folder, err := s.db.RenameFolder(ctx, database.RenameFolderParams{
+		NewName: cmd.Args[1],
+		UserID:  user.ID,
+		Name:    cmd.Args[0],
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("folder %s not found", cmd.Args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't rename folder: %w", err)
+	}
+
+	s.logger.Info("Renamed folder", "user", user.Name, "from", cmd.Args[0], "to", folder.Name)
+	return nil
+}
+
+// handlerRemoveFolder deletes a folder. The feeds in it stay followed,
+// outside of any folder.
+func handlerRemoveFolder(ctx context.Context, s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %s <name>", cmd.Name)
+	}
+
+	n, err := s.db.DeleteFolder(ctx, database.DeleteFolderParams{UserID: user.ID, Name: cmd.Args[0]})
+	if err != nil {
+		return fmt.Errorf("couldn't remove folder: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("folder %s not found", cmd.Args[0])
+	}
+
+	s.logger.Info("Removed folder", "user", user.Name, "folder", cmd.Args[0])
+	return nil
+}
+
+// handlerMoveFeed moves a followed feed into a folder, or out of any folder
+// when none is given.
+func handlerMoveFeed(ctx context.Context, s *state, cmd command, user database.User) error {
+	if len(cmd.Args) < 1 || len(cmd.Args) > 2 {
+		return fmt.Errorf("usage: %s <feed-url> [folder]", cmd.Name)
+	}
+
+	var name string
+	if len(cmd.Args) == 2 {
+		name = cmd.Args[1]
+	}
+	folderID, err := lookupFolder(ctx, s, user, name)
+	if err != nil {
+		return err
+	}
+
+	n, err := s.db.MoveFeedFollow(ctx, database.MoveFeedFollowParams{
+		FolderID: folderID,
+		UserID:   user.ID,
+		Url:      cmd.Args[0],
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't move feed: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("you don't follow %s", cmd.Args[0])
+	}
+
+	if name == "" {
+		s.logger.Info("Moved feed out of its folder", "user", user.Name, "url", cmd.Args[0])
+	} else {
+		s.logger.Info("Moved feed", "user", user.Name, "url", cmd.Args[0], "folder", name)
+	}
+	return nil
+}
+
+// lookupFolder returns the ID of the user's folder with the given name, or
+// a null ID when the name is empty.
+func lookupFolder(ctx context.Context, s *state, user database.User, name string) (uuid.NullUUID, error) {
+	if name == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	folder, err := s.db.GetFolderByName(ctx, database.GetFolderByNameParams{UserID: user.ID, Name: name})
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.NullUUID{}, fmt.Errorf("folder %s not found", name)
+	}
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("couldn't find folder: %w", err)
+	}
+	return uuid.NullUUID{UUID: folder.ID, Valid: true}, nil
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestHandlerFolders(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	blog := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	env.addFeed(t, "News", "https://example.com/news.xml")
+	savePostsForTest(t, env, blog,
+		testItem{title: "First", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Second", guid: "2", pubDate: "Tue, 06 Oct 2026 10:00:00 +0000"},
+	)
+	folders := middlewareLoggedIn(handlerFolders)
+
+	output, err := env.run(t, folders, "folders")
+	if err != nil {
+		t.Fatalf("folders: %v", err)
+	}
+	if !strings.Contains(output, "No folders") {
+		t.Errorf("folders should report no folders:\n%s", output)
+	}
+
+	for _, args := range [][]string{
+		{"add", "Tech"},
+		{"add", "Misc"},
+		{"move", blog.Url, "Tech"},
+	} {
+		if _, err := env.run(t, folders, "folders", args...); err != nil {
+			t.Fatalf("folders %v: %v", args, err)
+		}
+	}
+
+	output, err = env.run(t, folders, "folders")
+	if err != nil {
+		t.Fatalf("folders: %v", err)
+	}
+	if !strings.Contains(output, "* Tech: 1 feeds, 2 unread") || !strings.Contains(output, "* Misc: 0 feeds, 0 unread") {
+		t.Errorf("folders should list feed and unread counts:\n%s", output)
+	}
+	if strings.Index(output, "Misc") > strings.Index(output, "Tech") {
+		t.Errorf("folders should be sorted by name:\n%s", output)
+	}
+
+	if _, err := env.run(t, middlewareLoggedIn(handlerFollowing), "following"); err != nil {
+		t.Fatalf("following: %v", err)
+	}
+	if !strings.Contains(env.logs.String(), "folder=Tech") {
+		t.Errorf("following should show the folder:\n%s", env.logs.String())
+	}
+
+	if _, err := env.run(t, folders, "folders", "rename", "Tech", "Dev"); err != nil {
+		t.Fatalf("folders rename: %v", err)
+	}
+	if _, err := env.run(t, folders, "folders", "remove", "Misc"); err != nil {
+		t.Fatalf("folders remove: %v", err)
+	}
+	output, _ = env.run(t, folders, "folders")
+	if output != "* Dev: 1 feeds, 2 unread\n" {
+		t.Errorf("folders after rename and remove:\n%s", output)
+	}
+
+	for _, args := range [][]string{
+		{"add", "Dev"},
+		{"add"},
+		{"rename", "Misc", "Other"},
+		{"remove", "Misc"},
+		{"move", blog.Url, "Misc"},
+		{"move", "https://example.com/unknown.xml"},
+		{"unknown"},
+	} {
+		if _, err := env.run(t, folders, "folders", args...); err == nil {
+			t.Errorf("folders %v succeeded", args)
+		}
+	}
+}
+
+func TestHandlerFolderFilters(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	blog := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	news := env.addFeed(t, "News", "https://example.com/news.xml")
+	savePostsForTest(t, env, blog, testItem{title: "Blog post", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"})
+	savePostsForTest(t, env, news, testItem{title: "News post", guid: "1", pubDate: "Tue, 06 Oct 2026 10:00:00 +0000"})
+	folders := middlewareLoggedIn(handlerFolders)
+	browse := middlewareLoggedIn(handlerBrowse)
+
+	if _, err := env.run(t, folders, "folders", "add", "Tech"); err != nil {
+		t.Fatalf("folders add: %v", err)
+	}
+	if _, err := env.run(t, folders, "folders", "move", blog.Url, "Tech"); err != nil {
+		t.Fatalf("folders move: %v", err)
+	}
+
+	output, err := env.run(t, browse, "browse", "--folder", "Tech", "10")
+	if err != nil {
+		t.Fatalf("browse --folder: %v", err)
+	}
+	if !strings.Contains(output, "Blog post") || strings.Contains(output, "News post") {
+		t.Errorf("browse --folder should only show the folder's posts:\n%s", output)
+	}
+
+	if _, err := env.run(t, middlewareLoggedIn(handlerRead), "read", "--folder", "Tech"); err != nil {
+		t.Fatalf("read --folder: %v", err)
+	}
+	want := map[string]bool{"Blog post": true, "News post": false}
+	if got := readPostsForTest(t, env, user); !maps.Equal(got, want) {
+		t.Errorf("after read --folder: read = %v, want %v", got, want)
+	}
+
+	// Removing the folder keeps its feeds followed.
+	if _, err := env.run(t, folders, "folders", "remove", "Tech"); err != nil {
+		t.Fatalf("folders remove: %v", err)
+	}
+	output, err = env.run(t, browse, "browse", "--all", "10")
+	if err != nil {
+		t.Fatalf("browse: %v", err)
+	}
+	if !strings.Contains(output, "Blog post") || !strings.Contains(output, "News post") {
+		t.Errorf("browse after removing the folder:\n%s", output)
+	}
+
+	if _, err := env.run(t, browse, "browse", "--folder", "Tech"); err == nil {
+		t.Error("browse with an unknown folder succeeded")
+	}
+}
@@ -3,23 +3,25 @@ package cmd
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Pizzu/gator/internal/api"
 	"github.com/Pizzu/gator/internal/database"
 	"github.com/google/uuid"
 )
 
 // User Handlers
-func handlerLogin(s *state, cmd command) error {
+func handlerLogin(ctx context.Context, s *state, cmd command) error {
 	if len(cmd.Args) != 1 {
 		return fmt.Errorf("usage: %s <name>", cmd.Name)
 	}
 	name := cmd.Args[0]
 
-	ctx := context.Background()
 	user, err := s.db.GetUserByName(ctx, name)
 
 	if err != nil {
@@ -31,18 +33,16 @@ func handlerLogin(s *state, cmd command) error {
 		return fmt.Errorf("couldn't set current user: %w", err)
 	}
 
-	s.logger.Info("User switched successfully!")
+	s.logger.Info("User switched successfully!", "user", user.Name)
 	return nil
 }
 
-func handlerRegister(s *state, cmd command) error {
+func handlerRegister(ctx context.Context, s *state, cmd command) error {
 	if len(cmd.Args) != 1 {
 		return fmt.Errorf("usage: %s <name>", cmd.Name)
 	}
 	name := cmd.Args[0]
 
-	ctx := context.Background()
-
 	userPayload := database.CreateUserParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: name}
 
 	user, err := s.db.CreateUser(ctx, userPayload)
@@ -56,53 +56,54 @@ func handlerRegister(s *state, cmd command) error {
 		return fmt.Errorf("couldn't set current user: %w", err)
 	}
 
-	s.logger.Info(fmt.Sprintf("User created and set successfully: %v", user))
+	s.logger.Info("User created and set successfully", "user", user.Name, "user_id", user.ID)
 	return nil
 }
 
-func handlerGetAllUsers(s *state, _ command) error {
-	ctx := context.Background()
-
-	users, err := s.db.GetUsers(ctx)
+func handlerGetAllUsers(ctx context.Context, s *state, _ command) error {
+	currentUser := s.cfg.CurrentUserName
 
-	if err != nil {
-		return fmt.Errorf("couldn't get all users")
-	}
+	params := database.GetUsersParams{PageSize: listPageSize}
+	for {
+		users, err := s.db.GetUsers(ctx, params)
+		if err != nil {
+			return fmt.Errorf("couldn't get all users")
+		}
 
-	currentUser := s.cfg.CurrentUserName
+		for _, user := range users {
+			if currentUser == user.Name {
+				s.logger.Print("* "+user.Name, "current", true)
+			} else {
+				s.logger.Print(user.Name)
+			}
+		}
 
-	for _, user := range users {
-		if currentUser == user.Name {
-			s.logger.Printf("* %s (current)", user.Name)
-		} else {
-			s.logger.Printf("%s", user.Name)
+		if len(users) < listPageSize {
+			return nil
 		}
+		last := users[len(users)-1]
+		params.AfterCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: last.ID, Valid: true}
 	}
-
-	return nil
 }
 
-func handlerResetUsers(s *state, _ command) error {
-	ctx := context.Background()
-
+func handlerResetUsers(ctx context.Context, s *state, _ command) error {
 	err := s.db.DeleteAllUsers(ctx)
 
 	if err != nil {
 		return fmt.Errorf("couldn't delete users")
 	}
 
-	s.logger.Info("users deleted successfully")
+	s.logger.Info("Users deleted successfully")
 	return nil
 }
 
 // Feed Handlers
-func handlerAddFeed(s *state, cmd command, user database.User) error {
+func handlerAddFeed(ctx context.Context, s *state, cmd command, user database.User) error {
 	if len(cmd.Args) != 2 {
 		return fmt.Errorf("usage: %s <name> <url>", cmd.Name)
 	}
 
-	ctx := context.Background()
-
 	name := cmd.Args[0]
 	url := cmd.Args[1]
 
@@ -128,34 +129,155 @@ func handlerAddFeed(s *state, cmd command, user database.User) error {
 		return err
 	}
 
-	s.logger.Info(fmt.Sprintf("%s started following %s feed", feedFollow.UserName, feedFollow.FeedName))
-	s.logger.Info(fmt.Sprintf("%+v", feed))
+	s.logger.Info("Feed added", "feed_id", feed.ID, "feed", feed.Name, "url", feed.Url)
+	s.logger.Info("Started following feed", "user", feedFollow.UserName, "feed", feedFollow.FeedName)
 
 	return nil
 }
 
-func handlerGetAllFeeds(s *state, _ command) error {
-	ctx := context.Background()
+func handlerFeeds(ctx context.Context, s *state, cmd command) error {
+	if len(cmd.Args) == 0 {
+		return handlerGetAllFeeds(ctx, s, cmd)
+	}
+
+	sub := command{Name: cmd.Name + " " + cmd.Args[0], Args: cmd.Args[1:]}
+
+	switch cmd.Args[0] {
+	case "health":
+		return handlerFeedsHealth(ctx, s, sub)
+	case "enable":
+		return handlerEnableFeed(ctx, s, sub)
+	default:
+		return fmt.Errorf("usage: %s [health | enable <url>]", cmd.Name)
+	}
+}
+
+func handlerGetAllFeeds(ctx context.Context, s *state, _ command) error {
+	params := database.GetAllFeedsParams{PageSize: listPageSize}
+	for {
+		feeds, err := s.db.GetAllFeeds(ctx, params)
+		if err != nil {
+			return fmt.Errorf("couldn't retrieve feeds")
+		}
+
+		for _, feed := range feeds {
+			s.logger.Print(feed.Name, "url", feed.Url, "author", feed.AuthorName)
+		}
+
+		if len(feeds) < listPageSize {
+			return nil
+		}
+		last := feeds[len(feeds)-1]
+		params.AfterCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: last.ID, Valid: true}
+	}
+}
 
-	feeds, err := s.db.GetAllFeeds(ctx)
+func handlerFeedsHealth(ctx context.Context, s *state, _ command) error {
+	feeds, err := s.db.GetUnhealthyFeeds(ctx)
 
 	if err != nil {
-		return fmt.Errorf("couldn't retrieve feeds")
+		return fmt.Errorf("couldn't retrieve feed health: %w", err)
 	}
 
+	if len(feeds) == 0 {
+		s.logger.Info("All feeds are healthy")
+		return nil
+	}
+
+	s.logger.Info("Feeds are failing", "count", len(feeds))
 	for _, feed := range feeds {
-		s.logger.Printf("%+v", feed)
+		status := "failing"
+		if feed.DisabledAt.Valid {
+			status = "disabled since " + feed.DisabledAt.Time.Format(time.DateTime)
+		}
+		fmt.Printf("%s (%s)\n", feed.Name, feed.Url)
+		fmt.Printf("  Status:       %s, %d consecutive failures\n", status, feed.ConsecutiveFailures)
+		if feed.LastHttpStatus.Valid {
+			fmt.Printf("  HTTP status:  %d\n", feed.LastHttpStatus.Int32)
+		}
+		fmt.Printf("  Last error:   %s\n", feed.LastError.String)
+		fmt.Printf("  Last success: %s\n", formatNullTime(feed.LastSuccessAt))
+		if !feed.DisabledAt.Valid {
+			fmt.Printf("  Next retry:   %s\n", formatNullTime(feed.NextFetchAt))
+		}
 	}
+
 	return nil
 }
 
-// Feed Follow handler
-func handlerFeedFollow(s *state, cmd command, user database.User) error {
+func handlerEnableFeed(ctx context.Context, s *state, cmd command) error {
 	if len(cmd.Args) != 1 {
 		return fmt.Errorf("usage: %s <url>", cmd.Name)
 	}
 
-	ctx := context.Background()
+	feed, err := s.db.EnableFeed(ctx, cmd.Args[0])
+
+	if err != nil {
+		return fmt.Errorf("couldn't enable feed: %w", err)
+	}
+
+	s.logger.Info("Feed enabled, it will be fetched on the next agg run", "feed_id", feed.ID, "feed", feed.Name, "url", feed.Url)
+	return nil
+}
+
+func handlerSetInterval(ctx context.Context, s *state, cmd command, user database.User) error {
+	if len(cmd.Args) < 2 || len(cmd.Args) > 3 || (len(cmd.Args) == 3 && cmd.Args[2] != "adaptive") {
+		return fmt.Errorf("usage: %s <url> <interval|default> [adaptive]", cmd.Name)
+	}
+
+	url := cmd.Args[0]
+	adaptive := len(cmd.Args) == 3
+
+	interval := sql.NullInt32{}
+	if cmd.Args[1] != "default" {
+		d, err := time.ParseDuration(cmd.Args[1])
+		if err != nil {
+			return fmt.Errorf("invalid interval: %w", err)
+		}
+		if d < minFetchInterval || d > maxFetchInterval {
+			return fmt.Errorf("interval must be between %s and %s", minFetchInterval, maxFetchInterval)
+		}
+		interval = sql.NullInt32{Int32: durationSeconds(d), Valid: true}
+	}
+
+	feed, err := s.db.GetFeedByUrl(ctx, url)
+
+	if err != nil {
+		return fmt.Errorf("couldn't find feed: %w", err)
+	}
+
+	_, err = s.db.UpdateFeedSchedule(ctx, database.UpdateFeedScheduleParams{
+		ID:                   feed.ID,
+		UserID:               user.ID,
+		FetchIntervalSeconds: interval,
+		AdaptivePolling:      adaptive,
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("only the user who added %s can change its interval", feed.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't update feed interval: %w", err)
+	}
+
+	schedule := "the agg interval"
+	if interval.Valid {
+		schedule = "every " + cmd.Args[1]
+	}
+	if adaptive {
+		schedule += ", adapting to how often it posts"
+	}
+	s.logger.Info("Feed schedule updated, it applies after the next fetch", "feed_id", feed.ID, "feed", feed.Name, "url", feed.Url, "schedule", schedule)
+
+	return nil
+}
+
+// Feed Follow handler
+func handlerFeedFollow(ctx context.Context, s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %s <url>", cmd.Name)
+	}
 
 	url := cmd.Args[0]
 
@@ -176,18 +298,16 @@ func handlerFeedFollow(s *state, cmd command, user database.User) error {
 		return err
 	}
 
-	s.logger.Info(fmt.Sprintf("%s started following %s feed", feedFollow.UserName, feedFollow.FeedName))
+	s.logger.Info("Started following feed", "user", feedFollow.UserName, "feed", feedFollow.FeedName, "feed_id", feedFollow.FeedID)
 
 	return nil
 }
 
-func handlerFeedUnfollow(s *state, cmd command, user database.User) error {
+func handlerFeedUnfollow(ctx context.Context, s *state, cmd command, user database.User) error {
 	if len(cmd.Args) != 1 {
 		return fmt.Errorf("usage: %s <url>", cmd.Name)
 	}
 
-	ctx := context.Background()
-
 	url := cmd.Args[0]
 
 	err := s.db.UnfollowFeed(ctx, database.UnfollowFeedParams{UserID: user.ID, Url: url})
@@ -196,136 +316,372 @@ func handlerFeedUnfollow(s *state, cmd command, user database.User) error {
 		return err
 	}
 
-	s.logger.Info(fmt.Sprintf("%s stopped following %s feed", user.Name, url))
+	s.logger.Info("Stopped following feed", "user", user.Name, "url", url)
 
 	return nil
 }
 
-func handlerFollowing(s *state, cmd command, user database.User) error {
-	ctx := context.Background()
-
+func handlerFollowing(ctx context.Context, s *state, cmd command, user database.User) error {
 	feedsFollowed, err := s.db.GetFeedFollowsForUser(ctx, user.ID)
 
 	if err != nil {
 		return err
 	}
 
-	s.logger.Info(fmt.Sprintf("%s is following:", user.Name))
+	s.logger.Info("Followed feeds", "user", user.Name, "count", len(feedsFollowed))
 	for _, feedFollowed := range feedsFollowed {
-		s.logger.Printf("- %s", feedFollowed.FeedName)
+		args := []any{"feed_id", feedFollowed.FeedID}
+		if feedFollowed.FolderName.Valid {
+			args = append(args, "folder", feedFollowed.FolderName.String)
+		}
+		s.logger.Print("- "+feedFollowed.FeedName, append(args, "unread", feedFollowed.UnreadCount)...)
 	}
 
 	return nil
 }
 
-// Handler aggregator
-func handlerAggregator(s *state, cmd command) error {
-	if len(cmd.Args) != 1 {
-		return fmt.Errorf("usage: %s <time_between_reqs>", cmd.Name)
+// Browse Handler
+func handlerBrowse(ctx context.Context, s *state, cmd command, user database.User) error {
+	usage := fmt.Errorf("usage: %s [--all] [--folder name] [--tag name] [--since date] [--until date] [--next cursor | --prev cursor] [limit]", cmd.Name)
+
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	all := flags.Bool("all", false, "include posts already read")
+	folder := flags.String("folder", "", "only show posts of the feeds in this folder")
+	tag := flags.String("tag", "", "only show posts with this tag, read or not")
+	since := flags.String("since", "", "only show posts published on or after this date")
+	until := flags.String("until", "", "only show posts published before this date")
+	next := flags.String("next", "", "show the page after this cursor")
+	prev := flags.String("prev", "", "show the page before this cursor")
+
+	if err := flags.Parse(cmd.Args); err != nil || flags.NArg() > 1 || (*next != "" && *prev != "") {
+		return usage
+	}
+
+	limit := 2
+	if flags.NArg() == 1 {
+		if specifiedLimit, err := strconv.Atoi(flags.Arg(0)); err == nil && specifiedLimit > 0 {
+			limit = specifiedLimit
+		} else {
+			return fmt.Errorf("invalid limit: %s", flags.Arg(0))
+		}
 	}
 
-	timeBetweenRequests, err := time.ParseDuration(cmd.Args[0])
+	// One more post than the limit is fetched to tell whether there is
+	// another page in that direction. Tagged posts are shown even when read.
+	params := database.GetPostsForUserParams{
+		UserID:     user.ID,
+		UnreadOnly: !*all && *tag == "",
+		PageSize:   int32(limit + 1),
+	}
+	if *tag != "" {
+		params.Tag = sql.NullString{String: normalizeTag(*tag), Valid: true}
+	}
+	folderID, err := lookupFolder(ctx, s, user, *folder)
 	if err != nil {
-		return fmt.Errorf("invalid duration: %w", err)
+		return err
+	}
+	params.FolderID = folderID
+	if *since != "" {
+		date, err := parseDate(*since)
+		if err != nil {
+			return err
+		}
+		params.Since = sql.NullTime{Time: date, Valid: true}
+	}
+	if *until != "" {
+		date, err := parseDate(*until)
+		if err != nil {
+			return err
+		}
+		params.Until = sql.NullTime{Time: date, Valid: true}
 	}
 
-	s.logger.Info(fmt.Sprintf("Collecting feeds every %s...", timeBetweenRequests))
+	var posts []database.GetPostsForUserRow
+	var hasPrev, hasNext bool
+	if *prev != "" {
+		c, err := parseCursor(*prev)
+		if err != nil {
+			return err
+		}
+		params.CursorPublishedAt, params.CursorID = c.nullTime(), c.nullID()
 
-	ticker := time.NewTicker(timeBetweenRequests)
-	defer ticker.Stop()
+		newer, err := s.db.GetNewerPostsForUser(ctx, database.GetNewerPostsForUserParams(params))
+		if err != nil {
+			return fmt.Errorf("couldn't get posts for user: %w", err)
+		}
+		hasPrev, hasNext = len(newer) > limit, true
+		for i := min(len(newer), limit) - 1; i >= 0; i-- {
+			posts = append(posts, database.GetPostsForUserRow(newer[i]))
+		}
+	} else {
+		if *next != "" {
+			c, err := parseCursor(*next)
+			if err != nil {
+				return err
+			}
+			params.CursorPublishedAt, params.CursorID = c.nullTime(), c.nullID()
+			hasPrev = true
+		}
+
+		posts, err = s.db.GetPostsForUser(ctx, params)
+		if err != nil {
+			return fmt.Errorf("couldn't get posts for user: %w", err)
+		}
+		hasNext = len(posts) > limit
+		posts = posts[:min(len(posts), limit)]
+	}
+
+	s.logger.Info("Found posts", "user", user.Name, "count", len(posts))
+	if len(posts) == 0 {
+		if params.UnreadOnly && *next == "" && *prev == "" {
+			fmt.Println("No unread posts, see all with: browse --all")
+		}
+		return nil
+	}
+	for _, post := range posts {
+		title := post.Title
+		if post.Read {
+			title += " (read)"
+		}
+		printPost(post.PublishedAt, post.FeedName, title, post.ID, post.Description, post.Url)
+	}
 
-	for ; ; <-ticker.C {
-		scrapeFeeds(s)
+	// Page links repeat the options of this page.
+	var options []string
+	if *all {
+		options = append(options, "--all")
 	}
+	if *folder != "" {
+		options = append(options, "--folder", *folder)
+	}
+	if *tag != "" {
+		options = append(options, "--tag", *tag)
+	}
+	if *since != "" {
+		options = append(options, "--since", *since)
+	}
+	if *until != "" {
+		options = append(options, "--until", *until)
+	}
+	pageLink := func(direction string, c cursor) string {
+		return strings.Join(append([]string{cmd.Name}, append(options, direction, c.String(), strconv.Itoa(limit))...), " ")
+	}
+
+	first, last := posts[0], posts[len(posts)-1]
+	if hasPrev {
+		fmt.Printf("Previous page: %s\n", pageLink("--prev", postCursor(first.PublishedAt, first.CreatedAt, first.ID)))
+	}
+	if hasNext {
+		fmt.Printf("Next page: %s\n", pageLink("--next", postCursor(last.PublishedAt, last.CreatedAt, last.ID)))
+	}
+
+	return nil
 }
 
-func scrapeFeeds(s *state) {
-	ctx := context.Background()
+// Fetch Log Handlers
+func handlerFetchLog(ctx context.Context, s *state, cmd command) error {
+	if len(cmd.Args) >= 1 && cmd.Args[0] == "prune" {
+		return handlerPruneFetchLog(ctx, s, command{Name: cmd.Name + " prune", Args: cmd.Args[1:]})
+	}
+	if len(cmd.Args) < 1 || len(cmd.Args) > 2 {
+		return fmt.Errorf("usage: %s <url> [limit] | %s prune <retention>", cmd.Name, cmd.Name)
+	}
 
-	feed, err := s.db.GetNextFeedToFetch(ctx)
+	limit := 10
+	if len(cmd.Args) == 2 {
+		specifiedLimit, err := strconv.Atoi(cmd.Args[1])
+		if err != nil {
+			return fmt.Errorf("invalid limit: %w", err)
+		}
+		if specifiedLimit < 1 {
+			return errors.New("limit must be at least 1")
+		}
+		limit = specifiedLimit
+	}
 
+	feed, err := s.db.GetFeedByUrl(ctx, cmd.Args[0])
 	if err != nil {
-		s.logger.Error(fmt.Sprintf("Couldn't fetch next feed: %v", err))
-		return
+		return fmt.Errorf("couldn't find feed: %w", err)
 	}
 
-	markedFeed, err := s.db.MarkFeedFetched(ctx, feed.ID)
-
+	stats, err := s.db.GetFetchStatsForFeed(ctx, feed.ID)
 	if err != nil {
-		s.logger.Error(fmt.Sprintf("Couldn't save fetched feed: %v", err))
-		return
+		return fmt.Errorf("couldn't get fetch stats: %w", err)
 	}
 
-	fetchedFeed, err := s.client.FetchFeed(ctx, markedFeed.Url)
+	lastNewPosts := "never"
+	lastFetch, err := s.db.GetLastFetchWithNewPosts(ctx, feed.ID)
+	if err == nil {
+		lastNewPosts = lastFetch.StartedAt.Format(time.DateTime)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("couldn't get last fetch with new posts: %w", err)
+	}
 
+	entries, err := s.db.GetFetchLogForFeed(ctx, database.GetFetchLogForFeedParams{
+		FeedID: feed.ID,
+		Limit:  int32(limit),
+	})
 	if err != nil {
-		s.logger.Error(fmt.Sprintf("Error while fetching feed details: %v", err))
-		return
+		return fmt.Errorf("couldn't get fetch log: %w", err)
 	}
 
-	// Save all the posts for the current feed
-	for _, post := range fetchedFeed.Channel.Item {
-		publishedAt := sql.NullTime{}
+	fmt.Printf("%s (%s)\n", feed.Name, feed.Url)
+	fmt.Printf("Fetches:        %d (%d failed)\n", stats.Fetches, stats.Failures)
+	fmt.Printf("Duration:       avg %.0fms, max %dms\n", stats.AvgDurationMs, stats.MaxDurationMs)
+	fmt.Printf("New posts:      %d\n", stats.NewPosts)
+	fmt.Printf("Last new posts: %s\n", lastNewPosts)
+	fmt.Println("=====================================")
 
-		if t, err := time.Parse(time.RFC1123Z, post.PubDate); err == nil {
-			publishedAt = sql.NullTime{
-				Time:  t,
-				Valid: true,
-			}
+	for _, entry := range entries {
+		status := "-"
+		if entry.HttpStatus.Valid {
+			status = strconv.Itoa(int(entry.HttpStatus.Int32))
 		}
-
-		_, err = s.db.CreatePost(context.Background(), database.CreatePostParams{
-			ID:        uuid.New(),
-			CreatedAt: time.Now().UTC(),
-			UpdatedAt: time.Now().UTC(),
-			FeedID:    feed.ID,
-			Title:     post.Title,
-			Description: sql.NullString{
-				String: post.Description,
-				Valid:  true,
-			},
-			Url:         post.Link,
-			PublishedAt: publishedAt,
-		})
-		if err != nil {
-			if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-				continue
-			}
-			s.logger.Error(fmt.Sprintf("Couldn't create post: %v", err))
-			continue
+		fmt.Printf("%s  %6dms  HTTP %s  %8d bytes  %3d items  %3d new",
+			entry.StartedAt.Format(time.DateTime), entry.DurationMs, status, entry.BytesReceived, entry.ItemsParsed, entry.NewPosts)
+		if entry.Error.Valid {
+			fmt.Printf("  error: %s", entry.Error.String)
 		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func handlerPruneFetchLog(ctx context.Context, s *state, cmd command) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %s <retention>", cmd.Name)
+	}
+
+	retention, err := time.ParseDuration(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("invalid retention: %w", err)
+	}
+
+	deleted, err := s.db.PruneFetchLog(ctx, time.Now().UTC().Add(-retention))
+	if err != nil {
+		return fmt.Errorf("couldn't prune fetch log: %w", err)
+	}
 
+	s.logger.Info("Pruned fetch log", "deleted", deleted, "retention", retention)
+	return nil
+}
+
+// Post Retention Handler
+func handlerPrunePosts(ctx context.Context, s *state, cmd command) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %s <retention>", cmd.Name)
 	}
-	s.logger.Info(fmt.Sprintf("Feed %s collected, %v posts found\n", feed.Name, len(fetchedFeed.Channel.Item)))
+
+	retention, err := time.ParseDuration(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("invalid retention: %w", err)
+	}
+
+	deleted, err := prunePosts(ctx, s.db, time.Now().UTC().Add(-retention))
+	if err != nil {
+		return fmt.Errorf("couldn't prune posts: %w", err)
+	}
+
+	s.logger.Info("Pruned posts", "deleted", deleted, "retention", retention)
+	return nil
 }
 
-// Browse Handler
-func handlerBrowse(s *state, cmd command, user database.User) error {
-	limit := 2
-	if len(cmd.Args) == 1 {
-		if specifiedLimit, err := strconv.Atoi(cmd.Args[0]); err == nil {
-			limit = specifiedLimit
-		} else {
-			return fmt.Errorf("invalid limit: %w", err)
+// prunePosts deletes the posts published before the cutoff, remembering
+// their keys so that the posts still in their feed aren't fetched again as
+// new ones.
+func prunePosts(ctx context.Context, db database.Store, before time.Time) (int64, error) {
+	var deleted int64
+	err := db.InTx(ctx, func(q database.Querier) error {
+		if err := q.SavePrunedPostKeys(ctx, before); err != nil {
+			return err
 		}
+		n, err := q.PrunePosts(ctx, before)
+		deleted = n
+		return err
+	})
+	return deleted, err
+}
+
+// Validate Handler
+func handlerValidate(ctx context.Context, s *state, cmd command) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %s <url>", cmd.Name)
 	}
 
-	posts, err := s.db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
-		UserID: user.ID,
-		Limit:  int32(limit),
-	})
+	url := cmd.Args[0]
+
+	report, err := s.client.ValidateFeed(ctx, url)
 	if err != nil {
-		return fmt.Errorf("couldn't get posts for user: %w", err)
+		return fmt.Errorf("couldn't fetch feed: %w", err)
 	}
 
-	s.logger.Info(fmt.Sprintf("Found %d posts for user %s:\n", len(posts), user.Name))
-	for _, post := range posts {
-		fmt.Printf("%s from %s\n", post.PublishedAt.Time.Format("Mon Jan 2"), post.FeedName)
-		fmt.Printf("--- %s ---\n", post.Title)
-		fmt.Printf("Desc: %v\n", post.Description.String)
-		fmt.Printf("Link: %s\n", post.Url)
-		fmt.Println("=====================================")
+	fmt.Printf("Feed:         %s\n", report.URL)
+	fmt.Printf("HTTP status:  %s\n", report.Status)
+	fmt.Printf("Content-Type: %s\n", report.ContentType)
+	fmt.Printf("Size:         %d bytes\n", report.Size)
+	fmt.Printf("Format:       %s\n", report.Format)
+	fmt.Printf("Encoding:     %s\n", report.Encoding)
+	fmt.Printf("Items:        %d\n", report.ItemCount)
+
+	fmt.Println("Caching headers:")
+	if len(report.CacheHeaders) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, header := range report.CacheHeaders {
+		fmt.Printf("  %s: %s\n", header.Name, header.Value)
+	}
+
+	printSection := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		fmt.Printf("%s (%d):\n", title, len(lines))
+		for _, line := range lines {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+
+	describe := func(refs []api.ItemRef) []string {
+		lines := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			lines = append(lines, fmt.Sprintf("item #%d (line %d) %q", ref.Index, ref.Line, ref.Title))
+		}
+		return lines
+	}
+
+	xmlErrors := make([]string, 0, len(report.XMLErrors))
+	for _, xmlErr := range report.XMLErrors {
+		xmlErrors = append(xmlErrors, fmt.Sprintf("line %d: %s", xmlErr.Line, xmlErr.Message))
+	}
+	badDates := make([]string, 0, len(report.BadDates))
+	for _, issue := range report.BadDates {
+		badDates = append(badDates, fmt.Sprintf("item #%d (line %d) %q", issue.Index, issue.Line, issue.Value))
+	}
+	duplicates := make([]string, 0, len(report.DuplicateLinks))
+	for _, dup := range report.DuplicateLinks {
+		duplicates = append(duplicates, fmt.Sprintf("%s used by %d items: %s", dup.Link, len(dup.Items), strings.Join(describe(dup.Items), ", ")))
+	}
+
+	printSection("XML errors", xmlErrors)
+	printSection("Items missing a link", describe(report.MissingLinks))
+	printSection("Items missing a date", describe(report.MissingDates))
+	printSection("Items missing a GUID", describe(report.MissingGUIDs))
+	printSection("Unparseable dates", badDates)
+	printSection("Duplicate links", duplicates)
+	// Warnings don't make a feed unhealthy, they are only worth knowing.
+	printSection("Advisories", report.Warnings)
+
+	if !report.HasProblems() {
+		s.logger.Info("Feed looks healthy", "url", url)
 	}
 
 	return nil
 }
+
+func formatNullTime(t sql.NullTime) string {
+	if !t.Valid {
+		return "never"
+	}
+	return t.Time.Format(time.DateTime)
+}
@@ -0,0 +1,675 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Pizzu/gator/internal/config"
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestHandlerRegister(t *testing.T) {
+	env := newTestEnv(t)
+
+	user := env.register(t, "alan")
+	if env.s.cfg.CurrentUserName != "alan" {
+		t.Errorf("current user = %q, want alan", env.s.cfg.CurrentUserName)
+	}
+	saved, err := config.Read()
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if saved.CurrentUserName != "alan" {
+		t.Errorf("saved current user = %q, want alan", saved.CurrentUserName)
+	}
+	if user.Name != "alan" {
+		t.Errorf("user name = %q, want alan", user.Name)
+	}
+
+	if _, err := env.run(t, handlerRegister, "register", "alan"); err == nil {
+		t.Error("registering a taken name succeeded")
+	}
+	if _, err := env.run(t, handlerRegister, "register"); err == nil || !strings.Contains(err.Error(), "usage") {
+		t.Errorf("register without a name: err = %v, want usage", err)
+	}
+}
+
+func TestHandlerLogin(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	env.register(t, "bob")
+
+	if _, err := env.run(t, handlerLogin, "login", "alan"); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if env.s.cfg.CurrentUserName != "alan" {
+		t.Errorf("current user = %q, want alan", env.s.cfg.CurrentUserName)
+	}
+
+	if _, err := env.run(t, handlerLogin, "login", "carol"); err == nil {
+		t.Error("login of an unknown user succeeded")
+	}
+	if env.s.cfg.CurrentUserName != "alan" {
+		t.Errorf("failed login changed the current user to %q", env.s.cfg.CurrentUserName)
+	}
+	if _, err := env.run(t, handlerLogin, "login"); err == nil {
+		t.Error("login without a name succeeded")
+	}
+}
+
+func TestHandlerGetAllUsers(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	env.register(t, "bob")
+
+	if _, err := env.run(t, handlerGetAllUsers, "users"); err != nil {
+		t.Fatalf("users: %v", err)
+	}
+
+	logs := env.logs.String()
+	if !strings.Contains(logs, "* bob current=true") {
+		t.Errorf("logs don't mark bob as current:\n%s", logs)
+	}
+	if !strings.Contains(logs, "alan") || strings.Contains(logs, "* alan") {
+		t.Errorf("logs don't list alan as another user:\n%s", logs)
+	}
+}
+
+func TestHandlerGetAllUsersPages(t *testing.T) {
+	env := newTestEnv(t)
+	created := time.Now().UTC()
+	for i := range listPageSize + 5 {
+		_, err := env.store.CreateUser(context.Background(), database.CreateUserParams{
+			ID:        uuid.New(),
+			CreatedAt: created,
+			UpdatedAt: created,
+			Name:      fmt.Sprintf("user%03d", i),
+		})
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+
+	if _, err := env.run(t, handlerGetAllUsers, "users"); err != nil {
+		t.Fatalf("users: %v", err)
+	}
+	logs := env.logs.String()
+	for i := range listPageSize + 5 {
+		if name := fmt.Sprintf("user%03d\n", i); strings.Count(logs, name) != 1 {
+			t.Errorf("users should list %q once:\n%s", name, logs)
+		}
+	}
+}
+
+func TestHandlerResetUsers(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	env.addFeed(t, "Blog", "https://example.com/feed.xml")
+
+	if _, err := env.run(t, handlerResetUsers, "reset"); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+
+	users, _ := env.store.GetUsers(context.Background(), database.GetUsersParams{PageSize: 10})
+	feeds, _ := env.store.GetAllFeeds(context.Background(), database.GetAllFeedsParams{PageSize: 10})
+	if len(users) != 0 || len(feeds) != 0 {
+		t.Errorf("reset left %d users and %d feeds", len(users), len(feeds))
+	}
+}
+
+func TestHandlerAddFeed(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	if feed.Name != "Blog" || feed.UserID != user.ID {
+		t.Errorf("feed = %+v, want Blog added by alan", feed)
+	}
+
+	follows, _ := env.store.GetFeedFollowsForUser(context.Background(), user.ID)
+	if len(follows) != 1 || follows[0].FeedID != feed.ID {
+		t.Errorf("follows = %+v, want the new feed", follows)
+	}
+
+	addFeed := middlewareLoggedIn(handlerAddFeed)
+	if _, err := env.run(t, addFeed, "addfeed", "Again", "https://example.com/feed.xml"); err == nil {
+		t.Error("adding a feed url twice succeeded")
+	}
+	if _, err := env.run(t, addFeed, "addfeed", "Blog"); err == nil {
+		t.Error("addfeed without a url succeeded")
+	}
+}
+
+func TestHandlerFeeds(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	env.addFeed(t, "Blog", "https://example.com/feed.xml")
+
+	if _, err := env.run(t, handlerFeeds, "feeds"); err != nil {
+		t.Fatalf("feeds: %v", err)
+	}
+	if logs := env.logs.String(); !strings.Contains(logs, "Blog url=https://example.com/feed.xml author=alan") {
+		t.Errorf("feeds didn't list the feed:\n%s", logs)
+	}
+
+	if _, err := env.run(t, handlerFeeds, "feeds", "nope"); err == nil {
+		t.Error("unknown feeds subcommand succeeded")
+	}
+}
+
+func TestHandlerFeedsPages(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	for i := range listPageSize + 5 {
+		_, err := env.store.CreateFeed(context.Background(), database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			Name:      fmt.Sprintf("Feed %03d", i),
+			Url:       fmt.Sprintf("https://example.com/%d.xml", i),
+			UserID:    user.ID,
+		})
+		if err != nil {
+			t.Fatalf("create feed: %v", err)
+		}
+	}
+
+	if _, err := env.run(t, handlerFeeds, "feeds"); err != nil {
+		t.Fatalf("feeds: %v", err)
+	}
+	if count := strings.Count(env.logs.String(), "author=alan"); count != listPageSize+5 {
+		t.Errorf("feeds listed %d feeds, want %d", count, listPageSize+5)
+	}
+}
+
+func TestHandlerFeedsHealth(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+
+	if _, err := env.run(t, handlerFeeds, "feeds", "health"); err != nil {
+		t.Fatalf("feeds health: %v", err)
+	}
+	if logs := env.logs.String(); !strings.Contains(logs, "All feeds are healthy") {
+		t.Errorf("healthy feeds not reported:\n%s", logs)
+	}
+
+	failFeedForTest(t, env, database.MarkFeedFailedParams{
+		ID:             feed.ID,
+		BackoffSeconds: 60,
+		LastError:      "non-OK HTTP status: 500 Internal Server Error",
+		HttpStatus:     sql.NullInt32{Int32: 500, Valid: true},
+		Disable:        true,
+	})
+
+	output, err := env.run(t, handlerFeeds, "feeds", "health")
+	if err != nil {
+		t.Fatalf("feeds health: %v", err)
+	}
+	for _, want := range []string{"Blog (https://example.com/feed.xml)", "disabled since", "1 consecutive failures", "HTTP status:  500", "500 Internal Server Error"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("feeds health output misses %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestHandlerEnableFeed(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+
+	failFeedForTest(t, env, database.MarkFeedFailedParams{ID: feed.ID, LastError: "boom", Disable: true})
+
+	if _, err := env.run(t, handlerFeeds, "feeds", "enable", feed.Url); err != nil {
+		t.Fatalf("feeds enable: %v", err)
+	}
+	enabled, _ := env.store.GetFeedByUrl(context.Background(), feed.Url)
+	if enabled.DisabledAt.Valid || enabled.ConsecutiveFailures != 0 || enabled.NextFetchAt.Valid {
+		t.Errorf("feed still disabled or scheduled: %+v", enabled)
+	}
+
+	if _, err := env.run(t, handlerFeeds, "feeds", "enable", "https://example.com/missing.xml"); err == nil {
+		t.Error("enabling an unknown feed succeeded")
+	}
+	if _, err := env.run(t, handlerFeeds, "feeds", "enable"); err == nil {
+		t.Error("feeds enable without a url succeeded")
+	}
+}
+
+func TestHandlerSetInterval(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	setInterval := middlewareLoggedIn(handlerSetInterval)
+
+	if _, err := env.run(t, setInterval, "setinterval", feed.Url, "2h", "adaptive"); err != nil {
+		t.Fatalf("setinterval: %v", err)
+	}
+	updated, _ := env.store.GetFeedByUrl(context.Background(), feed.Url)
+	if updated.FetchIntervalSeconds.Int32 != 7200 || !updated.AdaptivePolling {
+		t.Errorf("schedule = %v adaptive=%t, want 7200s adaptive", updated.FetchIntervalSeconds, updated.AdaptivePolling)
+	}
+
+	if _, err := env.run(t, setInterval, "setinterval", feed.Url, "default"); err != nil {
+		t.Fatalf("setinterval default: %v", err)
+	}
+	updated, _ = env.store.GetFeedByUrl(context.Background(), feed.Url)
+	if updated.FetchIntervalSeconds.Valid || updated.AdaptivePolling {
+		t.Errorf("schedule = %v adaptive=%t, want default", updated.FetchIntervalSeconds, updated.AdaptivePolling)
+	}
+
+	for _, args := range [][]string{
+		{feed.Url},
+		{feed.Url, "soon"},
+		{feed.Url, "10s"},
+		{feed.Url, "1h", "fast"},
+		{"https://example.com/missing.xml", "1h"},
+	} {
+		if _, err := env.run(t, setInterval, "setinterval", args...); err == nil {
+			t.Errorf("setinterval %v succeeded", args)
+		}
+	}
+
+	env.register(t, "bob")
+	_, err := env.run(t, setInterval, "setinterval", feed.Url, "1h")
+	if err == nil || !strings.Contains(err.Error(), "only the user who added") {
+		t.Errorf("setinterval by another user: err = %v", err)
+	}
+}
+
+func TestHandlerFeedFollow(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	bob := env.register(t, "bob")
+	follow := middlewareLoggedIn(handlerFeedFollow)
+
+	if _, err := env.run(t, follow, "follow", feed.Url); err != nil {
+		t.Fatalf("follow: %v", err)
+	}
+	follows, _ := env.store.GetFeedFollowsForUser(context.Background(), bob.ID)
+	if len(follows) != 1 || follows[0].FeedName != "Blog" {
+		t.Errorf("follows = %+v, want Blog", follows)
+	}
+
+	if _, err := env.run(t, follow, "follow", feed.Url); err == nil {
+		t.Error("following a feed twice succeeded")
+	}
+	if _, err := env.run(t, follow, "follow", "https://example.com/missing.xml"); err == nil {
+		t.Error("following an unknown feed succeeded")
+	}
+}
+
+func TestHandlerFeedUnfollow(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+
+	if _, err := env.run(t, middlewareLoggedIn(handlerFeedUnfollow), "unfollow", feed.Url); err != nil {
+		t.Fatalf("unfollow: %v", err)
+	}
+	follows, _ := env.store.GetFeedFollowsForUser(context.Background(), user.ID)
+	if len(follows) != 0 {
+		t.Errorf("follows = %+v, want none", follows)
+	}
+
+	if _, err := env.run(t, middlewareLoggedIn(handlerFeedUnfollow), "unfollow"); err == nil {
+		t.Error("unfollow without a url succeeded")
+	}
+}
+
+func TestHandlerFollowing(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	env.addFeed(t, "News", "https://example.com/news.xml")
+
+	if _, err := env.run(t, middlewareLoggedIn(handlerFollowing), "following"); err != nil {
+		t.Fatalf("following: %v", err)
+	}
+	logs := env.logs.String()
+	for _, want := range []string{"count=2", "- Blog", "- News", "unread=0"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("following logs miss %q:\n%s", want, logs)
+		}
+	}
+}
+
+func TestHandlerBrowse(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	savePostsForTest(t, env, feed,
+		testItem{title: "Older", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Newer", guid: "2", pubDate: "Tue, 06 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Oldest", guid: "3", pubDate: "Sun, 04 Oct 2026 10:00:00 +0000"},
+	)
+	browse := middlewareLoggedIn(handlerBrowse)
+
+	output, err := env.run(t, browse, "browse")
+	if err != nil {
+		t.Fatalf("browse: %v", err)
+	}
+	if !strings.Contains(output, "--- Newer ---") || !strings.Contains(output, "--- Older ---") || strings.Contains(output, "Oldest") {
+		t.Errorf("browse should show the 2 newest posts:\n%s", output)
+	}
+	if strings.Index(output, "Newer") > strings.Index(output, "Older") {
+		t.Errorf("browse should show the newest post first:\n%s", output)
+	}
+
+	output, err = env.run(t, browse, "browse", "5")
+	if err != nil {
+		t.Fatalf("browse 5: %v", err)
+	}
+	if !strings.Contains(output, "--- Oldest ---") {
+		t.Errorf("browse 5 should show all posts:\n%s", output)
+	}
+
+	if _, err := env.run(t, browse, "browse", "many"); err == nil {
+		t.Error("browse with an invalid limit succeeded")
+	}
+}
+
+func TestHandlerBrowsePages(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	savePostsForTest(t, env, feed,
+		testItem{title: "Post 1", guid: "1", pubDate: "Thu, 01 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Post 2", guid: "2", pubDate: "Fri, 02 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Post 3", guid: "3", pubDate: "Fri, 02 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Post 4", guid: "4", pubDate: "Sat, 03 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Undated", guid: "5"},
+	)
+	browse := middlewareLoggedIn(handlerBrowse)
+
+	// follow runs the page link printed after label.
+	follow := func(output, label string) string {
+		t.Helper()
+		_, link, ok := strings.Cut(output, label+": ")
+		if !ok {
+			t.Fatalf("no %q link in:\n%s", label, output)
+		}
+		args := strings.Fields(strings.SplitN(link, "\n", 2)[0])
+		page, err := env.run(t, browse, args[0], args[1:]...)
+		if err != nil {
+			t.Fatalf("%s: %v", strings.Join(args, " "), err)
+		}
+		return page
+	}
+	titles := func(output string) []string {
+		var titles []string
+		for _, line := range strings.Split(output, "\n") {
+			if title, ok := strings.CutPrefix(line, "--- "); ok {
+				titles = append(titles, strings.TrimSuffix(title, " ---"))
+			}
+		}
+		return titles
+	}
+
+	first, err := env.run(t, browse, "browse", "2")
+	if err != nil {
+		t.Fatalf("browse: %v", err)
+	}
+	if got := titles(first); !slices.Equal(got, []string{"Undated", "Post 4"}) {
+		t.Errorf("first page = %v", got)
+	}
+	if strings.Contains(first, "Previous page") {
+		t.Errorf("first page has a previous page:\n%s", first)
+	}
+
+	second := follow(first, "Next page")
+	if got := titles(second); len(got) != 2 || got[0] == "Post 1" || !strings.HasPrefix(got[0], "Post ") {
+		t.Errorf("second page = %v, want Post 3 and Post 2", got)
+	}
+	third := follow(second, "Next page")
+	if got := titles(third); !slices.Equal(got, []string{"Post 1"}) {
+		t.Errorf("third page = %v", got)
+	}
+	if strings.Contains(third, "Next page") {
+		t.Errorf("last page has a next page:\n%s", third)
+	}
+
+	if back := follow(third, "Previous page"); !slices.Equal(titles(back), titles(second)) {
+		t.Errorf("previous page of the third = %v, want %v", titles(back), titles(second))
+	}
+	if back := follow(second, "Previous page"); !slices.Equal(titles(back), titles(first)) || strings.Contains(back, "Previous page") {
+		t.Errorf("previous page of the second = %v, want %v:\n%s", titles(back), titles(first), back)
+	}
+
+	window, err := env.run(t, browse, "browse", "--all", "--since", "2026-10-02T00:00:00Z", "--until", "2026-10-03T00:00:00Z", "1")
+	if err != nil {
+		t.Fatalf("browse --since --until: %v", err)
+	}
+	if !strings.Contains(window, "Next page: browse --all --since 2026-10-02T00:00:00Z --until 2026-10-03T00:00:00Z --next ") {
+		t.Errorf("page links should keep the options:\n%s", window)
+	}
+	if got := append(titles(window), titles(follow(window, "Next page"))...); len(got) != 2 || slices.Contains(got, "Post 1") || slices.Contains(got, "Post 4") {
+		t.Errorf("window = %v, want Post 2 and Post 3", got)
+	}
+
+	for _, args := range [][]string{{"--next", "garbage"}, {"--next", "a", "--prev", "b"}, {"--since", "soon"}, {"0"}} {
+		if _, err := env.run(t, browse, "browse", args...); err == nil {
+			t.Errorf("browse %v succeeded", args)
+		}
+	}
+}
+
+func TestHandlerFetchLog(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+
+	now := time.Now().UTC()
+	for i, entry := range []database.CreateFetchLogParams{
+		{StartedAt: now.Add(-2 * time.Hour), DurationMs: 100, HttpStatus: sql.NullInt32{Int32: 200, Valid: true}, ItemsParsed: 5, NewPosts: 5},
+		{StartedAt: now.Add(-time.Hour), DurationMs: 300, HttpStatus: sql.NullInt32{Int32: 500, Valid: true}, Error: sql.NullString{String: "server error", Valid: true}},
+	} {
+		entry.ID, entry.FeedID = uuid.New(), feed.ID
+		if err := env.store.CreateFetchLog(context.Background(), entry); err != nil {
+			t.Fatalf("create fetch log %d: %v", i, err)
+		}
+	}
+
+	output, err := env.run(t, handlerFetchLog, "fetchlog", feed.Url)
+	if err != nil {
+		t.Fatalf("fetchlog: %v", err)
+	}
+	for _, want := range []string{"Fetches:        2 (1 failed)", "avg 200ms, max 300ms", "New posts:      5", "error: server error"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("fetchlog output misses %q:\n%s", want, output)
+		}
+	}
+
+	output, err = env.run(t, handlerFetchLog, "fetchlog", feed.Url, "1")
+	if err != nil {
+		t.Fatalf("fetchlog 1: %v", err)
+	}
+	if strings.Count(output, "HTTP ") != 1 || !strings.Contains(output, "HTTP 500") {
+		t.Errorf("fetchlog 1 should show the latest entry only:\n%s", output)
+	}
+
+	for _, args := range [][]string{{}, {feed.Url, "many"}, {feed.Url, "0"}, {feed.Url, "-5"}, {"https://example.com/missing.xml"}} {
+		if _, err := env.run(t, handlerFetchLog, "fetchlog", args...); err == nil {
+			t.Errorf("fetchlog %v succeeded", args)
+		}
+	}
+	if _, err := env.run(t, handlerFetchLog, "fetchlog", feed.Url, "0"); err == nil || err.Error() != "limit must be at least 1" {
+		t.Errorf("fetchlog with a zero limit: err = %v", err)
+	}
+}
+
+func TestHandlerFetchLogSQLite(t *testing.T) {
+	// The fetch statistics are aggregated in SQL, so check them on a real
+	// database against fetches made by the aggregator.
+	env := newSQLiteEnv(t)
+	if _, err := env.run(t, handlerMigrate, "migrate", "up"); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	if _, err := env.run(t, handlerRegister, "register", "alan"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	server := newFeedServer(t, testItem{title: "One", guid: "1"}, testItem{title: "Two", guid: "2"})
+	if _, err := env.run(t, middlewareLoggedIn(handlerAddFeed), "addfeed", "Blog", server.URL); err != nil {
+		t.Fatalf("addfeed: %v", err)
+	}
+
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", server.URL); err != nil {
+		t.Fatalf("agg --feed: %v", err)
+	}
+	server.setStatus(http.StatusInternalServerError)
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", server.URL); err == nil {
+		t.Fatal("agg --feed on a failing feed succeeded")
+	}
+
+	output, err := env.run(t, handlerFetchLog, "fetchlog", server.URL)
+	if err != nil {
+		t.Fatalf("fetchlog: %v", err)
+	}
+	for _, want := range []string{"Fetches:        2 (1 failed)", "New posts:      2", "HTTP 200", "2 items    2 new", "HTTP 500", "error: non-OK HTTP status"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("fetchlog output misses %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Last new posts: never") {
+		t.Errorf("fetchlog should show when the feed last had new posts:\n%s", output)
+	}
+}
+
+func TestHandlerPruneFetchLog(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+
+	now := time.Now().UTC()
+	for _, startedAt := range []time.Time{now.Add(-48 * time.Hour), now.Add(-time.Hour)} {
+		err := env.store.CreateFetchLog(context.Background(), database.CreateFetchLogParams{ID: uuid.New(), FeedID: feed.ID, StartedAt: startedAt})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := env.run(t, handlerFetchLog, "fetchlog", "prune", "24h"); err != nil {
+		t.Fatalf("fetchlog prune: %v", err)
+	}
+	entries, _ := env.store.GetFetchLogForFeed(context.Background(), database.GetFetchLogForFeedParams{FeedID: feed.ID, Limit: 10})
+	if len(entries) != 1 {
+		t.Errorf("%d entries left, want 1", len(entries))
+	}
+
+	if _, err := env.run(t, handlerFetchLog, "fetchlog", "prune", "a while"); err == nil {
+		t.Error("prune with an invalid retention succeeded")
+	}
+}
+
+func TestHandlerValidate(t *testing.T) {
+	env := newTestEnv(t)
+	server := newFeedServer(t,
+		testItem{title: "One", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Two", guid: "2", pubDate: "yesterday"},
+	)
+
+	output, err := env.run(t, handlerValidate, "validate", server.URL)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	for _, want := range []string{"HTTP status:  200 OK", "Format:       RSS 2.0", "Items:        2", "Unparseable dates (1)", `"yesterday"`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("validate output misses %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(env.logs.String(), "Feed looks healthy") {
+		t.Errorf("a feed with an unparseable date was reported healthy:\n%s", env.logs.String())
+	}
+
+	// A feed without caching headers only gets an advisory.
+	server.setItems(testItem{title: "One", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"})
+	output, err = env.run(t, handlerValidate, "validate", server.URL)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if !strings.Contains(output, "Advisories (1)") || !strings.Contains(output, "no ETag or Last-Modified header") {
+		t.Errorf("validate output misses the caching advisory:\n%s", output)
+	}
+	if !strings.Contains(env.logs.String(), "Feed looks healthy") {
+		t.Errorf("a feed with only advisories should be reported healthy:\n%s", env.logs.String())
+	}
+
+	server.setStatus(http.StatusNotFound)
+	output, err = env.run(t, handlerValidate, "validate", server.URL)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if !strings.Contains(output, "HTTP status:  404 Not Found") {
+		t.Errorf("validate output misses the 404 status:\n%s", output)
+	}
+
+	server.Close()
+	if _, err := env.run(t, handlerValidate, "validate", server.URL); err == nil {
+		t.Error("validating an unreachable feed succeeded")
+	}
+	if _, err := env.run(t, handlerValidate, "validate"); err == nil {
+		t.Error("validate without a url succeeded")
+	}
+}
+
+// savePostsForTest saves items as posts of feed, the way the aggregator does.
+func savePostsForTest(t *testing.T, env *testEnv, feed database.Feed, items ...testItem) {
+	t.Helper()
+	err := env.store.InTx(context.Background(), func(q database.Querier) error {
+		_, _, err := savePosts(context.Background(), q, feed, rssItems(items))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("save posts: %v", err)
+	}
+}
+
+// failFeedForTest records a failed fetch of a feed, claiming it first the
+// way the aggregator does.
+func failFeedForTest(t *testing.T, env *testEnv, arg database.MarkFeedFailedParams) {
+	t.Helper()
+	_, err := env.store.ClaimFeed(context.Background(), database.ClaimFeedParams{ID: arg.ID, LeaseOwner: "test", LeaseSeconds: 60})
+	if err != nil {
+		t.Fatalf("claim feed: %v", err)
+	}
+	arg.LeaseOwner = "test"
+	if _, err := env.store.MarkFeedFailed(context.Background(), arg); err != nil {
+		t.Fatalf("mark feed failed: %v", err)
+	}
+}
+
+func TestMiddlewareLoggedIn(t *testing.T) {
+	env := newTestEnv(t)
+
+	var got database.User
+	handler := middlewareLoggedIn(func(ctx context.Context, s *state, cmd command, user database.User) error {
+		got = user
+		return nil
+	})
+
+	if _, err := env.run(t, handler, "test"); err == nil {
+		t.Error("handler ran without a logged in user")
+	}
+
+	env.s.cfg.CurrentUserName = "ghost"
+	if _, err := env.run(t, handler, "test"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("unknown current user: err = %v, want sql.ErrNoRows", err)
+	}
+
+	user := env.register(t, "alan")
+	if _, err := env.run(t, handler, "test"); err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	if got.ID != user.ID {
+		t.Errorf("handler got user %+v, want alan", got)
+	}
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+// healthMonitor tracks the aggregator's progress for the /healthz and /readyz
+// endpoints. The scheduler loop is alive as long as some worker has made
+// progress within aliveWindow; staleAfter, when set, also fails the health
+// check once no feed has been scraped successfully for that long.
+type healthMonitor struct {
+	s           *state
+	startedAt   time.Time
+	aliveWindow time.Duration
+	staleAfter  time.Duration
+	// leader is set when the process takes part in leader election.
+	leader *leaderElector
+
+	heartbeat   atomic.Int64
+	lastSuccess atomic.Int64
+}
+
+func newHealthMonitor(s *state, aliveWindow, staleAfter time.Duration) *healthMonitor {
+	h := &healthMonitor{
+		s:           s,
+		startedAt:   time.Now(),
+		aliveWindow: aliveWindow,
+		staleAfter:  staleAfter,
+	}
+	h.beat()
+	return h
+}
+
+// beat records that the scheduler loop is still turning.
+func (h *healthMonitor) beat() {
+	h.heartbeat.Store(time.Now().UnixNano())
+}
+
+// scraped records a successful scrape.
+func (h *healthMonitor) scraped() {
+	h.lastSuccess.Store(time.Now().UnixNano())
+}
+
+type healthCheck struct {
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type healthReport struct {
+	Status                 string                 `json:"status"`
+	Uptime                 string                 `json:"uptime"`
+	Instance               string                 `json:"instance,omitempty"`
+	Leader                 string                 `json:"leader,omitempty"`
+	IsLeader               bool                   `json:"is_leader"`
+	LeaderSince            *time.Time             `json:"leader_since,omitempty"`
+	LastSuccessfulScrape   *time.Time             `json:"last_successful_scrape"`
+	SecondsSinceLastScrape *float64               `json:"seconds_since_last_scrape"`
+	Checks                 map[string]healthCheck `json:"checks"`
+}
+
+// register adds the health endpoints to mux. /healthz fails when the
+// scheduler loop is stuck, so the orchestrator restarts the process; /readyz
+// also fails while the database is unreachable.
+func (h *healthMonitor) register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		h.respond(w, h.report(r.Context(), false))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		h.respond(w, h.report(r.Context(), true))
+	})
+}
+
+func (h *healthMonitor) report(ctx context.Context, ready bool) healthReport {
+	now := time.Now()
+	report := healthReport{
+		Status: "ok",
+		Uptime: now.Sub(h.startedAt).Round(time.Second).String(),
+		Checks: make(map[string]healthCheck),
+	}
+
+	sinceBeat := now.Sub(time.Unix(0, h.heartbeat.Load()))
+	report.Checks["scheduler"] = healthCheck{
+		OK:     sinceBeat <= h.aliveWindow,
+		Detail: fmt.Sprintf("last activity %s ago", sinceBeat.Round(time.Second)),
+	}
+
+	// Until the first success, the process's start time stands in for the
+	// last scrape so a fresh instance isn't reported stale straight away.
+	lastScrape := h.startedAt
+	if nanos := h.lastSuccess.Load(); nanos != 0 {
+		last := time.Unix(0, nanos).UTC()
+		since := now.Sub(last).Seconds()
+		report.LastSuccessfulScrape = &last
+		report.SecondsSinceLastScrape = &since
+		lastScrape = last
+	}
+	if h.staleAfter > 0 {
+		sinceScrape := now.Sub(lastScrape)
+		report.Checks["scrape"] = healthCheck{
+			OK:     sinceScrape <= h.staleAfter,
+			Detail: fmt.Sprintf("last successful scrape %s ago", sinceScrape.Round(time.Second)),
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	if h.leader != nil {
+		report.Instance = h.leader.instanceID
+		report.IsLeader = h.leader.isLeader()
+		if report.IsLeader {
+			since := h.leader.leaderSince().UTC()
+			report.LeaderSince = &since
+		}
+		// Not knowing the leader doesn't make this instance unhealthy.
+		if leader, err := h.leader.currentLeader(ctx); err == nil {
+			report.Leader = leader
+		}
+	}
+
+	if ready {
+		check := healthCheck{OK: true}
+		if err := h.s.conn.PingContext(ctx); err != nil {
+			check = healthCheck{OK: false, Detail: err.Error()}
+		}
+		report.Checks["database"] = check
+	}
+
+	for _, check := range report.Checks {
+		if !check.OK {
+			report.Status = "unavailable"
+		}
+	}
+	return report
+}
+
+func (h *healthMonitor) respond(w http.ResponseWriter, report healthReport) {
+	status := http.StatusOK
+	if report.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		h.s.logger.Error("Couldn't write health report", "error", err)
+	}
+}
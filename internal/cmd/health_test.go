@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Pizzu/gator/internal/config"
+	"github.com/Pizzu/gator/internal/storage"
+	"github.com/charmbracelet/log"
+)
+
+// getHealthForTest requests path from the monitor's endpoints and decodes
+// the report.
+func getHealthForTest(t *testing.T, h *healthMonitor, path string) (int, healthReport) {
+	t.Helper()
+	mux := http.NewServeMux()
+	h.register(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var report healthReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decode %s report: %v", path, err)
+	}
+	return rec.Code, report
+}
+
+func TestHealthz(t *testing.T) {
+	h := newHealthMonitor(&state{logger: log.New(io.Discard)}, time.Minute, 0)
+
+	code, report := getHealthForTest(t, h, "/healthz")
+	if code != http.StatusOK || report.Status != "ok" || !report.Checks["scheduler"].OK {
+		t.Errorf("/healthz of a fresh monitor = %d %+v", code, report)
+	}
+	if _, ok := report.Checks["scrape"]; ok || report.LastSuccessfulScrape != nil {
+		t.Errorf("/healthz without a stale limit or scrape = %+v", report)
+	}
+	if _, ok := report.Checks["database"]; ok {
+		t.Error("/healthz should not check the database")
+	}
+
+	// A loop that hasn't turned within the window is stuck.
+	h.heartbeat.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	code, report = getHealthForTest(t, h, "/healthz")
+	if code != http.StatusServiceUnavailable || report.Status != "unavailable" || report.Checks["scheduler"].OK {
+		t.Errorf("/healthz of a stuck loop = %d %+v", code, report)
+	}
+	h.beat()
+	if code, _ := getHealthForTest(t, h, "/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz after a heartbeat = %d", code)
+	}
+}
+
+func TestHealthzStale(t *testing.T) {
+	h := newHealthMonitor(&state{logger: log.New(io.Discard)}, time.Minute, time.Hour)
+
+	// A fresh instance isn't stale before it had the chance to scrape.
+	code, report := getHealthForTest(t, h, "/healthz")
+	if code != http.StatusOK || !report.Checks["scrape"].OK {
+		t.Errorf("/healthz of a fresh instance = %d %+v", code, report)
+	}
+
+	h.startedAt = time.Now().Add(-2 * time.Hour)
+	code, report = getHealthForTest(t, h, "/healthz")
+	if code != http.StatusServiceUnavailable || report.Checks["scrape"].OK {
+		t.Errorf("/healthz without a scrape for 2h = %d %+v", code, report)
+	}
+
+	h.scraped()
+	code, report = getHealthForTest(t, h, "/healthz")
+	if code != http.StatusOK || report.LastSuccessfulScrape == nil || *report.SecondsSinceLastScrape > 60 {
+		t.Errorf("/healthz after a scrape = %d %+v", code, report)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	backend, err := storage.Open("sqlite:" + filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer backend.DB.Close()
+	h := newHealthMonitor(NewState(&config.Config{}, backend, log.New(io.Discard)), time.Minute, 0)
+
+	code, report := getHealthForTest(t, h, "/readyz")
+	if code != http.StatusOK || !report.Checks["database"].OK || !report.Checks["scheduler"].OK {
+		t.Errorf("/readyz = %d %+v", code, report)
+	}
+
+	backend.DB.Close()
+	code, report = getHealthForTest(t, h, "/readyz")
+	if code != http.StatusServiceUnavailable || report.Checks["database"].OK || report.Checks["database"].Detail == "" {
+		t.Errorf("/readyz with the database closed = %d %+v", code, report)
+	}
+	if code, _ := getHealthForTest(t, h, "/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz with the database closed = %d, want it to stay healthy", code)
+	}
+}
+
+func TestHealthzLeader(t *testing.T) {
+	s := &state{logger: log.New(io.Discard)}
+	elector := newLeaderElector(s, "instance-1")
+	h := newHealthMonitor(s, time.Minute, 0)
+	h.leader = elector
+
+	elector.since.Store(time.Now().UnixNano())
+	elector.leader.Store(true)
+	code, report := getHealthForTest(t, h, "/healthz")
+	if code != http.StatusOK || !report.IsLeader || report.Leader != "instance-1" || report.Instance != "instance-1" || report.LeaderSince == nil {
+		t.Errorf("/healthz of the leader = %d %+v", code, report)
+	}
+}
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/Pizzu/gator/internal/hooks"
+	"github.com/google/uuid"
+)
+
+const (
+	hookTimeout     = 10 * time.Second
+	hookAttempts    = 3
+	hookRetryDelay  = 2 * time.Second
+	hookConcurrency = 4
+	hookQueueSize   = 256
+)
+
+func handlerHooks(ctx context.Context, s *state, cmd command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return handlerListHooks(ctx, s, cmd, user)
+	}
+
+	sub := command{Name: cmd.Name + " " + cmd.Args[0], Args: cmd.Args[1:]}
+	switch cmd.Args[0] {
+	case "add":
+		return handlerAddHook(ctx, s, sub, user)
+	case "remove":
+		return handlerRemoveHook(ctx, s, sub, user)
+	default:
+		return fmt.Errorf("usage: %s [add | remove]", cmd.Name)
+	}
+}
+
+func handlerListHooks(ctx context.Context, s *state, _ command, user database.User) error {
+	userHooks, err := s.db.GetHooksForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get hooks: %w", err)
+	}
+
+	if len(userHooks) == 0 {
+		fmt.Println("No hooks, add one with: hooks add webhook <url>")
+		return nil
+	}
+
+	for _, hook := range userHooks {
+		feeds := "all followed feeds"
+		if hook.FeedUrl.Valid {
+			feeds = hook.FeedUrl.String
+		}
+		fmt.Printf("* %s %s: %s\n", hook.ID, hook.Kind, hook.Target)
+		fmt.Printf("  Feeds:          %s\n", feeds)
+		if hook.Kind == "webhook" {
+			fmt.Printf("  Signed:         %t\n", hook.Secret.Valid)
+		}
+		fmt.Printf("  Last delivered: %s\n", formatNullTime(hook.LastDeliveredAt))
+		if hook.LastError.Valid {
+			fmt.Printf("  Last error:     %s\n", hook.LastError.String)
+		}
+	}
+
+	return nil
+}
+
+func handlerAddHook(ctx context.Context, s *state, cmd command, user database.User) error {
+	usage := fmt.Errorf("usage: %s [--feed url] [--secret secret] webhook <url> | %s [--feed url] command <command>", cmd.Name, cmd.Name)
+
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	feedURL := flags.String("feed", "", "only fire for posts of this feed")
+	secret := flags.String("secret", "", "sign webhook payloads with HMAC-SHA256 using this secret")
+
+	if err := flags.Parse(cmd.Args); err != nil || flags.NArg() != 2 {
+		return usage
+	}
+	kind, target := flags.Arg(0), flags.Arg(1)
+
+	switch kind {
+	case "webhook":
+		parsed, err := url.Parse(target)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid webhook url: %s", target)
+		}
+	case "command":
+		if *secret != "" {
+			return errors.New("--secret only applies to webhooks")
+		}
+	default:
+		return usage
+	}
+
+	params := database.CreateHookParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Kind:      kind,
+		Target:    target,
+		Secret:    sql.NullString{String: *secret, Valid: *secret != ""},
+	}
+
+	if *feedURL != "" {
+		feed, err := s.db.GetFeedByUrl(ctx, *feedURL)
+		if err != nil {
+			return fmt.Errorf("couldn't find feed: %w", err)
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	hook, err := s.db.CreateHook(ctx, params)
+	if err != nil {
+		return fmt.Errorf("couldn't create hook: %w", err)
+	}
+
+	fmt.Printf("Hook %s added, it fires for new posts of the feeds %s follows\n", hook.ID, user.Name)
+	return nil
+}
+
+func handlerRemoveHook(ctx context.Context, s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %s <id>", cmd.Name)
+	}
+
+	id, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("invalid hook id: %w", err)
+	}
+
+	removed, err := s.db.DeleteHook(ctx, database.DeleteHookParams{ID: id, UserID: user.ID})
+	if err != nil {
+		return fmt.Errorf("couldn't remove hook: %w", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("%s has no hook %s", user.Name, id)
+	}
+
+	fmt.Printf("Hook %s removed\n", id)
+	return nil
+}
+
+// hookDispatcher delivers new posts to the hooks of the users following
+// their feed. dispatch only queues deliveries, which hookConcurrency
+// goroutines work through in the background so slow receivers don't hold up
+// scraping; when the queue is full, deliveries are dropped. wait blocks until
+// the queued deliveries are done.
+type hookDispatcher struct {
+	s      *state
+	sender *hooks.Sender
+	queue  chan hookDelivery
+	wg     sync.WaitGroup
+}
+
+type hookDelivery struct {
+	hook    database.GetHooksForFeedRow
+	payload hooks.Payload
+}
+
+// newHookDispatcher starts the delivery goroutines, which deliver on ctx.
+func newHookDispatcher(ctx context.Context, s *state) *hookDispatcher {
+	d := &hookDispatcher{
+		s:      s,
+		sender: hooks.NewSender(hookTimeout, hookAttempts, hookRetryDelay),
+		queue:  make(chan hookDelivery, hookQueueSize),
+	}
+	for range hookConcurrency {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for delivery := range d.queue {
+				d.deliver(ctx, delivery.hook, delivery.payload)
+			}
+		}()
+	}
+	return d
+}
+
+func (d *hookDispatcher) dispatch(ctx context.Context, feed database.Feed, posts []database.Post) {
+	if len(posts) == 0 {
+		return
+	}
+
+	feedHooks, err := d.s.db.GetHooksForFeed(ctx, feed.ID)
+	if err != nil {
+		d.s.logger.Error("Couldn't get hooks for feed", "feed_id", feed.ID, "feed", feed.Name, "error", err)
+		return
+	}
+
+	for _, hook := range feedHooks {
+		for _, post := range posts {
+			select {
+			case d.queue <- hookDelivery{hook: hook, payload: hookPayload(hook, feed, post)}:
+			default:
+				d.s.logger.Warn("Hook queue is full, dropping delivery",
+					"hook_id", hook.ID, "kind", hook.Kind, "user", hook.UserName, "feed_id", feed.ID, "url", post.Url)
+			}
+		}
+	}
+}
+
+func (d *hookDispatcher) deliver(ctx context.Context, hook database.GetHooksForFeedRow, payload hooks.Payload) {
+	startedAt := time.Now()
+	var err error
+	switch hook.Kind {
+	case "webhook":
+		err = d.sender.PostWebhook(ctx, hook.Target, hook.Secret.String, payload)
+	case "command":
+		err = d.sender.RunCommand(ctx, hook.Target, payload)
+	default:
+		err = fmt.Errorf("unknown hook kind %q", hook.Kind)
+	}
+
+	if err != nil {
+		d.s.logger.Error("Hook failed",
+			"hook_id", hook.ID, "kind", hook.Kind, "user", hook.UserName, "feed_id", payload.Feed.ID,
+			"url", payload.Post.URL, "duration", time.Since(startedAt), "error", err)
+		err = d.s.db.MarkHookFailed(ctx, database.MarkHookFailedParams{
+			ID:        hook.ID,
+			LastError: sql.NullString{String: err.Error(), Valid: true},
+		})
+	} else {
+		err = d.s.db.MarkHookDelivered(ctx, hook.ID)
+	}
+	if err != nil {
+		d.s.logger.Error("Couldn't save outcome of hook", "hook_id", hook.ID, "error", err)
+	}
+}
+
+// wait stops accepting deliveries and blocks until the queued ones are done.
+func (d *hookDispatcher) wait() {
+	close(d.queue)
+	d.wg.Wait()
+}
+
+func hookPayload(hook database.GetHooksForFeedRow, feed database.Feed, post database.Post) hooks.Payload {
+	payload := hooks.Payload{
+		Event:    hooks.EventPostCreated,
+		Delivery: uuid.NewString(),
+		User:     hook.UserName,
+		Feed: hooks.Feed{
+			ID:   feed.ID.String(),
+			Name: feed.Name,
+			URL:  feed.Url,
+		},
+		Post: hooks.Post{
+			ID:          post.ID.String(),
+			GUID:        post.Guid.String,
+			Title:       post.Title,
+			URL:         post.Url,
+			Description: post.Description.String,
+		},
+	}
+	if post.PublishedAt.Valid {
+		payload.Post.PublishedAt = &post.PublishedAt.Time
+	}
+	return payload
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestHandlerHooks(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	hooksCmd := middlewareLoggedIn(handlerHooks)
+
+	output, err := env.run(t, hooksCmd, "hooks")
+	if err != nil {
+		t.Fatalf("hooks: %v", err)
+	}
+	if !strings.Contains(output, "No hooks") {
+		t.Errorf("hooks should report there are none:\n%s", output)
+	}
+
+	if _, err := env.run(t, hooksCmd, "hooks", "add", "--secret", "s3cret", "webhook", "https://example.com/hook"); err != nil {
+		t.Fatalf("hooks add webhook: %v", err)
+	}
+	if _, err := env.run(t, hooksCmd, "hooks", "add", "--feed", feed.Url, "command", "notify-send new post"); err != nil {
+		t.Fatalf("hooks add command: %v", err)
+	}
+
+	output, err = env.run(t, hooksCmd, "hooks")
+	if err != nil {
+		t.Fatalf("hooks: %v", err)
+	}
+	for _, want := range []string{"webhook: https://example.com/hook", "Signed:         true", "command: notify-send new post", "Feeds:          " + feed.Url} {
+		if !strings.Contains(output, want) {
+			t.Errorf("hooks output misses %q:\n%s", want, output)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"add", "webhook", "ftp://example.com/hook"},
+		{"add", "--secret", "s3cret", "command", "true"},
+		{"add", "--feed", "https://example.com/missing.xml", "command", "true"},
+		{"add", "email", "alan@example.com"},
+		{"add", "webhook"},
+		{"nope"},
+	} {
+		if _, err := env.run(t, hooksCmd, "hooks", args...); err == nil {
+			t.Errorf("hooks %v succeeded", args)
+		}
+	}
+
+	rows, _ := env.store.GetHooksForUser(context.Background(), user.ID)
+	if len(rows) != 2 {
+		t.Fatalf("got %d hooks, want 2", len(rows))
+	}
+	if rows[1].FeedID.UUID != feed.ID {
+		t.Errorf("command hook feed = %v, want %v", rows[1].FeedID, feed.ID)
+	}
+}
+
+func TestHandlerRemoveHook(t *testing.T) {
+	env := newTestEnv(t)
+	alan := env.register(t, "alan")
+	hooksCmd := middlewareLoggedIn(handlerHooks)
+
+	if _, err := env.run(t, hooksCmd, "hooks", "add", "command", "true"); err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := env.store.GetHooksForUser(context.Background(), alan.ID)
+	id := rows[0].ID.String()
+
+	env.register(t, "bob")
+	if _, err := env.run(t, hooksCmd, "hooks", "remove", id); err == nil {
+		t.Error("removing another user's hook succeeded")
+	}
+
+	if _, err := env.run(t, handlerLogin, "login", "alan"); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"remove"}, {"remove", "not-a-uuid"}, {"remove", uuid.NewString()}} {
+		if _, err := env.run(t, hooksCmd, "hooks", args...); err == nil {
+			t.Errorf("hooks %v succeeded", args)
+		}
+	}
+
+	if _, err := env.run(t, hooksCmd, "hooks", "remove", id); err != nil {
+		t.Fatalf("hooks remove: %v", err)
+	}
+	if rows, _ := env.store.GetHooksForUser(context.Background(), alan.ID); len(rows) != 0 {
+		t.Errorf("hook not removed: %+v", rows)
+	}
+}
+
+func TestHookDispatcherConcurrency(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+
+	var received atomic.Int32
+	var mu sync.Mutex
+	var running, maxRunning int
+	started := make(chan struct{}, hookConcurrency)
+	release := make(chan struct{})
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		mu.Lock()
+		running--
+		mu.Unlock()
+		received.Add(1)
+	}))
+	defer webhook.Close()
+
+	if _, err := env.store.CreateHook(context.Background(), database.CreateHookParams{
+		ID:     uuid.New(),
+		UserID: user.ID,
+		Kind:   "webhook",
+		Target: webhook.URL,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// More posts than the delivery goroutines and the queue can take, so
+	// some deliveries are dropped.
+	posts := make([]database.Post, hookConcurrency+hookQueueSize+10)
+	for i := range posts {
+		posts[i] = database.Post{ID: uuid.New(), FeedID: feed.ID, Title: fmt.Sprintf("Post %d", i), Url: fmt.Sprintf("https://example.com/%d", i)}
+	}
+
+	d := newHookDispatcher(context.Background(), env.s)
+	done := make(chan struct{})
+	go func() {
+		d.dispatch(context.Background(), feed, posts)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("dispatch waited for deliveries")
+	}
+
+	for range hookConcurrency {
+		<-started
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	d.wait()
+
+	if maxRunning > hookConcurrency {
+		t.Errorf("%d deliveries ran at once, want at most %d", maxRunning, hookConcurrency)
+	}
+	dropped := strings.Count(env.logs.String(), "Hook queue is full")
+	if dropped == 0 {
+		t.Errorf("no deliveries were dropped:\n%s", env.logs.String())
+	}
+	if n := int(received.Load()); n+dropped != len(posts) {
+		t.Errorf("webhook received %d payloads and %d were dropped, want %d in all", n, dropped, len(posts))
+	}
+}
@@ -0,0 +1,329 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	defaultJobTimeout = 10 * time.Minute
+	fetchLogRetention = 30 * 24 * time.Hour
+
+	jobSucceeded = "succeeded"
+	jobFailed    = "failed"
+)
+
+// job is a periodic maintenance task run by the scheduler inside agg.
+// schedule is the default cron expression, used until it is changed with
+// the jobs command.
+type job struct {
+	name        string
+	description string
+	schedule    string
+	timeout     time.Duration
+	run         func(ctx context.Context, s *state) error
+}
+
+var jobs = []job{
+	{
+		name:        "prune-fetchlog",
+		description: "delete fetch log entries older than 30 days",
+		schedule:    "0 3 * * *",
+		timeout:     defaultJobTimeout,
+		run:         jobPruneFetchLog,
+	},
+	{
+		name:        "prune-posts",
+		description: "delete posts older than the post_retention setting, if set",
+		schedule:    "30 3 * * *",
+		timeout:     defaultJobTimeout,
+		run:         jobPrunePosts,
+	},
+	{
+		name:        "feed-health",
+		description: "log a warning for feeds that are failing or disabled",
+		schedule:    "@hourly",
+		timeout:     time.Minute,
+		run:         jobFeedHealth,
+	},
+}
+
+func findJob(name string) (job, bool) {
+	for _, j := range jobs {
+		if j.name == name {
+			return j, true
+		}
+	}
+	return job{}, false
+}
+
+func jobPruneFetchLog(ctx context.Context, s *state) error {
+	pruned, err := s.db.PruneFetchLog(ctx, time.Now().UTC().Add(-fetchLogRetention))
+	if err != nil {
+		return err
+	}
+	s.logger.Info("Pruned fetch log", "deleted", pruned, "retention", fetchLogRetention)
+	return nil
+}
+
+// jobPrunePosts prunes posts like the prune command, with the retention
+// from the config. Pruning is off until a retention is set.
+func jobPrunePosts(ctx context.Context, s *state) error {
+	if s.cfg.PostRetention == "" {
+		s.logger.Debug("Not pruning posts, post_retention is not set")
+		return nil
+	}
+
+	retention, err := time.ParseDuration(s.cfg.PostRetention)
+	if err != nil || retention <= 0 {
+		return fmt.Errorf("invalid post_retention %q, want a positive duration such as 2160h", s.cfg.PostRetention)
+	}
+
+	pruned, err := prunePosts(ctx, s.db, time.Now().UTC().Add(-retention))
+	if err != nil {
+		return err
+	}
+	s.logger.Info("Pruned posts", "deleted", pruned, "retention", retention)
+	return nil
+}
+
+func jobFeedHealth(ctx context.Context, s *state) error {
+	feeds, err := s.db.GetUnhealthyFeeds(ctx)
+	if err != nil {
+		return err
+	}
+
+	disabled := 0
+	for _, feed := range feeds {
+		if feed.DisabledAt.Valid {
+			disabled++
+		}
+	}
+	if len(feeds) > 0 {
+		s.logger.Warn("Feeds are failing, see: feeds health", "failing", len(feeds), "disabled", disabled)
+	}
+	return nil
+}
+
+// errJobRunning is returned when a job is already running, in this process
+// or another one.
+var errJobRunning = errors.New("job is already running")
+
+// ensureJobs adds the registered jobs to the jobs table with their default
+// schedule. Existing rows keep their schedule.
+func ensureJobs(ctx context.Context, s *state) error {
+	for _, j := range jobs {
+		err := s.db.EnsureJob(ctx, database.EnsureJobParams{
+			Name:      j.name,
+			CreatedAt: time.Now().UTC(),
+			Schedule:  j.schedule,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't register job %s: %w", j.name, err)
+		}
+	}
+	return nil
+}
+
+// runJob runs j once and records the outcome. The run is claimed in the
+// database first, so a job never runs twice at the same time across all
+// processes; a crashed run is given up on once its timeout has passed.
+func runJob(ctx context.Context, s *state, j job, owner string) error {
+	runner := sql.NullString{String: owner, Valid: true}
+
+	_, err := s.db.StartJobRun(ctx, database.StartJobRunParams{
+		Name:           j.name,
+		RunningOwner:   runner,
+		TimeoutSeconds: durationSeconds(j.timeout),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return errJobRunning
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't start job: %w", err)
+	}
+
+	startedAt := time.Now()
+	runCtx, cancel := context.WithTimeout(ctx, j.timeout)
+	runErr := j.run(runCtx, s)
+	cancel()
+
+	finish := database.FinishJobRunParams{
+		Name:         j.name,
+		RunningOwner: runner,
+		DurationMs:   sql.NullInt32{Int32: int32(time.Since(startedAt) / time.Millisecond), Valid: true},
+		Status:       sql.NullString{String: jobSucceeded, Valid: true},
+	}
+	if runErr != nil {
+		finish.Status.String = jobFailed
+		finish.Error = sql.NullString{String: runErr.Error(), Valid: true}
+	}
+
+	// The outcome is saved even when ctx was cancelled during the run.
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), leaseReleaseTimeout)
+	defer cancel()
+	if err := s.db.FinishJobRun(saveCtx, finish); err != nil {
+		return fmt.Errorf("couldn't save job outcome: %w", err)
+	}
+
+	return runErr
+}
+
+// startScheduler runs the registered jobs on their schedules until the
+// returned cron is stopped. Jobs are skipped while this process is not the
+// leader. Schedules are read once, so changes take effect when agg restarts.
+func startScheduler(ctx context.Context, s *state, owner string, leader *leaderElector) (*cron.Cron, error) {
+	if err := ensureJobs(ctx, s); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.GetJobs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get jobs: %w", err)
+	}
+
+	scheduler := cron.New()
+	for _, row := range rows {
+		j, ok := findJob(row.Name)
+		if !ok {
+			continue
+		}
+
+		_, err := scheduler.AddFunc(row.Schedule, func() {
+			if !leader.isLeader() {
+				s.logger.Debug("Skipping job, not the leader", "job", j.name, "instance", owner)
+				return
+			}
+			s.logger.Info("Running job", "job", j.name)
+			startedAt := time.Now()
+			err := runJob(ctx, s, j, owner)
+			switch {
+			case errors.Is(err, errJobRunning):
+				s.logger.Info("Skipping job, it is still running", "job", j.name)
+			case err != nil:
+				s.logger.Error("Job failed", "job", j.name, "duration", time.Since(startedAt), "error", err)
+			default:
+				s.logger.Info("Job succeeded", "job", j.name, "duration", time.Since(startedAt))
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q for job %s: %w", row.Schedule, j.name, err)
+		}
+	}
+
+	scheduler.Start()
+	s.logger.Info("Scheduled jobs", "jobs", len(scheduler.Entries()))
+	return scheduler, nil
+}
+
+func handlerJobs(ctx context.Context, s *state, cmd command) error {
+	if len(cmd.Args) == 0 {
+		return handlerListJobs(ctx, s, cmd)
+	}
+
+	sub := command{Name: cmd.Name + " " + cmd.Args[0], Args: cmd.Args[1:]}
+	switch cmd.Args[0] {
+	case "run":
+		return handlerRunJob(ctx, s, sub)
+	case "schedule":
+		return handlerScheduleJob(ctx, s, sub)
+	default:
+		return fmt.Errorf("usage: %s [run | schedule]", cmd.Name)
+	}
+}
+
+func handlerListJobs(ctx context.Context, s *state, _ command) error {
+	if err := ensureJobs(ctx, s); err != nil {
+		return err
+	}
+
+	rows, err := s.db.GetJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't get jobs: %w", err)
+	}
+
+	for _, row := range rows {
+		j, ok := findJob(row.Name)
+		if !ok {
+			continue
+		}
+
+		next := "invalid schedule"
+		if schedule, err := cron.ParseStandard(row.Schedule); err == nil {
+			next = schedule.Next(time.Now()).Format(time.DateTime)
+		}
+
+		fmt.Printf("* %s: %s\n", j.name, j.description)
+		fmt.Printf("  Schedule: %s (next %s)\n", row.Schedule, next)
+		if row.RunningOwner.Valid {
+			fmt.Printf("  Running:  since %s on %s\n", formatNullTime(row.LastStartedAt), row.RunningOwner.String)
+		}
+		if !row.LastFinishedAt.Valid {
+			fmt.Println("  Last run: never")
+			continue
+		}
+		fmt.Printf("  Last run: %s, %s in %dms on %s\n",
+			formatNullTime(row.LastFinishedAt), row.LastStatus.String, row.LastDurationMs.Int32, row.LastRunBy.String)
+		if row.LastError.Valid {
+			fmt.Printf("  Error:    %s\n", row.LastError.String)
+		}
+	}
+
+	return nil
+}
+
+func handlerRunJob(ctx context.Context, s *state, cmd command) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %s <job>", cmd.Name)
+	}
+
+	j, ok := findJob(cmd.Args[0])
+	if !ok {
+		return fmt.Errorf("unknown job %q, see: jobs", cmd.Args[0])
+	}
+	if err := ensureJobs(ctx, s); err != nil {
+		return err
+	}
+
+	if err := runJob(ctx, s, j, newInstanceID()); err != nil {
+		return fmt.Errorf("job %s failed: %w", j.name, err)
+	}
+
+	fmt.Printf("Job %s succeeded\n", j.name)
+	return nil
+}
+
+func handlerScheduleJob(ctx context.Context, s *state, cmd command) error {
+	if len(cmd.Args) != 2 {
+		return fmt.Errorf("usage: %s <job> <cron expression|default>", cmd.Name)
+	}
+
+	j, ok := findJob(cmd.Args[0])
+	if !ok {
+		return fmt.Errorf("unknown job %q, see: jobs", cmd.Args[0])
+	}
+
+	schedule := cmd.Args[1]
+	if schedule == "default" {
+		schedule = j.schedule
+	}
+	if _, err := cron.ParseStandard(schedule); err != nil {
+		return fmt.Errorf("invalid cron expression: %w", err)
+	}
+
+	if err := ensureJobs(ctx, s); err != nil {
+		return err
+	}
+	if _, err := s.db.UpdateJobSchedule(ctx, database.UpdateJobScheduleParams{Name: j.name, Schedule: schedule}); err != nil {
+		return fmt.Errorf("couldn't update schedule: %w", err)
+	}
+
+	fmt.Printf("Job %s now runs on %q, restart agg to apply it\n", j.name, schedule)
+	return nil
+}
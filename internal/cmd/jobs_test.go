@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestHandlerJobs(t *testing.T) {
+	env := newTestEnv(t)
+
+	output, err := env.run(t, handlerJobs, "jobs")
+	if err != nil {
+		t.Fatalf("jobs: %v", err)
+	}
+	for _, want := range []string{"* feed-health:", "* prune-fetchlog:", "* prune-posts:", "Schedule: 0 3 * * *", "Last run: never"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("jobs output misses %q:\n%s", want, output)
+		}
+	}
+
+	if _, err := env.run(t, handlerJobs, "jobs", "nope"); err == nil {
+		t.Error("unknown jobs subcommand succeeded")
+	}
+}
+
+func TestHandlerRunJob(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+
+	now := time.Now().UTC()
+	for _, startedAt := range []time.Time{now.Add(-2 * fetchLogRetention), now} {
+		err := env.store.CreateFetchLog(context.Background(), database.CreateFetchLogParams{ID: uuid.New(), FeedID: feed.ID, StartedAt: startedAt})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := env.run(t, handlerJobs, "jobs", "run", "prune-fetchlog"); err != nil {
+		t.Fatalf("jobs run: %v", err)
+	}
+	entries, _ := env.store.GetFetchLogForFeed(context.Background(), database.GetFetchLogForFeedParams{FeedID: feed.ID, Limit: 10})
+	if len(entries) != 1 {
+		t.Errorf("%d fetch log entries left, want 1", len(entries))
+	}
+
+	job, _ := env.store.GetJob(context.Background(), "prune-fetchlog")
+	if job.LastStatus.String != jobSucceeded || job.RunningOwner.Valid || !job.LastFinishedAt.Valid {
+		t.Errorf("job outcome not saved: %+v", job)
+	}
+
+	output, err := env.run(t, handlerJobs, "jobs")
+	if err != nil {
+		t.Fatalf("jobs: %v", err)
+	}
+	if !strings.Contains(output, ", succeeded in ") {
+		t.Errorf("jobs should show the last run:\n%s", output)
+	}
+
+	for _, args := range [][]string{{"run"}, {"run", "nope"}} {
+		if _, err := env.run(t, handlerJobs, "jobs", args...); err == nil {
+			t.Errorf("jobs %v succeeded", args)
+		}
+	}
+}
+
+func TestRunJob(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	if err := ensureJobs(ctx, env.s); err != nil {
+		t.Fatal(err)
+	}
+
+	var deadline time.Time
+	failing := job{name: "feed-health", timeout: time.Minute, run: func(ctx context.Context, s *state) error {
+		deadline, _ = ctx.Deadline()
+		return errors.New("boom")
+	}}
+	if err := runJob(ctx, env.s, failing, "instance-1"); err == nil || err.Error() != "boom" {
+		t.Errorf("runJob of a failing job: err = %v, want boom", err)
+	}
+	if until := time.Until(deadline); until <= 0 || until > time.Minute {
+		t.Errorf("job ran with a deadline in %s, want its one minute timeout", until)
+	}
+
+	row, _ := env.store.GetJob(ctx, "feed-health")
+	if row.LastStatus.String != jobFailed || row.LastError.String != "boom" || row.LastRunBy.String != "instance-1" || row.RunningOwner.Valid {
+		t.Errorf("job failure not saved: %+v", row)
+	}
+
+	// The outcome is saved even when agg is stopped during the run.
+	runCtx, cancel := context.WithCancel(ctx)
+	cancelled := job{name: "feed-health", timeout: time.Minute, run: func(ctx context.Context, s *state) error {
+		cancel()
+		return ctx.Err()
+	}}
+	if err := runJob(runCtx, env.s, cancelled, "instance-1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("runJob of a cancelled job: err = %v", err)
+	}
+	row, _ = env.store.GetJob(ctx, "feed-health")
+	if row.LastStatus.String != jobFailed || row.RunningOwner.Valid {
+		t.Errorf("cancelled job outcome not saved: %+v", row)
+	}
+}
+
+func TestRunJobExpiredRun(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	if err := ensureJobs(ctx, env.s); err != nil {
+		t.Fatal(err)
+	}
+	j, _ := findJob("feed-health")
+
+	// A run that crashed is given up on once its timeout passed.
+	_, err := env.store.StartJobRun(ctx, database.StartJobRunParams{
+		Name:           j.name,
+		RunningOwner:   sql.NullString{String: "crashed-instance", Valid: true},
+		TimeoutSeconds: 60,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runJob(ctx, env.s, j, "instance-1"); !errors.Is(err, errJobRunning) {
+		t.Fatalf("runJob during another run: err = %v, want errJobRunning", err)
+	}
+
+	env.store.Now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if err := runJob(ctx, env.s, j, "instance-1"); err != nil {
+		t.Fatalf("runJob after the other run timed out: %v", err)
+	}
+	row, _ := env.store.GetJob(ctx, j.name)
+	if row.LastStatus.String != jobSucceeded || row.LastRunBy.String != "instance-1" {
+		t.Errorf("job outcome = %+v, want a success by instance-1", row)
+	}
+}
+
+func TestStartScheduler(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	elector := newLeaderElector(env.s, "instance-1")
+
+	scheduler, err := startScheduler(ctx, env.s, "instance-1", elector)
+	if err != nil {
+		t.Fatalf("startScheduler: %v", err)
+	}
+	<-scheduler.Stop().Done()
+	if len(scheduler.Entries()) != len(jobs) {
+		t.Errorf("scheduled %d jobs, want %d", len(scheduler.Entries()), len(jobs))
+	}
+
+	// Only the leader runs jobs.
+	scheduler.Entries()[0].Job.Run()
+	if strings.Contains(env.logs.String(), "Running job") {
+		t.Errorf("a follower should skip jobs:\n%s", env.logs.String())
+	}
+	elector.leader.Store(true)
+	scheduler.Entries()[0].Job.Run()
+	if !strings.Contains(env.logs.String(), "Job succeeded") {
+		t.Errorf("the leader should run jobs:\n%s", env.logs.String())
+	}
+
+	// Schedules set in the database are checked when agg starts.
+	if _, err := env.store.UpdateJobSchedule(ctx, database.UpdateJobScheduleParams{Name: "feed-health", Schedule: "every day"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := startScheduler(ctx, env.s, "instance-1", elector); err == nil || !strings.Contains(err.Error(), "invalid schedule") {
+		t.Errorf("startScheduler with an invalid schedule: err = %v", err)
+	}
+}
+
+func TestJobFeedHealth(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alan")
+	env.addFeed(t, "Healthy", "https://example.com/feed.xml")
+	failing := env.addFeed(t, "Failing", "https://example.com/failing.xml")
+	disabled := env.addFeed(t, "Disabled", "https://example.com/disabled.xml")
+
+	if err := jobFeedHealth(context.Background(), env.s); err != nil {
+		t.Fatalf("feed-health: %v", err)
+	}
+	if strings.Contains(env.logs.String(), "Feeds are failing") {
+		t.Errorf("feed-health warned about healthy feeds:\n%s", env.logs.String())
+	}
+
+	failFeedForTest(t, env, database.MarkFeedFailedParams{ID: failing.ID, BackoffSeconds: 60, LastError: "boom"})
+	failFeedForTest(t, env, database.MarkFeedFailedParams{ID: disabled.ID, LastError: "boom", Disable: true})
+	if err := jobFeedHealth(context.Background(), env.s); err != nil {
+		t.Fatalf("feed-health: %v", err)
+	}
+	if !strings.Contains(env.logs.String(), "failing=2 disabled=1") {
+		t.Errorf("feed-health should warn about the failing feeds:\n%s", env.logs.String())
+	}
+}
+
+func TestHandlerRunPrunePostsJob(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	savePostsForTest(t, env, feed,
+		testItem{title: "Old", guid: "1", pubDate: "Mon, 05 Jan 2026 10:00:00 +0000"},
+		testItem{title: "New", guid: "2", pubDate: "Mon, 05 Oct 2099 10:00:00 +0000"},
+	)
+
+	// Without a retention the job leaves posts alone.
+	if _, err := env.run(t, handlerJobs, "jobs", "run", "prune-posts"); err != nil {
+		t.Fatalf("jobs run: %v", err)
+	}
+	if left := postIDsForTest(t, env, user); len(left) != 2 {
+		t.Errorf("posts left = %v, want both", left)
+	}
+
+	env.s.cfg.PostRetention = "720h"
+	if _, err := env.run(t, handlerJobs, "jobs", "run", "prune-posts"); err != nil {
+		t.Fatalf("jobs run: %v", err)
+	}
+	if left := postIDsForTest(t, env, user); len(left) != 1 || left["New"] == "" {
+		t.Errorf("posts left = %v, want the new one", left)
+	}
+
+	for _, retention := range []string{"a while", "-720h"} {
+		env.s.cfg.PostRetention = retention
+		if _, err := env.run(t, handlerJobs, "jobs", "run", "prune-posts"); err == nil {
+			t.Errorf("prune-posts with a retention of %q succeeded", retention)
+		}
+	}
+	job, _ := env.store.GetJob(context.Background(), "prune-posts")
+	if job.LastStatus.String != jobFailed || !strings.Contains(job.LastError.String, "post_retention") {
+		t.Errorf("job failure not saved: %+v", job)
+	}
+}
+
+func TestHandlerRunJobAlreadyRunning(t *testing.T) {
+	env := newTestEnv(t)
+	if err := ensureJobs(context.Background(), env.s); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := env.store.StartJobRun(context.Background(), database.StartJobRunParams{
+		Name:           "feed-health",
+		RunningOwner:   sql.NullString{String: "other-instance", Valid: true},
+		TimeoutSeconds: 60,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = env.run(t, handlerJobs, "jobs", "run", "feed-health")
+	if !errors.Is(err, errJobRunning) {
+		t.Errorf("jobs run: err = %v, want errJobRunning", err)
+	}
+}
+
+func TestHandlerScheduleJob(t *testing.T) {
+	env := newTestEnv(t)
+
+	if _, err := env.run(t, handlerJobs, "jobs", "schedule", "feed-health", "*/5 * * * *"); err != nil {
+		t.Fatalf("jobs schedule: %v", err)
+	}
+	job, _ := env.store.GetJob(context.Background(), "feed-health")
+	if job.Schedule != "*/5 * * * *" {
+		t.Errorf("schedule = %q, want */5 * * * *", job.Schedule)
+	}
+
+	if _, err := env.run(t, handlerJobs, "jobs", "schedule", "feed-health", "default"); err != nil {
+		t.Fatalf("jobs schedule default: %v", err)
+	}
+	job, _ = env.store.GetJob(context.Background(), "feed-health")
+	if job.Schedule != "@hourly" {
+		t.Errorf("schedule = %q, want the default @hourly", job.Schedule)
+	}
+
+	for _, args := range [][]string{{"schedule", "feed-health"}, {"schedule", "feed-health", "every day"}, {"schedule", "nope", "@daily"}} {
+		if _, err := env.run(t, handlerJobs, "jobs", args...); err == nil {
+			t.Errorf("jobs %v succeeded", args)
+		}
+	}
+}
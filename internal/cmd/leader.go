@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/Pizzu/gator/internal/storage"
+)
+
+const (
+	// leaderLockKey is the Postgres advisory lock held by the leading agg
+	// process ("gatr").
+	leaderLockKey = int64(0x67617472)
+	leaderPoll    = 10 * time.Second
+)
+
+// leaderElector elects one aggregator process as the leader, which alone
+// runs the scheduled jobs. Advisory locks belong to a database session, so
+// the elector keeps a connection of its own for as long as it leads; if the
+// process dies, Postgres drops the session and the lock with it. Followers
+// retry every leaderPoll, so one of them takes over shortly after the
+// leader exits.
+type leaderElector struct {
+	s          *state
+	instanceID string
+
+	mu     sync.Mutex
+	conn   *sql.Conn
+	leader atomic.Bool
+	since  atomic.Int64
+}
+
+func newLeaderElector(s *state, instanceID string) *leaderElector {
+	return &leaderElector{s: s, instanceID: instanceID}
+}
+
+func (e *leaderElector) isLeader() bool {
+	return e.leader.Load()
+}
+
+// leaderSince returns when this process became leader.
+func (e *leaderElector) leaderSince() time.Time {
+	return time.Unix(0, e.since.Load())
+}
+
+// currentLeader returns the instance ID of the leading process, or an empty
+// string if there is none.
+func (e *leaderElector) currentLeader(ctx context.Context) (string, error) {
+	if e.isLeader() {
+		return e.instanceID, nil
+	}
+	holder, err := e.s.db.GetAdvisoryLockHolder(ctx, leaderLockKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return holder.String, err
+}
+
+// run campaigns for leadership until ctx is cancelled, then hands it over.
+func (e *leaderElector) run(ctx context.Context) {
+	if e.s.backend.Dialect == storage.DialectSQLite {
+		e.runAlone(ctx)
+		return
+	}
+	defer e.resign()
+
+	lastLeader := ""
+	for {
+		if err := e.campaign(ctx); err != nil && ctx.Err() == nil {
+			e.s.logger.Error("Leader election failed", "instance", e.instanceID, "error", err)
+			e.stepDown()
+		}
+
+		if !e.isLeader() {
+			leader, err := e.currentLeader(ctx)
+			if err == nil && leader != "" && leader != lastLeader {
+				e.s.logger.Info("Following the leader", "leader", leader, "instance", e.instanceID)
+			}
+			lastLeader = leader
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(leaderPoll):
+		}
+	}
+}
+
+// runAlone leads until ctx is cancelled. SQLite has no advisory locks and
+// its database lives on a single host, so there is no one to elect; should
+// several agg processes share the file, job runs are still claimed in the
+// jobs table and never overlap.
+func (e *leaderElector) runAlone(ctx context.Context) {
+	e.since.Store(time.Now().UnixNano())
+	e.leader.Store(true)
+	e.s.logger.Info("Became the leader", "instance", e.instanceID)
+
+	<-ctx.Done()
+	e.leader.Store(false)
+}
+
+// campaign tries to take the lock, or checks that the session holding it
+// is still alive when this process already leads.
+func (e *leaderElector) campaign(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		conn, err := e.s.conn.Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("couldn't get a connection: %w", err)
+		}
+		e.conn = conn
+		// Other instances find the leader's ID through its session.
+		if err := database.New(conn).SetApplicationName(ctx, e.instanceID); err != nil {
+			return fmt.Errorf("couldn't name session: %w", err)
+		}
+	}
+
+	if e.isLeader() {
+		return e.conn.PingContext(ctx)
+	}
+
+	acquired, err := database.New(e.conn).TryAdvisoryLock(ctx, leaderLockKey)
+	if err != nil {
+		return fmt.Errorf("couldn't try leader lock: %w", err)
+	}
+	if acquired {
+		e.since.Store(time.Now().UnixNano())
+		e.leader.Store(true)
+		e.s.logger.Info("Became the leader", "instance", e.instanceID)
+	}
+	return nil
+}
+
+// stepDown gives up leadership after the session was lost, dropping the
+// connection so that the lock is released if the session is still there.
+func (e *leaderElector) stepDown() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.leader.Swap(false) {
+		e.s.logger.Warn("Lost leadership", "instance", e.instanceID)
+	}
+	if e.conn != nil {
+		discardConn(e.conn)
+		e.conn = nil
+	}
+}
+
+// resign releases the lock on shutdown so that another instance can take
+// over right away instead of waiting for the session to time out.
+func (e *leaderElector) resign() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		return
+	}
+	if e.leader.Swap(false) {
+		ctx, cancel := context.WithTimeout(context.Background(), leaseReleaseTimeout)
+		defer cancel()
+
+		if _, err := database.New(e.conn).AdvisoryUnlock(ctx, leaderLockKey); err != nil {
+			e.s.logger.Error("Couldn't release leadership", "instance", e.instanceID, "error", err)
+		} else {
+			e.s.logger.Info("Handed over leadership", "instance", e.instanceID)
+		}
+	}
+	discardConn(e.conn)
+	e.conn = nil
+}
+
+// discardConn closes conn without returning it to the pool, so a session
+// that may still hold the lock is never reused by other queries.
+func discardConn(conn *sql.Conn) {
+	conn.Raw(func(any) error { return driver.ErrBadConn })
+	conn.Close()
+}
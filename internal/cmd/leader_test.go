@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Pizzu/gator/internal/config"
+	"github.com/Pizzu/gator/internal/storage"
+	"github.com/charmbracelet/log"
+)
+
+func TestLeaderElectorSQLite(t *testing.T) {
+	// SQLite has no advisory locks, so its only agg process leads alone.
+	backend, err := storage.Open("sqlite:" + filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer backend.DB.Close()
+	s := NewState(&config.Config{}, backend, log.New(io.Discard))
+	elector := newLeaderElector(s, "instance-1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		elector.run(ctx)
+	}()
+
+	for deadline := time.Now().Add(5 * time.Second); !elector.isLeader(); {
+		if time.Now().After(deadline) {
+			t.Fatal("the elector never became leader")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if since := time.Since(elector.leaderSince()); since < 0 || since > time.Minute {
+		t.Errorf("leader since %s ago, want now", since)
+	}
+	if leader, err := elector.currentLeader(ctx); err != nil || leader != "instance-1" {
+		t.Errorf("currentLeader = %q, %v, want instance-1", leader, err)
+	}
+
+	h := newHealthMonitor(s, time.Minute, 0)
+	h.leader = elector
+	code, report := getHealthForTest(t, h, "/healthz")
+	if code != http.StatusOK || !report.IsLeader || report.Leader != "instance-1" || report.Instance != "instance-1" || report.LeaderSince == nil {
+		t.Errorf("/healthz of the leader = %d %+v", code, report)
+	}
+
+	cancel()
+	<-done
+	if elector.isLeader() {
+		t.Error("the elector still leads after it was stopped")
+	}
+
+	// Not knowing the leader leaves the instance healthy.
+	code, report = getHealthForTest(t, h, "/healthz")
+	if code != http.StatusOK || report.IsLeader || report.Leader != "" || report.LeaderSince != nil {
+		t.Errorf("/healthz after stepping down = %d %+v", code, report)
+	}
+}
@@ -7,19 +7,23 @@ import (
 	"github.com/Pizzu/gator/internal/database"
 )
 
-func middlewareLoggedIn(handler func(s *state, cmd command, user database.User) error) func(s *state, cmd command) error {
-	return func(s *state, cmd command) error {
-		currentUsername := s.cfg.CurrentUserName
-		if currentUsername == "" {
-			return errors.New("not logged in, sign in first")
-		}
-		ctx := context.Background()
-		user, err := s.db.GetUserByName(ctx, currentUsername)
+func middlewareLoggedIn(handler func(ctx context.Context, s *state, cmd command, user database.User) error) func(ctx context.Context, s *state, cmd command) error {
+	return func(ctx context.Context, s *state, cmd command) error {
+		user, err := currentUser(ctx, s)
 
 		if err != nil {
 			return err
 		}
 
-		return handler(s, cmd, user)
+		return handler(ctx, s, cmd, user)
 	}
 }
+
+func currentUser(ctx context.Context, s *state) (database.User, error) {
+	currentUsername := s.cfg.CurrentUserName
+	if currentUsername == "" {
+		return database.User{}, errors.New("not logged in, sign in first")
+	}
+
+	return s.db.GetUserByName(ctx, currentUsername)
+}
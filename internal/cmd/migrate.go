@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pressly/goose/v3"
+)
+
+// newMigrator returns a goose provider for the migrations embedded in the
+// binary for the database in use. It shares the goose_db_version table with
+// the goose CLI, so databases set up by hand keep working.
+func newMigrator(s *state) (*goose.Provider, error) {
+	provider, err := goose.NewProvider(s.backend.Dialect, s.conn, s.backend.Migrations)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load migrations: %w", err)
+	}
+	return provider, nil
+}
+
+// checkSchema refuses to run against a database whose schema is older than
+// the migrations embedded in the binary.
+func checkSchema(ctx context.Context, s *state) error {
+	migrator, err := newMigrator(s)
+	if err != nil {
+		return err
+	}
+
+	current, target, err := migrator.GetVersions(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't check database schema: %w", err)
+	}
+
+	if current < target {
+		return fmt.Errorf("database schema is at version %d but this build needs version %d, run: gator migrate up", current, target)
+	}
+	if current > target {
+		s.logger.Warn("Database schema is newer than this build, consider upgrading", "version", current, "expected", target)
+	}
+	return nil
+}
+
+func handlerMigrate(ctx context.Context, s *state, cmd command) error {
+	usage := fmt.Errorf("usage: %s up | down | status | to <version>", cmd.Name)
+	if len(cmd.Args) == 0 {
+		return usage
+	}
+
+	migrator, err := newMigrator(s)
+	if err != nil {
+		return err
+	}
+
+	var results []*goose.MigrationResult
+	switch {
+	case cmd.Args[0] == "status" && len(cmd.Args) == 1:
+		return printMigrationStatus(ctx, migrator)
+	case cmd.Args[0] == "up" && len(cmd.Args) == 1:
+		results, err = migrator.Up(ctx)
+	case cmd.Args[0] == "down" && len(cmd.Args) == 1:
+		var result *goose.MigrationResult
+		result, err = migrator.Down(ctx)
+		if result != nil {
+			results = append(results, result)
+		}
+	case cmd.Args[0] == "to" && len(cmd.Args) == 2:
+		results, err = migrateTo(ctx, migrator, cmd.Args[1])
+	default:
+		return usage
+	}
+
+	for _, result := range results {
+		fmt.Println(result)
+	}
+
+	var partialErr *goose.PartialError
+	switch {
+	case errors.Is(err, goose.ErrNoNextVersion):
+		fmt.Println("Nothing to roll back")
+	case errors.As(err, &partialErr):
+		for _, result := range partialErr.Applied {
+			fmt.Println(result)
+		}
+		return fmt.Errorf("migration %s failed: %w", filepath.Base(partialErr.Failed.Source.Path), partialErr.Err)
+	case err != nil:
+		return fmt.Errorf("couldn't migrate: %w", err)
+	}
+
+	version, err := migrator.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't get schema version: %w", err)
+	}
+	if len(results) == 0 && cmd.Args[0] != "down" {
+		fmt.Println("Nothing to migrate")
+	}
+	fmt.Printf("Database schema is at version %d\n", version)
+	return nil
+}
+
+// migrateTo applies or rolls back migrations until the schema is at version.
+func migrateTo(ctx context.Context, migrator *goose.Provider, arg string) ([]*goose.MigrationResult, error) {
+	version, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || version < 0 {
+		return nil, fmt.Errorf("invalid version: %s", arg)
+	}
+
+	current, err := migrator.GetDBVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if version < current {
+		return migrator.DownTo(ctx, version)
+	}
+	return migrator.UpTo(ctx, version)
+}
+
+func printMigrationStatus(ctx context.Context, migrator *goose.Provider) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't get migration status: %w", err)
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		applied := "pending"
+		if status.State == goose.StateApplied {
+			applied = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		} else {
+			pending++
+		}
+		fmt.Printf("%-20s %s\n", applied, filepath.Base(status.Source.Path))
+	}
+
+	fmt.Printf("%d migrations, %d pending\n", len(statuses), pending)
+	return nil
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Pizzu/gator/internal/config"
+	"github.com/Pizzu/gator/internal/storage"
+	"github.com/Pizzu/gator/sql/schema"
+	sqliteschema "github.com/Pizzu/gator/sql/sqlite/schema"
+	"github.com/charmbracelet/log"
+)
+
+// newSQLiteEnv returns a state backed by a new SQLite database, as migrations
+// need a real one.
+func newSQLiteEnv(t *testing.T) *testEnv {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	backend, err := storage.Open("sqlite:" + filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { backend.DB.Close() })
+
+	logs := &syncBuffer{}
+	return &testEnv{
+		s:    NewState(&config.Config{DbURL: "sqlite:gator.db"}, backend, log.New(logs)),
+		logs: logs,
+	}
+}
+
+func TestHandlerMigrate(t *testing.T) {
+	env := newSQLiteEnv(t)
+	ctx := context.Background()
+
+	if err := checkSchema(ctx, env.s); err == nil || !strings.Contains(err.Error(), "migrate up") {
+		t.Errorf("checkSchema on an empty database: err = %v, want a hint to migrate", err)
+	}
+
+	migrations, err := fs.Glob(sqliteschema.FS, "*.sql")
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("list migrations: %v", err)
+	}
+
+	output, err := env.run(t, handlerMigrate, "migrate", "status")
+	if err != nil {
+		t.Fatalf("migrate status: %v", err)
+	}
+	if !strings.Contains(output, fmt.Sprintf("%d pending", len(migrations))) {
+		t.Errorf("migrate status should list pending migrations:\n%s", output)
+	}
+
+	output, err = env.run(t, handlerMigrate, "migrate", "up")
+	if err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	if !strings.Contains(output, fmt.Sprintf("Database schema is at version %d", len(migrations))) {
+		t.Errorf("unexpected migrate up output:\n%s", output)
+	}
+	if err := checkSchema(ctx, env.s); err != nil {
+		t.Errorf("checkSchema after migrating: %v", err)
+	}
+
+	output, err = env.run(t, handlerMigrate, "migrate", "up")
+	if err != nil {
+		t.Fatalf("second migrate up: %v", err)
+	}
+	if !strings.Contains(output, "Nothing to migrate") {
+		t.Errorf("second migrate up should have nothing to do:\n%s", output)
+	}
+
+	// Commands work on the migrated database.
+	if _, err := env.run(t, handlerRegister, "register", "alan"); err != nil {
+		t.Errorf("register on SQLite: %v", err)
+	}
+
+	if _, err := env.run(t, handlerMigrate, "migrate", "to", "0"); err != nil {
+		t.Fatalf("migrate to 0: %v", err)
+	}
+	output, err = env.run(t, handlerMigrate, "migrate", "down")
+	if err != nil {
+		t.Fatalf("migrate down: %v", err)
+	}
+	if !strings.Contains(output, "Nothing to roll back") {
+		t.Errorf("migrate down on an empty schema should have nothing to do:\n%s", output)
+	}
+
+	for _, args := range [][]string{{}, {"sideways"}, {"to"}, {"to", "-1"}, {"up", "now"}} {
+		if _, err := env.run(t, handlerMigrate, "migrate", args...); err == nil {
+			t.Errorf("migrate %v succeeded", args)
+		}
+	}
+}
+
+func TestHandlerMigrateSteps(t *testing.T) {
+	env := newSQLiteEnv(t)
+	ctx := context.Background()
+	migrations, _ := fs.Glob(sqliteschema.FS, "*.sql")
+
+	if _, err := env.run(t, handlerMigrate, "migrate", "up"); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	output, err := env.run(t, handlerMigrate, "migrate", "down")
+	if err != nil {
+		t.Fatalf("migrate down: %v", err)
+	}
+	if !strings.Contains(output, fmt.Sprintf("Database schema is at version %d", len(migrations)-1)) {
+		t.Errorf("migrate down should roll back one migration:\n%s", output)
+	}
+	if err := checkSchema(ctx, env.s); err == nil || !strings.Contains(err.Error(), "migrate up") {
+		t.Errorf("checkSchema on a schema behind the binary: err = %v, want a hint to migrate", err)
+	}
+	output, _ = env.run(t, handlerMigrate, "migrate", "status")
+	if !strings.Contains(output, "1 pending") {
+		t.Errorf("migrate status after rolling back:\n%s", output)
+	}
+
+	output, err = env.run(t, handlerMigrate, "migrate", "to", strconv.Itoa(len(migrations)))
+	if err != nil {
+		t.Fatalf("migrate to %d: %v", len(migrations), err)
+	}
+	if !strings.Contains(output, fmt.Sprintf("Database schema is at version %d", len(migrations))) {
+		t.Errorf("unexpected migrate to output:\n%s", output)
+	}
+	if err := checkSchema(ctx, env.s); err != nil {
+		t.Errorf("checkSchema after migrating back up: %v", err)
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	// goose orders migrations by their number, so a gap or a duplicate
+	// means two branches added a migration each.
+	for name, migrations := range map[string]fs.FS{"postgres": schema.FS, "sqlite": sqliteschema.FS} {
+		files, err := fs.Glob(migrations, "*.sql")
+		if err != nil || len(files) == 0 {
+			t.Fatalf("%s: list migrations: %v", name, err)
+		}
+		for i, file := range files {
+			if prefix := fmt.Sprintf("%03d_", i+1); !strings.HasPrefix(file, prefix) {
+				t.Errorf("%s: migration %s should be numbered %s", name, file, prefix)
+			}
+			data, err := fs.ReadFile(migrations, file)
+			if err != nil {
+				t.Fatalf("%s: read %s: %v", name, file, err)
+			}
+			for _, annotation := range []string{"-- +goose Up", "-- +goose Down"} {
+				if !strings.Contains(string(data), annotation) {
+					t.Errorf("%s: migration %s misses %q", name, file, annotation)
+				}
+			}
+		}
+	}
+}
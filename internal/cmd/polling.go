@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+)
+
+const (
+	minFetchInterval = time.Minute
+	maxFetchInterval = 7 * 24 * time.Hour
+
+	// Adaptive feeds halve their interval after a fetch that found new posts
+	// and stretch it by half after one that didn't.
+	adaptiveTighten = 0.5
+	adaptiveBackoff = 1.5
+
+	// Failing feeds wait twice as long after every consecutive failure, up
+	// to maxFailureBackoff.
+	maxFailureBackoff = 24 * time.Hour
+)
+
+// baseInterval is the interval configured for a feed, or the aggregator
+// default when the feed has none. A zero default, from a one-shot run
+// without an interval, keeps the interval the feed was last polled at.
+func baseInterval(feed database.Feed, defaultInterval time.Duration) time.Duration {
+	if feed.FetchIntervalSeconds.Valid {
+		return time.Duration(feed.FetchIntervalSeconds.Int32) * time.Second
+	}
+	if defaultInterval > 0 {
+		return defaultInterval
+	}
+	if feed.PollIntervalSeconds.Valid {
+		return time.Duration(feed.PollIntervalSeconds.Int32) * time.Second
+	}
+	return defaultOneShotInterval
+}
+
+// nextPollInterval decides how long to wait before fetching a feed again.
+func nextPollInterval(feed database.Feed, defaultInterval time.Duration, newPosts int) time.Duration {
+	base := baseInterval(feed, defaultInterval)
+	if !feed.AdaptivePolling {
+		return base
+	}
+
+	current := base
+	if feed.PollIntervalSeconds.Valid {
+		current = time.Duration(feed.PollIntervalSeconds.Int32) * time.Second
+	}
+
+	factor := adaptiveBackoff
+	if newPosts > 0 {
+		factor = adaptiveTighten
+	}
+
+	next := time.Duration(float64(current) * factor)
+	return min(max(next, minFetchInterval), maxFetchInterval)
+}
+
+// failureBackoff decides how long to wait before retrying a feed that has
+// failed the given number of times in a row.
+func failureBackoff(feed database.Feed, defaultInterval time.Duration, failures int) time.Duration {
+	base := baseInterval(feed, defaultInterval)
+	limit := max(base, maxFailureBackoff)
+
+	backoff := base
+	for i := 0; i < failures && backoff < limit; i++ {
+		backoff *= 2
+	}
+	return min(backoff, limit)
+}
+
+func durationSeconds(d time.Duration) int32 {
+	return int32(d / time.Second)
+}
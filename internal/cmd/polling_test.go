@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+)
+
+// seconds returns d as the NullInt32 the feeds table stores intervals in.
+func seconds(d time.Duration) sql.NullInt32 {
+	return sql.NullInt32{Int32: durationSeconds(d), Valid: true}
+}
+
+func TestBaseInterval(t *testing.T) {
+	for _, tt := range []struct {
+		name            string
+		feed            database.Feed
+		defaultInterval time.Duration
+		want            time.Duration
+	}{
+		{"agg interval", database.Feed{}, time.Hour, time.Hour},
+		{"own interval", database.Feed{FetchIntervalSeconds: seconds(2 * time.Hour)}, time.Hour, 2 * time.Hour},
+		{"own interval without agg interval", database.Feed{FetchIntervalSeconds: seconds(2 * time.Hour)}, 0, 2 * time.Hour},
+		{"last interval without agg interval", database.Feed{PollIntervalSeconds: seconds(3 * time.Hour)}, 0, 3 * time.Hour},
+		{"never fetched without agg interval", database.Feed{}, 0, defaultOneShotInterval},
+	} {
+		if got := baseInterval(tt.feed, tt.defaultInterval); got != tt.want {
+			t.Errorf("%s: baseInterval = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNextPollInterval(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		feed     database.Feed
+		newPosts int
+		want     time.Duration
+	}{
+		{"fixed", database.Feed{PollIntervalSeconds: seconds(10 * time.Minute)}, 3, time.Hour},
+		{"fixed own interval", database.Feed{FetchIntervalSeconds: seconds(2 * time.Hour)}, 0, 2 * time.Hour},
+		{"adaptive first fetch with posts", database.Feed{AdaptivePolling: true}, 1, 30 * time.Minute},
+		{"adaptive first fetch without posts", database.Feed{AdaptivePolling: true}, 0, 90 * time.Minute},
+		{"adaptive with posts", database.Feed{AdaptivePolling: true, PollIntervalSeconds: seconds(20 * time.Minute)}, 2, 10 * time.Minute},
+		{"adaptive without posts", database.Feed{AdaptivePolling: true, PollIntervalSeconds: seconds(20 * time.Minute)}, 0, 30 * time.Minute},
+		{"adaptive at the minimum", database.Feed{AdaptivePolling: true, PollIntervalSeconds: seconds(90 * time.Second)}, 1, minFetchInterval},
+		{"adaptive at the maximum", database.Feed{AdaptivePolling: true, PollIntervalSeconds: seconds(6 * 24 * time.Hour)}, 0, maxFetchInterval},
+	} {
+		if got := nextPollInterval(tt.feed, time.Hour, tt.newPosts); got != tt.want {
+			t.Errorf("%s: nextPollInterval = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFailureBackoff(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		feed     database.Feed
+		failures int
+		want     time.Duration
+	}{
+		{"first failure", database.Feed{}, 1, 2 * time.Hour},
+		{"third failure", database.Feed{}, 3, 8 * time.Hour},
+		{"capped", database.Feed{}, 10, maxFailureBackoff},
+		{"own interval", database.Feed{FetchIntervalSeconds: seconds(10 * time.Minute)}, 2, 40 * time.Minute},
+		{"own interval over the cap", database.Feed{FetchIntervalSeconds: seconds(48 * time.Hour)}, 2, 48 * time.Hour},
+	} {
+		if got := failureBackoff(tt.feed, time.Hour, tt.failures); got != tt.want {
+			t.Errorf("%s: failureBackoff = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func handlerRead(ctx context.Context, s *state, cmd command, user database.User) error {
+	return markPosts(ctx, s, cmd, user, true)
+}
+
+func handlerUnread(ctx context.Context, s *state, cmd command, user database.User) error {
+	return markPosts(ctx, s, cmd, user, false)
+}
+
+// markPosts marks posts of the feeds the user follows as read or unread,
+// either the posts given by ID or every post matching the flags.
+func markPosts(ctx context.Context, s *state, cmd command, user database.User, read bool) error {
+	usage := fmt.Errorf("usage: %s <post-id> ... | %s [--feed url] [--folder name] [--before date] [--all]", cmd.Name, cmd.Name)
+
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	feedURL := flags.String("feed", "", "only mark posts of this feed")
+	folder := flags.String("folder", "", "only mark posts of the feeds in this folder")
+	before := flags.String("before", "", "only mark posts published before this date")
+	all := flags.Bool("all", false, "mark every post of the followed feeds")
+
+	if err := flags.Parse(cmd.Args); err != nil {
+		return usage
+	}
+	filtered := *feedURL != "" || *folder != "" || *before != "" || *all
+	if filtered == (flags.NArg() > 0) {
+		return usage
+	}
+
+	var marked int64
+	if flags.NArg() > 0 {
+		ids := make([]uuid.UUID, 0, flags.NArg())
+		for _, arg := range flags.Args() {
+			id, err := uuid.Parse(arg)
+			if err != nil {
+				return fmt.Errorf("invalid post id: %s", arg)
+			}
+			ids = append(ids, id)
+		}
+
+		err := s.db.InTx(ctx, func(q database.Querier) error {
+			for _, id := range ids {
+				n, err := q.SetPostsRead(ctx, database.SetPostsReadParams{
+					Read:   read,
+					UserID: user.ID,
+					PostID: uuid.NullUUID{UUID: id, Valid: true},
+				})
+				if err != nil {
+					return err
+				}
+				marked += n
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't mark posts: %w", err)
+		}
+	} else {
+		params := database.SetPostsReadParams{Read: read, UserID: user.ID}
+
+		if *feedURL != "" {
+			feed, err := s.db.GetFeedByUrl(ctx, *feedURL)
+			if err != nil {
+				return fmt.Errorf("couldn't find feed: %w", err)
+			}
+			params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+		}
+		folderID, err := lookupFolder(ctx, s, user, *folder)
+		if err != nil {
+			return err
+		}
+		params.FolderID = folderID
+		if *before != "" {
+			date, err := parseDate(*before)
+			if err != nil {
+				return err
+			}
+			params.Before = sql.NullTime{Time: date, Valid: true}
+		}
+
+		n, err := s.db.SetPostsRead(ctx, params)
+		if err != nil {
+			return fmt.Errorf("couldn't mark posts: %w", err)
+		}
+		marked = n
+	}
+
+	state := "unread"
+	if read {
+		state = "read"
+	}
+	s.logger.Info("Marked posts as "+state, "user", user.Name, "count", marked)
+
+	return nil
+}
+
+// parseDate parses a date such as 2024-01-31, taken as midnight local time,
+// or an RFC 3339 timestamp.
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return date.UTC(), nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD or RFC 3339", value)
+	}
+	return date.UTC(), nil
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+)
+
+func readPostsForTest(t *testing.T, env *testEnv, user database.User) map[string]bool {
+	t.Helper()
+	posts, err := env.store.GetPostsForUser(context.Background(), database.GetPostsForUserParams{UserID: user.ID, PageSize: 100})
+	if err != nil {
+		t.Fatalf("get posts: %v", err)
+	}
+	read := make(map[string]bool, len(posts))
+	for _, post := range posts {
+		read[post.Title] = post.Read
+	}
+	return read
+}
+
+func TestHandlerReadByID(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	savePostsForTest(t, env, feed,
+		testItem{title: "First", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Second", guid: "2", pubDate: "Tue, 06 Oct 2026 10:00:00 +0000"},
+	)
+	posts, _ := env.store.GetPostsForUser(context.Background(), database.GetPostsForUserParams{UserID: user.ID, PageSize: 10})
+
+	if _, err := env.run(t, middlewareLoggedIn(handlerRead), "read", posts[0].ID.String()); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if read := readPostsForTest(t, env, user); !read[posts[0].Title] || read[posts[1].Title] {
+		t.Errorf("read = %v, want only %s read", read, posts[0].Title)
+	}
+	if !strings.Contains(env.logs.String(), "count=1") {
+		t.Errorf("read logs miss the count:\n%s", env.logs.String())
+	}
+
+	if _, err := env.run(t, middlewareLoggedIn(handlerUnread), "unread", posts[0].ID.String()); err != nil {
+		t.Fatalf("unread: %v", err)
+	}
+	if read := readPostsForTest(t, env, user); read[posts[0].Title] {
+		t.Errorf("read = %v, want none read", read)
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"not-a-uuid"},
+		{"--all", posts[0].ID.String()},
+		{"--before", "yesterday"},
+	} {
+		if _, err := env.run(t, middlewareLoggedIn(handlerRead), "read", args...); err == nil {
+			t.Errorf("read %v succeeded", args)
+		}
+	}
+}
+
+func TestHandlerReadFilters(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	blog := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	news := env.addFeed(t, "News", "https://example.com/news.xml")
+	savePostsForTest(t, env, blog,
+		testItem{title: "Blog old", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Blog new", guid: "2", pubDate: "Sat, 10 Oct 2026 10:00:00 +0000"},
+	)
+	savePostsForTest(t, env, news,
+		testItem{title: "News old", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"},
+		testItem{title: "News new", guid: "2", pubDate: "Sat, 10 Oct 2026 10:00:00 +0000"},
+	)
+	read := middlewareLoggedIn(handlerRead)
+
+	if _, err := env.run(t, read, "read", "--before", "2026-10-08"); err != nil {
+		t.Fatalf("read --before: %v", err)
+	}
+	want := map[string]bool{"Blog old": true, "Blog new": false, "News old": true, "News new": false}
+	if got := readPostsForTest(t, env, user); !maps.Equal(got, want) {
+		t.Errorf("after --before: read = %v, want %v", got, want)
+	}
+
+	if _, err := env.run(t, read, "read", "--feed", blog.Url); err != nil {
+		t.Fatalf("read --feed: %v", err)
+	}
+	want["Blog new"] = true
+	if got := readPostsForTest(t, env, user); !maps.Equal(got, want) {
+		t.Errorf("after --feed: read = %v, want %v", got, want)
+	}
+
+	follows, _ := env.store.GetFeedFollowsForUser(context.Background(), user.ID)
+	for _, follow := range follows {
+		if wantUnread := map[string]int64{"Blog": 0, "News": 1}[follow.FeedName]; follow.UnreadCount != wantUnread {
+			t.Errorf("%s unread = %d, want %d", follow.FeedName, follow.UnreadCount, wantUnread)
+		}
+	}
+
+	output, err := env.run(t, middlewareLoggedIn(handlerBrowse), "browse", "10")
+	if err != nil {
+		t.Fatalf("browse: %v", err)
+	}
+	if !strings.Contains(output, "--- News new ---") || strings.Contains(output, "old") || strings.Contains(output, "Blog") {
+		t.Errorf("browse should only show unread posts:\n%s", output)
+	}
+
+	output, err = env.run(t, middlewareLoggedIn(handlerBrowse), "browse", "--all", "10")
+	if err != nil {
+		t.Fatalf("browse --all: %v", err)
+	}
+	if !strings.Contains(output, "--- Blog old (read) ---") || !strings.Contains(output, "--- News new ---") {
+		t.Errorf("browse --all should show every post:\n%s", output)
+	}
+
+	if _, err := env.run(t, read, "read", "--all"); err != nil {
+		t.Fatalf("read --all: %v", err)
+	}
+	output, _ = env.run(t, middlewareLoggedIn(handlerBrowse), "browse")
+	if !strings.Contains(output, "No unread posts") {
+		t.Errorf("browse should report no unread posts:\n%s", output)
+	}
+
+	if _, err := env.run(t, middlewareLoggedIn(handlerUnread), "unread", "--all"); err != nil {
+		t.Fatalf("unread --all: %v", err)
+	}
+	for title, isRead := range readPostsForTest(t, env, user) {
+		if isRead {
+			t.Errorf("%s still read after unread --all", title)
+		}
+	}
+}
+
+func TestSetPostsUnreadCount(t *testing.T) {
+	// Posts without a state are unread already, so marking them unread
+	// doesn't count them.
+	env := newTestEnv(t)
+	ctx := context.Background()
+	user := env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	savePostsForTest(t, env, feed,
+		testItem{title: "First", guid: "1"},
+		testItem{title: "Second", guid: "2"},
+	)
+	if _, err := env.run(t, middlewareLoggedIn(handlerRead), "read", "--all"); err != nil {
+		t.Fatalf("read --all: %v", err)
+	}
+	savePostsForTest(t, env, feed, testItem{title: "Third", guid: "3"})
+
+	for _, want := range []int64{2, 0} {
+		n, err := env.store.SetPostsRead(ctx, database.SetPostsReadParams{Read: false, UserID: user.ID})
+		if err != nil {
+			t.Fatalf("mark posts unread: %v", err)
+		}
+		if n != want {
+			t.Errorf("marked %d posts unread, want %d", n, want)
+		}
+	}
+}
+
+func TestSetPostsUnreadCountSQLite(t *testing.T) {
+	// Marking posts unread must not add a state for the posts without one.
+	env := newSQLiteEnv(t)
+	ctx := context.Background()
+	if _, err := env.run(t, handlerMigrate, "migrate", "up"); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	if _, err := env.run(t, handlerRegister, "register", "alan"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	server := newFeedServer(t, testItem{title: "First", guid: "1"}, testItem{title: "Second", guid: "2"})
+	if _, err := env.run(t, middlewareLoggedIn(handlerAddFeed), "addfeed", "Blog", server.URL); err != nil {
+		t.Fatalf("addfeed: %v", err)
+	}
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", server.URL); err != nil {
+		t.Fatalf("agg --feed: %v", err)
+	}
+	user, err := env.s.db.GetUserByName(ctx, "alan")
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	posts, err := env.s.db.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: user.ID, PageSize: 10})
+	if err != nil || len(posts) != 2 {
+		t.Fatalf("get posts = %d, %v, want 2", len(posts), err)
+	}
+	if _, err := env.run(t, middlewareLoggedIn(handlerRead), "read", posts[0].ID.String()); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	for _, want := range []int64{1, 0} {
+		n, err := env.s.db.SetPostsRead(ctx, database.SetPostsReadParams{Read: false, UserID: user.ID})
+		if err != nil {
+			t.Fatalf("mark posts unread: %v", err)
+		}
+		if n != want {
+			t.Errorf("marked %d posts unread, want %d", n, want)
+		}
+	}
+	var states int
+	if err := env.s.conn.QueryRowContext(ctx, "SELECT count(*) FROM post_states").Scan(&states); err != nil || states != 1 {
+		t.Errorf("post states = %d, %v, want only the one of the post read before", states, err)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	date, err := parseDate("2026-10-08")
+	if err != nil {
+		t.Fatalf("parse date: %v", err)
+	}
+	if want := time.Date(2026, 10, 8, 0, 0, 0, 0, time.Local).UTC(); !date.Equal(want) || date.Location() != time.UTC {
+		t.Errorf("date = %v, want %v", date, want)
+	}
+
+	date, err = parseDate("2026-10-08T12:00:00+02:00")
+	if err != nil {
+		t.Fatalf("parse timestamp: %v", err)
+	}
+	if want := time.Date(2026, 10, 8, 10, 0, 0, 0, time.UTC); !date.Equal(want) {
+		t.Errorf("date = %v, want %v", date, want)
+	}
+
+	if _, err := parseDate("08/10/2026"); err == nil {
+		t.Error("parsing an invalid date succeeded")
+	}
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"os"
@@ -9,22 +11,27 @@ import (
 	"github.com/Pizzu/gator/internal/api"
 	"github.com/Pizzu/gator/internal/config"
 	"github.com/Pizzu/gator/internal/database"
+	"github.com/Pizzu/gator/internal/storage"
 	"github.com/charmbracelet/log"
 )
 
 type state struct {
-	cfg    *config.Config
-	db     *database.Queries
-	client api.Client
-	logger *log.Logger
+	cfg     *config.Config
+	backend *storage.Backend
+	conn    *sql.DB
+	db      database.Store
+	client  api.Client
+	logger  *log.Logger
 }
 
-func NewState(cfg *config.Config, db *database.Queries, logger *log.Logger) *state {
+func NewState(cfg *config.Config, backend *storage.Backend, logger *log.Logger) *state {
 	return &state{
-		cfg:    cfg,
-		db:     db,
-		client: api.NewClient(5 * time.Second),
-		logger: logger,
+		cfg:     cfg,
+		backend: backend,
+		conn:    backend.DB,
+		db:      backend.Store,
+		client:  api.NewClient(5 * time.Second),
+		logger:  logger,
 	}
 }
 
@@ -34,12 +41,12 @@ type command struct {
 }
 
 type commands struct {
-	registeredCommands map[string]func(*state, command) error
+	registeredCommands map[string]func(context.Context, *state, command) error
 }
 
-func Execute(s *state) error {
+func Execute(ctx context.Context, s *state) error {
 	cmds := commands{
-		registeredCommands: make(map[string]func(*state, command) error),
+		registeredCommands: make(map[string]func(context.Context, *state, command) error),
 	}
 
 	// Register commands
@@ -49,11 +56,27 @@ func Execute(s *state) error {
 	cmds.register("users", handlerGetAllUsers)
 	cmds.register("agg", handlerAggregator)
 	cmds.register("addfeed", middlewareLoggedIn(handlerAddFeed))
-	cmds.register("feeds", handlerGetAllFeeds)
+	cmds.register("feeds", handlerFeeds)
+	cmds.register("setinterval", middlewareLoggedIn(handlerSetInterval))
 	cmds.register("follow", middlewareLoggedIn(handlerFeedFollow))
 	cmds.register("following", middlewareLoggedIn(handlerFollowing))
 	cmds.register("unfollow", middlewareLoggedIn(handlerFeedUnfollow))
+	cmds.register("folders", middlewareLoggedIn(handlerFolders))
 	cmds.register("browse", middlewareLoggedIn(handlerBrowse))
+	cmds.register("search", middlewareLoggedIn(handlerSearch))
+	cmds.register("read", middlewareLoggedIn(handlerRead))
+	cmds.register("unread", middlewareLoggedIn(handlerUnread))
+	cmds.register("star", middlewareLoggedIn(handlerStar))
+	cmds.register("unstar", middlewareLoggedIn(handlerUnstar))
+	cmds.register("starred", middlewareLoggedIn(handlerStarred))
+	cmds.register("later", middlewareLoggedIn(handlerLater))
+	cmds.register("tags", middlewareLoggedIn(handlerTags))
+	cmds.register("prune", handlerPrunePosts)
+	cmds.register("validate", handlerValidate)
+	cmds.register("fetchlog", handlerFetchLog)
+	cmds.register("hooks", middlewareLoggedIn(handlerHooks))
+	cmds.register("jobs", handlerJobs)
+	cmds.register("migrate", handlerMigrate)
 
 	if len(os.Args) < 2 {
 		return fmt.Errorf("usage: cli <command> [args...]")
@@ -62,17 +85,23 @@ func Execute(s *state) error {
 	cmdName := os.Args[1]
 	cmdArgs := os.Args[2:]
 
-	return cmds.run(s, command{Name: cmdName, Args: cmdArgs})
+	if cmdName != "migrate" {
+		if err := checkSchema(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	return cmds.run(ctx, s, command{Name: cmdName, Args: cmdArgs})
 }
 
-func (c *commands) register(name string, f func(*state, command) error) {
+func (c *commands) register(name string, f func(context.Context, *state, command) error) {
 	c.registeredCommands[name] = f
 }
 
-func (c *commands) run(s *state, cmd command) error {
+func (c *commands) run(ctx context.Context, s *state, cmd command) error {
 	f, ok := c.registeredCommands[cmd.Name]
 	if !ok {
 		return errors.New("command not found")
 	}
-	return f(s, cmd)
+	return f(ctx, s, cmd)
 }
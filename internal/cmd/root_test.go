@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Pizzu/gator/internal/api"
+	"github.com/Pizzu/gator/internal/config"
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/Pizzu/gator/internal/database/memory"
+	"github.com/charmbracelet/log"
+)
+
+// testEnv is a state backed by an in-memory store, with the logs kept for
+// inspection. The config file is written to a temporary home directory.
+type testEnv struct {
+	s     *state
+	store *memory.Store
+	logs  *syncBuffer
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	store := memory.NewStore()
+	logs := &syncBuffer{}
+	return &testEnv{
+		s: &state{
+			cfg:    &config.Config{DbURL: "postgres://localhost/gator_test"},
+			db:     store,
+			client: api.NewClient(5 * time.Second),
+			logger: log.New(logs),
+		},
+		store: store,
+		logs:  logs,
+	}
+}
+
+// run runs a top level command the way Execute does, without the schema
+// check, and returns what it printed to stdout.
+func (e *testEnv) run(t *testing.T, handler func(context.Context, *state, command) error, name string, args ...string) (string, error) {
+	t.Helper()
+	return captureStdout(t, func() error {
+		return handler(context.Background(), e.s, command{Name: name, Args: args})
+	})
+}
+
+// register creates a user and logs it in.
+func (e *testEnv) register(t *testing.T, name string) database.User {
+	t.Helper()
+	if _, err := e.run(t, handlerRegister, "register", name); err != nil {
+		t.Fatalf("register %s: %v", name, err)
+	}
+	user, err := e.store.GetUserByName(context.Background(), name)
+	if err != nil {
+		t.Fatalf("get user %s: %v", name, err)
+	}
+	return user
+}
+
+// addFeed adds a feed as the logged in user, who then follows it.
+func (e *testEnv) addFeed(t *testing.T, name, url string) database.Feed {
+	t.Helper()
+	if _, err := e.run(t, middlewareLoggedIn(handlerAddFeed), "addfeed", name, url); err != nil {
+		t.Fatalf("addfeed %s: %v", name, err)
+	}
+	feed, err := e.store.GetFeedByUrl(context.Background(), url)
+	if err != nil {
+		t.Fatalf("get feed %s: %v", url, err)
+	}
+	return feed
+}
+
+// captureStdout returns what fn prints to stdout.
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	fnErr := fn()
+	w.Close()
+	return <-output, fnErr
+}
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of the
+// aggregator workers.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// testItem is an item of the RSS feed served by newFeedServer.
+type testItem struct {
+	title   string
+	guid    string
+	pubDate string
+}
+
+// feedServer serves an RSS feed whose items and status can be changed
+// between requests.
+type feedServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	items  []testItem
+	status int
+}
+
+func newFeedServer(t *testing.T, items ...testItem) *feedServer {
+	t.Helper()
+	fs := &feedServer{items: items, status: http.StatusOK}
+	fs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fs.mu.Lock()
+		defer fs.mu.Unlock()
+
+		if fs.status != http.StatusOK {
+			w.WriteHeader(fs.status)
+			return
+		}
+		var body strings.Builder
+		body.WriteString(`<?xml version="1.0"?><rss version="2.0"><channel><title>Test</title><link>http://example.com</link><description>Test feed</description>`)
+		for _, item := range fs.items {
+			fmt.Fprintf(&body, "<item><title>%s</title><link>http://example.com/%s</link><guid>%s</guid><description>About %s</description><pubDate>%s</pubDate></item>",
+				item.title, item.guid, item.guid, item.title, item.pubDate)
+		}
+		body.WriteString(`</channel></rss>`)
+		w.Header().Set("Content-Type", "application/rss+xml")
+		io.WriteString(w, body.String())
+	}))
+	t.Cleanup(fs.Close)
+	return fs
+}
+
+func (fs *feedServer) setItems(items ...testItem) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.items = items
+}
+
+func (fs *feedServer) setStatus(status int) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.status = status
+}
+
+func TestCommandsRun(t *testing.T) {
+	env := newTestEnv(t)
+	cmds := commands{registeredCommands: make(map[string]func(context.Context, *state, command) error)}
+	cmds.register("register", handlerRegister)
+
+	if err := cmds.run(context.Background(), env.s, command{Name: "register", Args: []string{"alan"}}); err != nil {
+		t.Fatalf("run register: %v", err)
+	}
+	if err := cmds.run(context.Background(), env.s, command{Name: "nope"}); err == nil {
+		t.Error("run of an unknown command succeeded")
+	}
+}
+
+func rssItems(items []testItem) []api.RSSItem {
+	rss := make([]api.RSSItem, 0, len(items))
+	for _, item := range items {
+		rss = append(rss, api.RSSItem{
+			Title:       item.title,
+			Link:        "http://example.com/" + item.guid,
+			Description: "About " + item.title,
+			PubDate:     item.pubDate,
+			GUID:        item.guid,
+		})
+	}
+	return rss
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func handlerSearch(ctx context.Context, s *state, cmd command, user database.User) error {
+	usage := fmt.Errorf("usage: %s [--feed url] [--since date] [--until date] [--limit n] <query>", cmd.Name)
+
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	feedURL := flags.String("feed", "", "only search posts of this feed")
+	since := flags.String("since", "", "only search posts published on or after this date")
+	until := flags.String("until", "", "only search posts published before this date")
+	limit := flags.Int("limit", 10, "maximum number of results")
+
+	if err := flags.Parse(cmd.Args); err != nil || flags.NArg() == 0 || *limit < 1 {
+		return usage
+	}
+
+	params := database.SearchPostsParams{
+		Query:    strings.Join(flags.Args(), " "),
+		UserID:   user.ID,
+		PageSize: int32(*limit),
+	}
+
+	if *feedURL != "" {
+		feed, err := s.db.GetFeedByUrl(ctx, *feedURL)
+		if err != nil {
+			return fmt.Errorf("couldn't find feed: %w", err)
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+	if *since != "" {
+		date, err := parseDate(*since)
+		if err != nil {
+			return err
+		}
+		params.Since = sql.NullTime{Time: date, Valid: true}
+	}
+	if *until != "" {
+		date, err := parseDate(*until)
+		if err != nil {
+			return err
+		}
+		params.Until = sql.NullTime{Time: date, Valid: true}
+	}
+
+	posts, err := s.db.SearchPosts(ctx, params)
+	if err != nil {
+		return fmt.Errorf("couldn't search posts: %w", err)
+	}
+
+	s.logger.Info("Found posts", "user", user.Name, "query", params.Query, "count", len(posts))
+	for _, post := range posts {
+		fmt.Printf("%s from %s\n", post.PublishedAt.Time.Format("Mon Jan 2"), post.FeedName)
+		fmt.Printf("--- %s ---\n", post.Title)
+		fmt.Printf("ID: %s\n", post.ID)
+		fmt.Printf("Match: %s\n", strings.Join(strings.Fields(post.Snippet), " "))
+		fmt.Printf("Link: %s\n", post.Url)
+		fmt.Println("=====================================")
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	serverReadTimeout     = 10 * time.Second
+	serverShutdownTimeout = 5 * time.Second
+)
+
+// serveHTTP serves handler on addr until ctx is cancelled. Failing to listen
+// is logged rather than fatal, so the aggregator keeps running.
+func serveHTTP(ctx context.Context, s *state, addr string, handler http.Handler) {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: serverReadTimeout,
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	})
+	defer stop()
+
+	s.logger.Info("Serving HTTP", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.logger.Error("HTTP server stopped", "addr", addr, "error", err)
+	}
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func handlerStar(ctx context.Context, s *state, cmd command, user database.User) error {
+	ids, err := parsePostIDs(cmd)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.InTx(ctx, func(q database.Querier) error {
+		for _, id := range ids {
+			n, err := q.StarPost(ctx, database.StarPostParams{
+				CreatedAt: time.Now().UTC(),
+				UserID:    user.ID,
+				PostID:    id,
+			})
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				return fmt.Errorf("post %s not found in the feeds you follow", id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't star posts: %w", err)
+	}
+
+	s.logger.Info("Starred posts", "user", user.Name, "count", len(ids))
+	return nil
+}
+
+func handlerUnstar(ctx context.Context, s *state, cmd command, user database.User) error {
+	ids, err := parsePostIDs(cmd)
+	if err != nil {
+		return err
+	}
+
+	var unstarred int64
+	err = s.db.InTx(ctx, func(q database.Querier) error {
+		for _, id := range ids {
+			n, err := q.UnstarPost(ctx, database.UnstarPostParams{UserID: user.ID, PostID: id})
+			if err != nil {
+				return err
+			}
+			unstarred += n
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't unstar posts: %w", err)
+	}
+
+	s.logger.Info("Unstarred posts", "user", user.Name, "count", unstarred)
+	return nil
+}
+
+func handlerStarred(ctx context.Context, s *state, cmd command, user database.User) error {
+	limit, err := parseListLimit(cmd)
+	if err != nil {
+		return err
+	}
+
+	posts, err := s.db.GetStarredPostsForUser(ctx, database.GetStarredPostsForUserParams{
+		UserID: user.ID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't get starred posts: %w", err)
+	}
+
+	if len(posts) == 0 {
+		fmt.Println("No starred posts, star one with: star <post-id>")
+		return nil
+	}
+	for _, post := range posts {
+		printPost(post.PublishedAt, post.FeedName, post.Title, post.ID, post.Description, post.Url)
+	}
+	return nil
+}
+
+// Read Later Handlers
+func handlerLater(ctx context.Context, s *state, cmd command, user database.User) error {
+	if len(cmd.Args) > 0 {
+		sub := command{Name: cmd.Name + " " + cmd.Args[0], Args: cmd.Args[1:]}
+		switch cmd.Args[0] {
+		case "add":
+			return handlerSavePosts(ctx, s, sub, user)
+		case "remove":
+			return handlerUnsavePosts(ctx, s, sub, user)
+		}
+	}
+
+	limit, err := parseListLimit(cmd)
+	if err != nil {
+		return fmt.Errorf("usage: %s [limit] | %s add <post-id> ... | %s remove <post-id> ...", cmd.Name, cmd.Name, cmd.Name)
+	}
+
+	posts, err := s.db.GetSavedPostsForUser(ctx, database.GetSavedPostsForUserParams{
+		UserID: user.ID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't get read later list: %w", err)
+	}
+
+	if len(posts) == 0 {
+		fmt.Println("Nothing to read later, add a post with: later add <post-id>")
+		return nil
+	}
+	for _, post := range posts {
+		printPost(post.PublishedAt, post.FeedName, post.Title, post.ID, post.Description, post.Url)
+	}
+	return nil
+}
+
+func handlerSavePosts(ctx context.Context, s *state, cmd command, user database.User) error {
+	ids, err := parsePostIDs(cmd)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.InTx(ctx, func(q database.Querier) error {
+		for _, id := range ids {
+			n, err := q.SavePost(ctx, database.SavePostParams{
+				CreatedAt: time.Now().UTC(),
+				UserID:    user.ID,
+				PostID:    id,
+			})
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				return fmt.Errorf("post %s not found in the feeds you follow", id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't save posts: %w", err)
+	}
+
+	s.logger.Info("Saved posts for later", "user", user.Name, "count", len(ids))
+	return nil
+}
+
+func handlerUnsavePosts(ctx context.Context, s *state, cmd command, user database.User) error {
+	ids, err := parsePostIDs(cmd)
+	if err != nil {
+		return err
+	}
+
+	var removed int64
+	err = s.db.InTx(ctx, func(q database.Querier) error {
+		for _, id := range ids {
+			n, err := q.UnsavePost(ctx, database.UnsavePostParams{UserID: user.ID, PostID: id})
+			if err != nil {
+				return err
+			}
+			removed += n
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't remove posts from read later list: %w", err)
+	}
+
+	s.logger.Info("Removed posts from read later list", "user", user.Name, "count", removed)
+	return nil
+}
+
+func parsePostIDs(cmd command) ([]uuid.UUID, error) {
+	if len(cmd.Args) == 0 {
+		return nil, fmt.Errorf("usage: %s <post-id> ...", cmd.Name)
+	}
+
+	ids := make([]uuid.UUID, 0, len(cmd.Args))
+	for _, arg := range cmd.Args {
+		id, err := uuid.Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid post id: %s", arg)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseListLimit returns the optional limit argument of a listing command.
+func parseListLimit(cmd command) (int, error) {
+	switch len(cmd.Args) {
+	case 0:
+		return 20, nil
+	case 1:
+		limit, err := strconv.Atoi(cmd.Args[0])
+		if err != nil || limit < 1 {
+			return 0, fmt.Errorf("invalid limit: %s", cmd.Args[0])
+		}
+		return limit, nil
+	default:
+		return 0, fmt.Errorf("usage: %s [limit]", cmd.Name)
+	}
+}
+
+func printPost(publishedAt sql.NullTime, feedName, title string, id uuid.UUID, description sql.NullString, url string) {
+	fmt.Printf("%s from %s\n", publishedAt.Time.Format("Mon Jan 2"), feedName)
+	fmt.Printf("--- %s ---\n", title)
+	fmt.Printf("ID: %s\n", id)
+	fmt.Printf("Desc: %v\n", description.String)
+	fmt.Printf("Link: %s\n", url)
+	fmt.Println("=====================================")
+}
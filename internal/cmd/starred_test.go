@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// postIDsForTest returns the IDs of the user's posts by title.
+func postIDsForTest(t *testing.T, env *testEnv, user database.User) map[string]string {
+	t.Helper()
+	posts, err := env.store.GetPostsForUser(context.Background(), database.GetPostsForUserParams{UserID: user.ID, PageSize: 100})
+	if err != nil {
+		t.Fatalf("get posts: %v", err)
+	}
+	ids := make(map[string]string, len(posts))
+	for _, post := range posts {
+		ids[post.Title] = post.ID.String()
+	}
+	return ids
+}
+
+func TestHandlerStar(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	savePostsForTest(t, env, feed,
+		testItem{title: "First", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Second", guid: "2", pubDate: "Tue, 06 Oct 2026 10:00:00 +0000"},
+	)
+	ids := postIDsForTest(t, env, user)
+	starred := middlewareLoggedIn(handlerStarred)
+
+	output, err := env.run(t, starred, "starred")
+	if err != nil {
+		t.Fatalf("starred: %v", err)
+	}
+	if !strings.Contains(output, "No starred posts") {
+		t.Errorf("starred should report no posts:\n%s", output)
+	}
+
+	if _, err := env.run(t, middlewareLoggedIn(handlerStar), "star", ids["First"]); err != nil {
+		t.Fatalf("star: %v", err)
+	}
+	if _, err := env.run(t, middlewareLoggedIn(handlerStar), "star", ids["Second"], ids["First"]); err != nil {
+		t.Fatalf("star again: %v", err)
+	}
+
+	output, err = env.run(t, starred, "starred")
+	if err != nil {
+		t.Fatalf("starred: %v", err)
+	}
+	if strings.Count(output, "ID: ") != 2 || strings.Index(output, "Second") > strings.Index(output, "First") {
+		t.Errorf("starred should show both posts, last starred first:\n%s", output)
+	}
+
+	if _, err := env.run(t, middlewareLoggedIn(handlerUnstar), "unstar", ids["Second"]); err != nil {
+		t.Fatalf("unstar: %v", err)
+	}
+	output, _ = env.run(t, starred, "starred", "5")
+	if strings.Contains(output, "Second") || !strings.Contains(output, "--- First ---") {
+		t.Errorf("starred after unstar:\n%s", output)
+	}
+
+	for _, args := range [][]string{{}, {"not-a-uuid"}, {uuid.NewString()}} {
+		if _, err := env.run(t, middlewareLoggedIn(handlerStar), "star", args...); err == nil {
+			t.Errorf("star %v succeeded", args)
+		}
+	}
+	if _, err := env.run(t, starred, "starred", "none"); err == nil {
+		t.Error("starred with an invalid limit succeeded")
+	}
+}
+
+func TestHandlerLater(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	savePostsForTest(t, env, feed,
+		testItem{title: "First", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Second", guid: "2", pubDate: "Tue, 06 Oct 2026 10:00:00 +0000"},
+	)
+	ids := postIDsForTest(t, env, user)
+	later := middlewareLoggedIn(handlerLater)
+
+	output, err := env.run(t, later, "later")
+	if err != nil {
+		t.Fatalf("later: %v", err)
+	}
+	if !strings.Contains(output, "Nothing to read later") {
+		t.Errorf("later should report an empty list:\n%s", output)
+	}
+
+	if _, err := env.run(t, later, "later", "add", ids["Second"]); err != nil {
+		t.Fatalf("later add: %v", err)
+	}
+	if _, err := env.run(t, later, "later", "add", ids["First"], ids["Second"]); err != nil {
+		t.Fatalf("later add again: %v", err)
+	}
+
+	output, err = env.run(t, later, "later")
+	if err != nil {
+		t.Fatalf("later: %v", err)
+	}
+	if strings.Count(output, "ID: ") != 2 || strings.Index(output, "Second") > strings.Index(output, "First") {
+		t.Errorf("later should list both posts, first saved first:\n%s", output)
+	}
+
+	if _, err := env.run(t, later, "later", "remove", ids["Second"]); err != nil {
+		t.Fatalf("later remove: %v", err)
+	}
+	output, _ = env.run(t, later, "later", "1")
+	if strings.Contains(output, "Second") || !strings.Contains(output, "--- First ---") {
+		t.Errorf("later after remove:\n%s", output)
+	}
+
+	for _, args := range [][]string{{"add"}, {"add", uuid.NewString()}, {"remove", "not-a-uuid"}, {"sideways"}} {
+		if _, err := env.run(t, later, "later", args...); err == nil {
+			t.Errorf("later %v succeeded", args)
+		}
+	}
+}
+
+func TestHandlerPrunePosts(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	savePostsForTest(t, env, feed,
+		testItem{title: "Old", guid: "1", pubDate: "Mon, 05 Jan 2026 10:00:00 +0000"},
+		testItem{title: "Old starred", guid: "2", pubDate: "Mon, 05 Jan 2026 10:00:00 +0000"},
+		testItem{title: "Old saved", guid: "3", pubDate: "Mon, 05 Jan 2026 10:00:00 +0000"},
+		testItem{title: "Old tagged", guid: "5", pubDate: "Mon, 05 Jan 2026 10:00:00 +0000"},
+		testItem{title: "New", guid: "4", pubDate: "Mon, 05 Oct 2099 10:00:00 +0000"},
+	)
+	ids := postIDsForTest(t, env, user)
+
+	if _, err := env.run(t, middlewareLoggedIn(handlerStar), "star", ids["Old starred"]); err != nil {
+		t.Fatalf("star: %v", err)
+	}
+	if _, err := env.run(t, middlewareLoggedIn(handlerLater), "later", "add", ids["Old saved"]); err != nil {
+		t.Fatalf("later add: %v", err)
+	}
+	if _, err := env.run(t, middlewareLoggedIn(handlerTags), "tags", "add", ids["Old tagged"], "keep"); err != nil {
+		t.Fatalf("tags add: %v", err)
+	}
+
+	if _, err := env.run(t, handlerPrunePosts, "prune", "720h"); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	left := postIDsForTest(t, env, user)
+	if _, ok := left["Old"]; ok || len(left) != 4 {
+		t.Errorf("posts left = %v, want the starred, saved, tagged and new ones", left)
+	}
+	if !strings.Contains(env.logs.String(), "deleted=1") {
+		t.Errorf("prune logs miss the count:\n%s", env.logs.String())
+	}
+
+	for _, args := range [][]string{{}, {"a while"}} {
+		if _, err := env.run(t, handlerPrunePosts, "prune", args...); err == nil {
+			t.Errorf("prune %v succeeded", args)
+		}
+	}
+}
+
+func TestHandlerPrunePostsNotFetchedAgain(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	server := newFeedServer(t,
+		testItem{title: "Old", guid: "1", pubDate: "Mon, 05 Jan 2026 10:00:00 +0000"},
+		testItem{title: "New", guid: "2", pubDate: "Mon, 05 Oct 2099 10:00:00 +0000"},
+	)
+	env.addFeed(t, "Blog", server.URL)
+
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", server.URL); err != nil {
+		t.Fatalf("agg --feed: %v", err)
+	}
+	if _, err := env.run(t, handlerPrunePosts, "prune", "720h"); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+
+	// The pruned post is still in the feed, but isn't inserted again.
+	server.setItems(
+		testItem{title: "Old", guid: "1", pubDate: "Mon, 05 Jan 2026 10:00:00 +0000"},
+		testItem{title: "New", guid: "2", pubDate: "Mon, 05 Oct 2099 10:00:00 +0000"},
+		testItem{title: "Newer", guid: "3", pubDate: "Tue, 06 Oct 2099 10:00:00 +0000"},
+	)
+	if _, err := env.run(t, handlerAggregator, "agg", "--feed", server.URL); err != nil {
+		t.Fatalf("agg --feed after prune: %v", err)
+	}
+
+	left := postIDsForTest(t, env, user)
+	if _, ok := left["Old"]; ok || len(left) != 2 {
+		t.Errorf("posts after fetching again = %v, want New and Newer", left)
+	}
+	if logs := env.logs.String(); !strings.Contains(logs, "new_posts=1 updated_posts=0") {
+		t.Errorf("the fetch after prune should only find the newer post:\n%s", logs)
+	}
+}
+
+func TestPrunePostsSQLiteOutsideUTC(t *testing.T) {
+	// go-sqlite3 stores times with the offset of their zone, so run as if
+	// TZ were set east and west of UTC.
+	for _, hours := range []int{10, -10} {
+		t.Run(fmt.Sprintf("UTC%+d", hours), func(t *testing.T) {
+			local := time.Local
+			time.Local = time.FixedZone(fmt.Sprintf("UTC%+d", hours), hours*60*60)
+			t.Cleanup(func() { time.Local = local })
+
+			env := newSQLiteEnv(t)
+			ctx := context.Background()
+			// Stop before 011_utc_times, so that it has to rewrite a time
+			// stored in the local zone.
+			if _, err := env.run(t, handlerMigrate, "migrate", "to", "10"); err != nil {
+				t.Fatalf("migrate to 10: %v", err)
+			}
+			createdAt := time.Now().Truncate(time.Millisecond)
+			if _, err := env.s.conn.ExecContext(ctx,
+				"INSERT INTO users (id, created_at, updated_at, name) VALUES (?, ?, ?, ?)",
+				uuid.New(), createdAt, createdAt, "alan"); err != nil {
+				t.Fatalf("insert user: %v", err)
+			}
+			if _, err := env.run(t, handlerMigrate, "migrate", "up"); err != nil {
+				t.Fatalf("migrate up: %v", err)
+			}
+			var stored string
+			if err := env.s.conn.QueryRowContext(ctx, "SELECT CAST(created_at AS TEXT) FROM users").Scan(&stored); err != nil {
+				t.Fatalf("get user: %v", err)
+			}
+			if want := createdAt.UTC().Format("2006-01-02 15:04:05.000+00:00"); stored != want {
+				t.Errorf("created_at after migrating = %s, want %s", stored, want)
+			}
+
+			env.s.cfg.CurrentUserName = "alan"
+			server := newFeedServer(t,
+				testItem{title: "Old", guid: "1", pubDate: time.Now().Add(-2 * time.Hour).Format(time.RFC1123Z)},
+				testItem{title: "New", guid: "2", pubDate: time.Now().Add(-30 * time.Minute).Format(time.RFC1123Z)},
+			)
+			if _, err := env.run(t, middlewareLoggedIn(handlerAddFeed), "addfeed", "Blog", server.URL); err != nil {
+				t.Fatalf("addfeed: %v", err)
+			}
+			if _, err := env.run(t, handlerAggregator, "agg", "--feed", server.URL); err != nil {
+				t.Fatalf("agg --feed: %v", err)
+			}
+
+			deleted, err := prunePosts(ctx, env.s.db, time.Now().Add(-time.Hour))
+			if err != nil {
+				t.Fatalf("prune posts: %v", err)
+			}
+			if deleted != 1 {
+				t.Errorf("pruned %d posts, want only the one published before the cutoff", deleted)
+			}
+		})
+	}
+}
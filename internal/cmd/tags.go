@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func handlerTags(ctx context.Context, s *state, cmd command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return handlerListTags(ctx, s, cmd, user)
+	}
+
+	sub := command{Name: cmd.Name + " " + cmd.Args[0], Args: cmd.Args[1:]}
+	switch cmd.Args[0] {
+	case "add":
+		return handlerTagPost(ctx, s, sub, user)
+	case "remove":
+		return handlerUntagPost(ctx, s, sub, user)
+	case "export":
+		return handlerExportTags(ctx, s, sub, user)
+	default:
+		return fmt.Errorf("usage: %s [add | remove | export]", cmd.Name)
+	}
+}
+
+func handlerListTags(ctx context.Context, s *state, _ command, user database.User) error {
+	tags, err := s.db.GetTagsForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get tags: %w", err)
+	}
+
+	if len(tags) == 0 {
+		fmt.Println("No tags, tag a post with: tags add <post-id> <tag> ...")
+		return nil
+	}
+
+	for _, tag := range tags {
+		fmt.Printf("* %s: %d posts\n", tag.Name, tag.PostCount)
+	}
+	return nil
+}
+
+func handlerTagPost(ctx context.Context, s *state, cmd command, user database.User) error {
+	postID, names, err := parseTagArgs(cmd)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	err = s.db.InTx(ctx, func(q database.Querier) error {
+		for _, name := range names {
+			tag, err := q.UpsertTag(ctx, database.UpsertTagParams{
+				ID:        uuid.New(),
+				CreatedAt: now,
+				UserID:    user.ID,
+				Name:      name,
+			})
+			if err != nil {
+				return err
+			}
+			n, err := q.TagPost(ctx, database.TagPostParams{
+				CreatedAt: now,
+				TagID:     tag.ID,
+				PostID:    postID,
+			})
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				return fmt.Errorf("post %s not found in the feeds you follow", postID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't tag post: %w", err)
+	}
+
+	s.logger.Info("Tagged post", "user", user.Name, "post_id", postID, "tags", strings.Join(names, ","))
+	return nil
+}
+
+// handlerUntagPost removes tags from a post. Tags left without posts are
+// deleted.
+func handlerUntagPost(ctx context.Context, s *state, cmd command, user database.User) error {
+	postID, names, err := parseTagArgs(cmd)
+	if err != nil {
+		return err
+	}
+
+	var removed int64
+	err = s.db.InTx(ctx, func(q database.Querier) error {
+		for _, name := range names {
+			n, err := q.UntagPost(ctx, database.UntagPostParams{PostID: postID, UserID: user.ID, Name: name})
+			if err != nil {
+				return err
+			}
+			removed += n
+		}
+		return q.DeleteUnusedTags(ctx, user.ID)
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't untag post: %w", err)
+	}
+
+	s.logger.Info("Removed tags from post", "user", user.Name, "post_id", postID, "count", removed)
+	return nil
+}
+
+// taggedPost is a tagged post in the JSON export.
+type taggedPost struct {
+	Tag         string     `json:"tag"`
+	PostID      string     `json:"post_id"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Feed        string     `json:"feed"`
+	PublishedAt *time.Time `json:"published_at"`
+	TaggedAt    time.Time  `json:"tagged_at"`
+}
+
+// handlerExportTags writes the user's tagged posts to stdout as JSON or
+// CSV, one entry per tag of a post.
+func handlerExportTags(ctx context.Context, s *state, cmd command, user database.User) error {
+	usage := fmt.Errorf("usage: %s [--format json|csv] [tag]", cmd.Name)
+
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	format := flags.String("format", "json", "output format, json or csv")
+
+	if err := flags.Parse(cmd.Args); err != nil || flags.NArg() > 1 || (*format != "json" && *format != "csv") {
+		return usage
+	}
+
+	params := database.GetTaggedPostsForUserParams{UserID: user.ID}
+	if flags.NArg() == 1 {
+		params.Tag = sql.NullString{String: normalizeTag(flags.Arg(0)), Valid: true}
+	}
+	posts, err := s.db.GetTaggedPostsForUser(ctx, params)
+	if err != nil {
+		return fmt.Errorf("couldn't get tagged posts: %w", err)
+	}
+
+	if *format == "csv" {
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"tag", "post_id", "title", "url", "feed", "published_at", "tagged_at"})
+		for _, post := range posts {
+			var publishedAt string
+			if post.PublishedAt.Valid {
+				publishedAt = post.PublishedAt.Time.Format(time.RFC3339)
+			}
+			w.Write([]string{
+				post.Tag,
+				post.ID.String(),
+				post.Title,
+				post.Url,
+				post.FeedName,
+				publishedAt,
+				post.TaggedAt.Format(time.RFC3339),
+			})
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	entries := make([]taggedPost, 0, len(posts))
+	for _, post := range posts {
+		entry := taggedPost{
+			Tag:      post.Tag,
+			PostID:   post.ID.String(),
+			Title:    post.Title,
+			URL:      post.Url,
+			Feed:     post.FeedName,
+			TaggedAt: post.TaggedAt,
+		}
+		if post.PublishedAt.Valid {
+			entry.PublishedAt = &post.PublishedAt.Time
+		}
+		entries = append(entries, entry)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+// parseTagArgs parses the <post-id> <tag> ... arguments of tags add and
+// remove.
+func parseTagArgs(cmd command) (uuid.UUID, []string, error) {
+	if len(cmd.Args) < 2 {
+		return uuid.UUID{}, nil, fmt.Errorf("usage: %s <post-id> <tag> ...", cmd.Name)
+	}
+
+	postID, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return uuid.UUID{}, nil, fmt.Errorf("invalid post id: %s", cmd.Args[0])
+	}
+
+	names := make([]string, 0, len(cmd.Args)-1)
+	for _, arg := range cmd.Args[1:] {
+		name := normalizeTag(arg)
+		if name == "" || strings.ContainsFunc(name, unicode.IsSpace) || strings.Contains(name, ",") {
+			return uuid.UUID{}, nil, fmt.Errorf("invalid tag %q, tags can't be empty or contain spaces or commas", arg)
+		}
+		names = append(names, name)
+	}
+	return postID, names, nil
+}
+
+// normalizeTag returns the tag name as stored: trimmed and lower case, so
+// "Security" and "security" are the same tag.
+func normalizeTag(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
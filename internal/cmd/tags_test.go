@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandlerTags(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	savePostsForTest(t, env, feed,
+		testItem{title: "First", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Second", guid: "2", pubDate: "Tue, 06 Oct 2026 10:00:00 +0000"},
+	)
+	ids := postIDsForTest(t, env, user)
+	tags := middlewareLoggedIn(handlerTags)
+
+	output, err := env.run(t, tags, "tags")
+	if err != nil {
+		t.Fatalf("tags: %v", err)
+	}
+	if !strings.Contains(output, "No tags") {
+		t.Errorf("tags should report no tags:\n%s", output)
+	}
+
+	for _, args := range [][]string{
+		{"add", ids["First"], "Security", "to-share"},
+		{"add", ids["Second"], "security"},
+		{"add", ids["Second"], "security"},
+	} {
+		if _, err := env.run(t, tags, "tags", args...); err != nil {
+			t.Fatalf("tags %v: %v", args, err)
+		}
+	}
+
+	output, err = env.run(t, tags, "tags")
+	if err != nil {
+		t.Fatalf("tags: %v", err)
+	}
+	if output != "* security: 2 posts\n* to-share: 1 posts\n" {
+		t.Errorf("tags should list tags with counts:\n%s", output)
+	}
+
+	if _, err := env.run(t, tags, "tags", "remove", ids["First"], "to-share"); err != nil {
+		t.Fatalf("tags remove: %v", err)
+	}
+	output, _ = env.run(t, tags, "tags")
+	if output != "* security: 2 posts\n" {
+		t.Errorf("tags without posts should be deleted:\n%s", output)
+	}
+
+	for _, args := range [][]string{
+		{"add", ids["First"]},
+		{"add", "not-a-uuid", "security"},
+		{"add", ids["First"], "two words"},
+		{"add", ids["First"], "a,b"},
+		{"add", ids["First"], " "},
+		{"add", "00000000-0000-0000-0000-000000000000", "security"},
+		{"export", "--format", "xml"},
+		{"unknown"},
+	} {
+		if _, err := env.run(t, tags, "tags", args...); err == nil {
+			t.Errorf("tags %v succeeded", args)
+		}
+	}
+	output, _ = env.run(t, tags, "tags")
+	if output != "* security: 2 posts\n" {
+		t.Errorf("failed tagging should change nothing:\n%s", output)
+	}
+}
+
+func TestHandlerBrowseTag(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	savePostsForTest(t, env, feed,
+		testItem{title: "First", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Second", guid: "2", pubDate: "Tue, 06 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Third", guid: "3", pubDate: "Wed, 07 Oct 2026 10:00:00 +0000"},
+	)
+	ids := postIDsForTest(t, env, user)
+	browse := middlewareLoggedIn(handlerBrowse)
+
+	for _, id := range []string{ids["First"], ids["Third"]} {
+		if _, err := env.run(t, middlewareLoggedIn(handlerTags), "tags", "add", id, "security"); err != nil {
+			t.Fatalf("tags add: %v", err)
+		}
+	}
+	if _, err := env.run(t, middlewareLoggedIn(handlerRead), "read", "--all"); err != nil {
+		t.Fatalf("read --all: %v", err)
+	}
+
+	output, err := env.run(t, browse, "browse", "--tag", "Security", "1")
+	if err != nil {
+		t.Fatalf("browse --tag: %v", err)
+	}
+	if !strings.Contains(output, "--- Third (read) ---") || strings.Contains(output, "Second") {
+		t.Errorf("browse --tag should show tagged posts, read or not:\n%s", output)
+	}
+	if !strings.Contains(output, "Next page: browse --tag Security --next ") {
+		t.Errorf("browse --tag should keep the tag in page links:\n%s", output)
+	}
+
+	output, err = env.run(t, browse, "browse", "--tag", "unknown")
+	if err != nil {
+		t.Fatalf("browse --tag unknown: %v", err)
+	}
+	if strings.Contains(output, "ID: ") {
+		t.Errorf("browse with an unknown tag should show nothing:\n%s", output)
+	}
+}
+
+func TestHandlerExportTags(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.register(t, "alan")
+	feed := env.addFeed(t, "Blog", "https://example.com/feed.xml")
+	savePostsForTest(t, env, feed,
+		testItem{title: "First", guid: "1", pubDate: "Mon, 05 Oct 2026 10:00:00 +0000"},
+		testItem{title: "Second", guid: "2", pubDate: "Tue, 06 Oct 2026 10:00:00 +0000"},
+	)
+	ids := postIDsForTest(t, env, user)
+	tags := middlewareLoggedIn(handlerTags)
+
+	if _, err := env.run(t, tags, "tags", "add", ids["First"], "security", "to-share"); err != nil {
+		t.Fatalf("tags add: %v", err)
+	}
+	if _, err := env.run(t, tags, "tags", "add", ids["Second"], "to-share"); err != nil {
+		t.Fatalf("tags add: %v", err)
+	}
+
+	output, err := env.run(t, tags, "tags", "export")
+	if err != nil {
+		t.Fatalf("tags export: %v", err)
+	}
+	var entries []taggedPost
+	if err := json.Unmarshal([]byte(output), &entries); err != nil {
+		t.Fatalf("tags export should print JSON: %v\n%s", err, output)
+	}
+	if len(entries) != 3 || entries[0].Tag != "security" || entries[0].Title != "First" || entries[1].Tag != "to-share" {
+		t.Errorf("tags export = %+v", entries)
+	}
+	if want := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC); entries[0].PublishedAt == nil || !entries[0].PublishedAt.Equal(want) {
+		t.Errorf("published_at = %v, want %v", entries[0].PublishedAt, want)
+	}
+	if entries[0].URL == "" || entries[0].Feed != "Blog" || entries[0].PostID != ids["First"] {
+		t.Errorf("tags export entry = %+v", entries[0])
+	}
+
+	output, err = env.run(t, tags, "tags", "export", "--format", "csv", "to-share")
+	if err != nil {
+		t.Fatalf("tags export --format csv: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		t.Fatalf("tags export should print CSV: %v\n%s", err, output)
+	}
+	if len(records) != 3 || strings.Join(records[0], ",") != "tag,post_id,title,url,feed,published_at,tagged_at" || records[1][0] != "to-share" {
+		t.Errorf("tags export --format csv:\n%s", output)
+	}
+
+	output, _ = env.run(t, tags, "tags", "export", "unknown")
+	if strings.TrimSpace(output) != "[]" {
+		t.Errorf("exporting an unknown tag should print an empty list:\n%s", output)
+	}
+}
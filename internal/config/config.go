@@ -14,6 +14,10 @@ const configFileName = ".gatorconfig.json"
 type Config struct {
 	DbURL           string `json:"db_url"`
 	CurrentUserName string `json:"current_user_name"`
+	LogFormat       string `json:"log_format,omitempty"`
+	LogLevel        string `json:"log_level,omitempty"`
+	LogFile         string `json:"log_file,omitempty"`
+	PostRetention   string `json:"post_retention,omitempty"`
 }
 
 func Read() (Config, error) {
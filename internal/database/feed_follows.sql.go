@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,10 +22,10 @@ VALUES (
     $4,
     $5
 )
-RETURNING id, created_at, updated_at, user_id, feed_id
+RETURNING id, created_at, updated_at, user_id, feed_id, folder_id
 )
 SELECT
-    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id,
+    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.folder_id,
     f.name AS feed_name,
     u.name AS user_name
 FROM inserted_feed_follow
@@ -46,6 +47,7 @@ type CreateFeedFollowRow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	FolderID  uuid.NullUUID
 	FeedName  string
 	UserName  string
 }
@@ -65,6 +67,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.FolderID,
 		&i.FeedName,
 		&i.UserName,
 	)
@@ -72,21 +75,31 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT fs.id, fs.created_at, fs.updated_at, fs.user_id, fs.feed_id, f.name AS feed_name, u.name AS user_name
+SELECT fs.id, fs.created_at, fs.updated_at, fs.user_id, fs.feed_id, fs.folder_id, f.name AS feed_name, u.name AS user_name, folders.name AS folder_name, (
+    SELECT COUNT(*)
+    FROM posts p
+    LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = fs.user_id
+    WHERE p.feed_id = fs.feed_id AND ps.read IS NOT TRUE
+) AS unread_count
 FROM feed_follows fs
 INNER JOIN users u on u.id = fs.user_id
 INNER JOIN feeds f on f.id = fs.feed_id
+LEFT JOIN folders ON folders.id = fs.folder_id
 WHERE fs.user_id = $1
+ORDER BY folders.name NULLS FIRST, f.name
 `
 
 type GetFeedFollowsForUserRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.UUID
-	FeedID    uuid.UUID
-	FeedName  string
-	UserName  string
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	FeedID      uuid.UUID
+	FolderID    uuid.NullUUID
+	FeedName    string
+	UserName    string
+	FolderName  sql.NullString
+	UnreadCount int64
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
@@ -104,8 +117,11 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.FolderID,
 			&i.FeedName,
 			&i.UserName,
+			&i.FolderName,
+			&i.UnreadCount,
 		); err != nil {
 			return nil, err
 		}
@@ -7,11 +7,101 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const claimFeed = `-- name: ClaimFeed :one
+UPDATE feeds
+SET lease_owner = $1::text,
+    lease_expires_at = NOW() + ($2::int * INTERVAL '1 second')
+WHERE id = $3
+AND (lease_expires_at IS NULL OR lease_expires_at < NOW())
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, lease_owner, lease_expires_at, next_fetch_at, fetch_interval_seconds, poll_interval_seconds, adaptive_polling, consecutive_failures, last_error, last_success_at, last_http_status, disabled_at
+`
+
+type ClaimFeedParams struct {
+	LeaseOwner   string
+	LeaseSeconds int32
+	ID           uuid.UUID
+}
+
+func (q *Queries) ClaimFeed(ctx context.Context, arg ClaimFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, claimFeed, arg.LeaseOwner, arg.LeaseSeconds, arg.ID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.PollIntervalSeconds,
+		&i.AdaptivePolling,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.LastHttpStatus,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const claimNextFeedToFetch = `-- name: ClaimNextFeedToFetch :one
+UPDATE feeds
+SET lease_owner = $1::text,
+    lease_expires_at = NOW() + ($2::int * INTERVAL '1 second')
+WHERE id = (
+    SELECT id
+    FROM feeds
+    WHERE disabled_at IS NULL
+    AND (lease_expires_at IS NULL OR lease_expires_at < NOW())
+    AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+    ORDER BY next_fetch_at ASC NULLS FIRST
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, lease_owner, lease_expires_at, next_fetch_at, fetch_interval_seconds, poll_interval_seconds, adaptive_polling, consecutive_failures, last_error, last_success_at, last_http_status, disabled_at
+`
+
+type ClaimNextFeedToFetchParams struct {
+	LeaseOwner   string
+	LeaseSeconds int32
+}
+
+func (q *Queries) ClaimNextFeedToFetch(ctx context.Context, arg ClaimNextFeedToFetchParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, claimNextFeedToFetch, arg.LeaseOwner, arg.LeaseSeconds)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.PollIntervalSeconds,
+		&i.AdaptivePolling,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.LastHttpStatus,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
 const createFeed = `-- name: CreateFeed :one
 INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
 VALUES (
@@ -22,7 +112,7 @@ VALUES (
     $5,
     $6
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, lease_owner, lease_expires_at, next_fetch_at, fetch_interval_seconds, poll_interval_seconds, adaptive_polling, consecutive_failures, last_error, last_success_at, last_http_status, disabled_at
 `
 
 type CreateFeedParams struct {
@@ -52,24 +142,85 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.PollIntervalSeconds,
+		&i.AdaptivePolling,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.LastHttpStatus,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const enableFeed = `-- name: EnableFeed :one
+UPDATE feeds
+SET consecutive_failures = 0,
+    disabled_at = NULL,
+    next_fetch_at = NULL,
+    updated_at = NOW()
+WHERE url = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, lease_owner, lease_expires_at, next_fetch_at, fetch_interval_seconds, poll_interval_seconds, adaptive_polling, consecutive_failures, last_error, last_success_at, last_http_status, disabled_at
+`
+
+func (q *Queries) EnableFeed(ctx context.Context, url string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, enableFeed, url)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.PollIntervalSeconds,
+		&i.AdaptivePolling,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.LastHttpStatus,
+		&i.DisabledAt,
 	)
 	return i, err
 }
 
 const getAllFeeds = `-- name: GetAllFeeds :many
-SELECT f.name, f.url, u.name as author_name
+SELECT f.id, f.created_at, f.name, f.url, u.name as author_name
 FROM feeds f
 INNER JOIN users u ON f.user_id = u.id
+WHERE $1::timestamp IS NULL
+   OR (f.created_at, f.id) > ($1, $2::uuid)
+ORDER BY f.created_at, f.id
+LIMIT $3
 `
 
+type GetAllFeedsParams struct {
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	PageSize       int32
+}
+
 type GetAllFeedsRow struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
 	Name       string
 	Url        string
 	AuthorName string
 }
 
-func (q *Queries) GetAllFeeds(ctx context.Context) ([]GetAllFeedsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getAllFeeds)
+// Returns a page of feeds in the order they were added, after the given
+// (created_at, id) cursor if any.
+func (q *Queries) GetAllFeeds(ctx context.Context, arg GetAllFeedsParams) ([]GetAllFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAllFeeds, arg.AfterCreatedAt, arg.AfterID, arg.PageSize)
 	if err != nil {
 		return nil, err
 	}
@@ -77,7 +228,13 @@ func (q *Queries) GetAllFeeds(ctx context.Context) ([]GetAllFeedsRow, error) {
 	var items []GetAllFeedsRow
 	for rows.Next() {
 		var i GetAllFeedsRow
-		if err := rows.Scan(&i.Name, &i.Url, &i.AuthorName); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Name,
+			&i.Url,
+			&i.AuthorName,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -92,7 +249,7 @@ func (q *Queries) GetAllFeeds(ctx context.Context) ([]GetAllFeedsRow, error) {
 }
 
 const getFeedByUrl = `-- name: GetFeedByUrl :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, lease_owner, lease_expires_at, next_fetch_at, fetch_interval_seconds, poll_interval_seconds, adaptive_polling, consecutive_failures, last_error, last_success_at, last_http_status, disabled_at
 FROM feeds
 WHERE url = $1
 `
@@ -108,19 +265,173 @@ func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.PollIntervalSeconds,
+		&i.AdaptivePolling,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.LastHttpStatus,
+		&i.DisabledAt,
 	)
 	return i, err
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at 
+const getFeedsByName = `-- name: GetFeedsByName :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, lease_owner, lease_expires_at, next_fetch_at, fetch_interval_seconds, poll_interval_seconds, adaptive_polling, consecutive_failures, last_error, last_success_at, last_http_status, disabled_at
+FROM feeds
+WHERE name = $1
+`
+
+func (q *Queries) GetFeedsByName(ctx context.Context, name string) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsByName, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.LeaseOwner,
+			&i.LeaseExpiresAt,
+			&i.NextFetchAt,
+			&i.FetchIntervalSeconds,
+			&i.PollIntervalSeconds,
+			&i.AdaptivePolling,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.LastHttpStatus,
+			&i.DisabledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFetchQueueStats = `-- name: GetFetchQueueStats :one
+SELECT
+    COUNT(*) AS due_feeds,
+    COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(COALESCE(next_fetch_at, created_at))), 0)::float8 AS oldest_due_seconds
+FROM feeds
+WHERE disabled_at IS NULL
+AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+`
+
+type GetFetchQueueStatsRow struct {
+	DueFeeds         int64
+	OldestDueSeconds float64
+}
+
+func (q *Queries) GetFetchQueueStats(ctx context.Context) (GetFetchQueueStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getFetchQueueStats)
+	var i GetFetchQueueStatsRow
+	err := row.Scan(&i.DueFeeds, &i.OldestDueSeconds)
+	return i, err
+}
+
+const getUnhealthyFeeds = `-- name: GetUnhealthyFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, lease_owner, lease_expires_at, next_fetch_at, fetch_interval_seconds, poll_interval_seconds, adaptive_polling, consecutive_failures, last_error, last_success_at, last_http_status, disabled_at
 FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1
+WHERE consecutive_failures > 0 OR disabled_at IS NOT NULL
+ORDER BY disabled_at ASC NULLS LAST, consecutive_failures DESC, name
+`
+
+func (q *Queries) GetUnhealthyFeeds(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getUnhealthyFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.LeaseOwner,
+			&i.LeaseExpiresAt,
+			&i.NextFetchAt,
+			&i.FetchIntervalSeconds,
+			&i.PollIntervalSeconds,
+			&i.AdaptivePolling,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.LastHttpStatus,
+			&i.DisabledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markFeedFailed = `-- name: MarkFeedFailed :one
+UPDATE feeds
+SET last_fetched_at = NOW(),
+    updated_at = NOW(),
+    next_fetch_at = NOW() + ($1::int * INTERVAL '1 second'),
+    consecutive_failures = consecutive_failures + 1,
+    last_error = $2::text,
+    last_http_status = $3,
+    disabled_at = CASE WHEN $4::bool THEN NOW() ELSE NULL END,
+    lease_owner = NULL,
+    lease_expires_at = NULL
+WHERE id = $5 AND lease_owner = $6::text
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, lease_owner, lease_expires_at, next_fetch_at, fetch_interval_seconds, poll_interval_seconds, adaptive_polling, consecutive_failures, last_error, last_success_at, last_http_status, disabled_at
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
+type MarkFeedFailedParams struct {
+	BackoffSeconds int32
+	LastError      string
+	HttpStatus     sql.NullInt32
+	Disable        bool
+	ID             uuid.UUID
+	LeaseOwner     string
+}
+
+// Like MarkFeedFetched, returns no row once the lease was lost.
+func (q *Queries) MarkFeedFailed(ctx context.Context, arg MarkFeedFailedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, markFeedFailed,
+		arg.BackoffSeconds,
+		arg.LastError,
+		arg.HttpStatus,
+		arg.Disable,
+		arg.ID,
+		arg.LeaseOwner,
+	)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -130,6 +441,17 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.PollIntervalSeconds,
+		&i.AdaptivePolling,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.LastHttpStatus,
+		&i.DisabledAt,
 	)
 	return i, err
 }
@@ -137,13 +459,101 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 const markFeedFetched = `-- name: MarkFeedFetched :one
 UPDATE feeds
 SET last_fetched_at = NOW(),
+    updated_at = NOW(),
+    poll_interval_seconds = $1::int,
+    next_fetch_at = NOW() + ($1::int * INTERVAL '1 second'),
+    consecutive_failures = 0,
+    last_error = NULL,
+    last_success_at = NOW(),
+    disabled_at = NULL,
+    last_http_status = $2::int,
+    lease_owner = NULL,
+    lease_expires_at = NULL
+WHERE id = $3 AND lease_owner = $4::text
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, lease_owner, lease_expires_at, next_fetch_at, fetch_interval_seconds, poll_interval_seconds, adaptive_polling, consecutive_failures, last_error, last_success_at, last_http_status, disabled_at
+`
+
+type MarkFeedFetchedParams struct {
+	PollIntervalSeconds int32
+	HttpStatus          int32
+	ID                  uuid.UUID
+	LeaseOwner          string
+}
+
+// Only the process holding the lease can record the outcome of a fetch, for
+// any other no row is returned.
+func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, markFeedFetched,
+		arg.PollIntervalSeconds,
+		arg.HttpStatus,
+		arg.ID,
+		arg.LeaseOwner,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.PollIntervalSeconds,
+		&i.AdaptivePolling,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.LastHttpStatus,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const releaseFeedLease = `-- name: ReleaseFeedLease :exec
+UPDATE feeds
+SET lease_owner = NULL,
+    lease_expires_at = NULL
+WHERE id = $1 AND lease_owner = $2
+`
+
+type ReleaseFeedLeaseParams struct {
+	ID         uuid.UUID
+	LeaseOwner sql.NullString
+}
+
+func (q *Queries) ReleaseFeedLease(ctx context.Context, arg ReleaseFeedLeaseParams) error {
+	_, err := q.db.ExecContext(ctx, releaseFeedLease, arg.ID, arg.LeaseOwner)
+	return err
+}
+
+const updateFeedSchedule = `-- name: UpdateFeedSchedule :one
+UPDATE feeds
+SET fetch_interval_seconds = $3,
+    adaptive_polling = $4,
+    poll_interval_seconds = NULL,
     updated_at = NOW()
-WHERE id = $1
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+WHERE id = $1 AND user_id = $2
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, lease_owner, lease_expires_at, next_fetch_at, fetch_interval_seconds, poll_interval_seconds, adaptive_polling, consecutive_failures, last_error, last_success_at, last_http_status, disabled_at
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, markFeedFetched, id)
+type UpdateFeedScheduleParams struct {
+	ID                   uuid.UUID
+	UserID               uuid.UUID
+	FetchIntervalSeconds sql.NullInt32
+	AdaptivePolling      bool
+}
+
+func (q *Queries) UpdateFeedSchedule(ctx context.Context, arg UpdateFeedScheduleParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, updateFeedSchedule,
+		arg.ID,
+		arg.UserID,
+		arg.FetchIntervalSeconds,
+		arg.AdaptivePolling,
+	)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -153,6 +563,17 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, erro
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.PollIntervalSeconds,
+		&i.AdaptivePolling,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.LastHttpStatus,
+		&i.DisabledAt,
 	)
 	return i, err
 }
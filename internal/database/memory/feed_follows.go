@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	user, ok := t.userByID(arg.UserID)
+	if !ok {
+		return database.CreateFeedFollowRow{}, errForeignKey("fk_users")
+	}
+	feed, ok := t.feedByID(arg.FeedID)
+	if !ok {
+		return database.CreateFeedFollowRow{}, errForeignKey("fk_feeds")
+	}
+	for _, follow := range t.follows {
+		if follow.UserID == arg.UserID && follow.FeedID == arg.FeedID {
+			return database.CreateFeedFollowRow{}, errUnique("feed_follows_user_id_feed_id_key")
+		}
+	}
+
+	follow := database.FeedFollow{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		FeedID:    arg.FeedID,
+	}
+	t.follows = append(t.follows, follow)
+	return database.CreateFeedFollowRow{
+		ID:        follow.ID,
+		CreatedAt: follow.CreatedAt,
+		UpdatedAt: follow.UpdatedAt,
+		UserID:    follow.UserID,
+		FeedID:    follow.FeedID,
+		FolderID:  follow.FolderID,
+		FeedName:  feed.Name,
+		UserName:  user.Name,
+	}, nil
+}
+
+func (s *Store) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsForUserRow, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	var rows []database.GetFeedFollowsForUserRow
+	for _, follow := range t.follows {
+		if follow.UserID != userID {
+			continue
+		}
+		user, _ := t.userByID(follow.UserID)
+		feed, _ := t.feedByID(follow.FeedID)
+		var unread int64
+		for _, post := range t.posts {
+			if post.FeedID == follow.FeedID && !t.isRead(userID, post.ID) {
+				unread++
+			}
+		}
+		var folderName sql.NullString
+		if folder, ok := t.folderByID(follow.FolderID); ok {
+			folderName = sql.NullString{String: folder.Name, Valid: true}
+		}
+		rows = append(rows, database.GetFeedFollowsForUserRow{
+			ID:          follow.ID,
+			CreatedAt:   follow.CreatedAt,
+			UpdatedAt:   follow.UpdatedAt,
+			UserID:      follow.UserID,
+			FeedID:      follow.FeedID,
+			FolderID:    follow.FolderID,
+			FeedName:    feed.Name,
+			UserName:    user.Name,
+			FolderName:  folderName,
+			UnreadCount: unread,
+		})
+	}
+	// Follows outside of any folder come first, as a NULL name sorts like
+	// an empty one.
+	slices.SortStableFunc(rows, func(a, b database.GetFeedFollowsForUserRow) int {
+		return cmp.Or(
+			cmp.Compare(a.FolderName.String, b.FolderName.String),
+			cmp.Compare(a.FeedName, b.FeedName),
+		)
+	})
+	return rows, nil
+}
+
+func (s *Store) UnfollowFeed(ctx context.Context, arg database.UnfollowFeedParams) error {
+	t, unlock := s.lock()
+	defer unlock()
+
+	t.follows = slices.DeleteFunc(t.follows, func(follow database.FeedFollow) bool {
+		feed, _ := t.feedByID(follow.FeedID)
+		return follow.UserID == arg.UserID && feed.Url == arg.Url
+	})
+	return nil
+}
+
+func (t *tables) isFollowing(userID, feedID uuid.UUID) bool {
+	for _, follow := range t.follows {
+		if follow.UserID == userID && follow.FeedID == feedID {
+			return true
+		}
+	}
+	return false
+}
+
+// inFolder reports whether the user's follow of the feed is in the folder,
+// which is true of every follow when folderID is null.
+func (t *tables) inFolder(userID, feedID uuid.UUID, folderID uuid.NullUUID) bool {
+	if !folderID.Valid {
+		return true
+	}
+	for _, follow := range t.follows {
+		if follow.UserID == userID && follow.FeedID == feedID {
+			return follow.FolderID == folderID
+		}
+	}
+	return false
+}
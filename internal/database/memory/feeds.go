@@ -0,0 +1,273 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	if _, ok := t.userByID(arg.UserID); !ok {
+		return database.Feed{}, errForeignKey("fk_users")
+	}
+	for _, feed := range t.feeds {
+		if feed.Url == arg.Url {
+			return database.Feed{}, errUnique("feeds_url_key")
+		}
+	}
+
+	feed := database.Feed{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		Name:      arg.Name,
+		Url:       arg.Url,
+		UserID:    arg.UserID,
+	}
+	t.feeds = append(t.feeds, feed)
+	return feed, nil
+}
+
+func (s *Store) GetAllFeeds(ctx context.Context, arg database.GetAllFeedsParams) ([]database.GetAllFeedsRow, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	var rows []database.GetAllFeedsRow
+	for _, feed := range t.feeds {
+		if arg.AfterCreatedAt.Valid && compareKeys(feed.CreatedAt, feed.ID, arg.AfterCreatedAt.Time, arg.AfterID.UUID) <= 0 {
+			continue
+		}
+		user, _ := t.userByID(feed.UserID)
+		rows = append(rows, database.GetAllFeedsRow{
+			ID:         feed.ID,
+			CreatedAt:  feed.CreatedAt,
+			Name:       feed.Name,
+			Url:        feed.Url,
+			AuthorName: user.Name,
+		})
+	}
+	slices.SortFunc(rows, func(a, b database.GetAllFeedsRow) int {
+		return compareKeys(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	return rows[:min(len(rows), int(arg.PageSize))], nil
+}
+
+func (s *Store) GetFeedByUrl(ctx context.Context, url string) (database.Feed, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	for _, feed := range t.feeds {
+		if feed.Url == url {
+			return feed, nil
+		}
+	}
+	return database.Feed{}, sql.ErrNoRows
+}
+
+func (s *Store) GetFeedsByName(ctx context.Context, name string) ([]database.Feed, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	var feeds []database.Feed
+	for _, feed := range t.feeds {
+		if feed.Name == name {
+			feeds = append(feeds, feed)
+		}
+	}
+	return feeds, nil
+}
+
+func (s *Store) MarkFeedFetched(ctx context.Context, arg database.MarkFeedFetchedParams) (database.Feed, error) {
+	now := s.now()
+	return s.updateFeed(func(feed database.Feed) bool {
+		return feed.ID == arg.ID && feed.LeaseOwner == sql.NullString{String: arg.LeaseOwner, Valid: true}
+	}, func(feed *database.Feed) {
+		feed.LastFetchedAt = sql.NullTime{Time: now, Valid: true}
+		feed.UpdatedAt = now
+		feed.PollIntervalSeconds = sql.NullInt32{Int32: arg.PollIntervalSeconds, Valid: true}
+		feed.NextFetchAt = sql.NullTime{Time: now.Add(seconds(arg.PollIntervalSeconds)), Valid: true}
+		feed.ConsecutiveFailures = 0
+		feed.LastError = sql.NullString{}
+		feed.LastSuccessAt = sql.NullTime{Time: now, Valid: true}
+		feed.DisabledAt = sql.NullTime{}
+		feed.LastHttpStatus = sql.NullInt32{Int32: arg.HttpStatus, Valid: true}
+		feed.LeaseOwner = sql.NullString{}
+		feed.LeaseExpiresAt = sql.NullTime{}
+	})
+}
+
+func (s *Store) MarkFeedFailed(ctx context.Context, arg database.MarkFeedFailedParams) (database.Feed, error) {
+	now := s.now()
+	return s.updateFeed(func(feed database.Feed) bool {
+		return feed.ID == arg.ID && feed.LeaseOwner == sql.NullString{String: arg.LeaseOwner, Valid: true}
+	}, func(feed *database.Feed) {
+		feed.LastFetchedAt = sql.NullTime{Time: now, Valid: true}
+		feed.UpdatedAt = now
+		feed.NextFetchAt = sql.NullTime{Time: now.Add(seconds(arg.BackoffSeconds)), Valid: true}
+		feed.ConsecutiveFailures++
+		feed.LastError = sql.NullString{String: arg.LastError, Valid: true}
+		feed.LastHttpStatus = arg.HttpStatus
+		feed.DisabledAt = sql.NullTime{Time: now, Valid: arg.Disable}
+		feed.LeaseOwner = sql.NullString{}
+		feed.LeaseExpiresAt = sql.NullTime{}
+	})
+}
+
+func (s *Store) ClaimNextFeedToFetch(ctx context.Context, arg database.ClaimNextFeedToFetchParams) (database.Feed, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	now := s.now()
+	next := -1
+	for i, feed := range t.feeds {
+		if !feed.DisabledAt.Valid && leaseFree(feed, now) && (!feed.NextFetchAt.Valid || !feed.NextFetchAt.Time.After(now)) {
+			if next == -1 || compareNullTime(feed.NextFetchAt, t.feeds[next].NextFetchAt, true) < 0 {
+				next = i
+			}
+		}
+	}
+	if next == -1 {
+		return database.Feed{}, sql.ErrNoRows
+	}
+
+	feed := &t.feeds[next]
+	feed.LeaseOwner = sql.NullString{String: arg.LeaseOwner, Valid: true}
+	feed.LeaseExpiresAt = sql.NullTime{Time: now.Add(seconds(arg.LeaseSeconds)), Valid: true}
+	return *feed, nil
+}
+
+func (s *Store) ClaimFeed(ctx context.Context, arg database.ClaimFeedParams) (database.Feed, error) {
+	now := s.now()
+	return s.updateFeed(func(feed database.Feed) bool { return feed.ID == arg.ID && leaseFree(feed, now) }, func(feed *database.Feed) {
+		feed.LeaseOwner = sql.NullString{String: arg.LeaseOwner, Valid: true}
+		feed.LeaseExpiresAt = sql.NullTime{Time: now.Add(seconds(arg.LeaseSeconds)), Valid: true}
+	})
+}
+
+func (s *Store) ReleaseFeedLease(ctx context.Context, arg database.ReleaseFeedLeaseParams) error {
+	_, err := s.updateFeed(func(feed database.Feed) bool {
+		return feed.ID == arg.ID && arg.LeaseOwner.Valid && feed.LeaseOwner == arg.LeaseOwner
+	}, func(feed *database.Feed) {
+		feed.LeaseOwner = sql.NullString{}
+		feed.LeaseExpiresAt = sql.NullTime{}
+	})
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+func (s *Store) UpdateFeedSchedule(ctx context.Context, arg database.UpdateFeedScheduleParams) (database.Feed, error) {
+	now := s.now()
+	return s.updateFeed(func(feed database.Feed) bool { return feed.ID == arg.ID && feed.UserID == arg.UserID }, func(feed *database.Feed) {
+		feed.FetchIntervalSeconds = arg.FetchIntervalSeconds
+		feed.AdaptivePolling = arg.AdaptivePolling
+		feed.PollIntervalSeconds = sql.NullInt32{}
+		feed.UpdatedAt = now
+	})
+}
+
+func (s *Store) GetUnhealthyFeeds(ctx context.Context) ([]database.Feed, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	var feeds []database.Feed
+	for _, feed := range t.feeds {
+		if feed.ConsecutiveFailures > 0 || feed.DisabledAt.Valid {
+			feeds = append(feeds, feed)
+		}
+	}
+	slices.SortStableFunc(feeds, func(a, b database.Feed) int {
+		return cmp.Or(
+			compareNullTime(a.DisabledAt, b.DisabledAt, false),
+			-cmp.Compare(a.ConsecutiveFailures, b.ConsecutiveFailures),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+	return feeds, nil
+}
+
+func (s *Store) EnableFeed(ctx context.Context, url string) (database.Feed, error) {
+	now := s.now()
+	return s.updateFeed(func(feed database.Feed) bool { return feed.Url == url }, func(feed *database.Feed) {
+		feed.ConsecutiveFailures = 0
+		feed.DisabledAt = sql.NullTime{}
+		feed.NextFetchAt = sql.NullTime{}
+		feed.UpdatedAt = now
+	})
+}
+
+func (s *Store) GetFetchQueueStats(ctx context.Context) (database.GetFetchQueueStatsRow, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	now := s.now()
+	var stats database.GetFetchQueueStatsRow
+	for _, feed := range t.feeds {
+		if feed.DisabledAt.Valid || (feed.NextFetchAt.Valid && feed.NextFetchAt.Time.After(now)) {
+			continue
+		}
+		stats.DueFeeds++
+		dueSince := feed.CreatedAt
+		if feed.NextFetchAt.Valid {
+			dueSince = feed.NextFetchAt.Time
+		}
+		stats.OldestDueSeconds = max(stats.OldestDueSeconds, now.Sub(dueSince).Seconds())
+	}
+	return stats, nil
+}
+
+// updateFeed applies update to the first feed matching match and returns
+// it, like an UPDATE ... RETURNING on a unique key.
+func (s *Store) updateFeed(match func(database.Feed) bool, update func(*database.Feed)) (database.Feed, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	for i := range t.feeds {
+		if match(t.feeds[i]) {
+			update(&t.feeds[i])
+			return t.feeds[i], nil
+		}
+	}
+	return database.Feed{}, sql.ErrNoRows
+}
+
+func (t *tables) feedByID(id uuid.UUID) (database.Feed, bool) {
+	for _, feed := range t.feeds {
+		if feed.ID == id {
+			return feed, true
+		}
+	}
+	return database.Feed{}, false
+}
+
+func leaseFree(feed database.Feed, now time.Time) bool {
+	return !feed.LeaseExpiresAt.Valid || feed.LeaseExpiresAt.Time.Before(now)
+}
+
+// compareNullTime orders a and b ascending, with NULL sorting first or last
+// like NULLS FIRST and NULLS LAST.
+func compareNullTime(a, b sql.NullTime, nullsFirst bool) int {
+	nullOrder := 1
+	if nullsFirst {
+		nullOrder = -1
+	}
+	switch {
+	case !a.Valid && !b.Valid:
+		return 0
+	case !a.Valid:
+		return nullOrder
+	case !b.Valid:
+		return -nullOrder
+	default:
+		return a.Time.Compare(b.Time)
+	}
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateFetchLog(ctx context.Context, arg database.CreateFetchLogParams) error {
+	t, unlock := s.lock()
+	defer unlock()
+
+	if _, ok := t.feedByID(arg.FeedID); !ok {
+		return errForeignKey("fk_feeds")
+	}
+	t.fetchLog = append(t.fetchLog, database.FetchLog(arg))
+	return nil
+}
+
+// fetchLogForFeed returns the entries of a feed, newest first.
+func (t *tables) fetchLogForFeed(feedID uuid.UUID) []database.FetchLog {
+	var entries []database.FetchLog
+	for _, entry := range t.fetchLog {
+		if entry.FeedID == feedID {
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b database.FetchLog) int {
+		return b.StartedAt.Compare(a.StartedAt)
+	})
+	return entries
+}
+
+func (s *Store) GetFetchLogForFeed(ctx context.Context, arg database.GetFetchLogForFeedParams) ([]database.FetchLog, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	entries := t.fetchLogForFeed(arg.FeedID)
+	return entries[:min(len(entries), int(arg.Limit))], nil
+}
+
+func (s *Store) GetFetchStatsForFeed(ctx context.Context, feedID uuid.UUID) (database.GetFetchStatsForFeedRow, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	var stats database.GetFetchStatsForFeedRow
+	var totalDuration int64
+	for _, entry := range t.fetchLogForFeed(feedID) {
+		stats.Fetches++
+		if entry.Error.Valid {
+			stats.Failures++
+		}
+		totalDuration += int64(entry.DurationMs)
+		stats.MaxDurationMs = max(stats.MaxDurationMs, entry.DurationMs)
+		stats.NewPosts += entry.NewPosts
+	}
+	if stats.Fetches > 0 {
+		stats.AvgDurationMs = float64(totalDuration) / float64(stats.Fetches)
+	}
+	return stats, nil
+}
+
+func (s *Store) GetLastFetchWithNewPosts(ctx context.Context, feedID uuid.UUID) (database.FetchLog, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	for _, entry := range t.fetchLogForFeed(feedID) {
+		if entry.NewPosts > 0 {
+			return entry, nil
+		}
+	}
+	return database.FetchLog{}, sql.ErrNoRows
+}
+
+func (s *Store) PruneFetchLog(ctx context.Context, startedAt time.Time) (int64, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	before := len(t.fetchLog)
+	t.fetchLog = slices.DeleteFunc(t.fetchLog, func(entry database.FetchLog) bool {
+		return entry.StartedAt.Before(startedAt)
+	})
+	return int64(before - len(t.fetchLog)), nil
+}
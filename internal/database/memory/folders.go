@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateFolder(ctx context.Context, arg database.CreateFolderParams) (database.Folder, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	if _, ok := t.userByID(arg.UserID); !ok {
+		return database.Folder{}, errForeignKey("fk_users")
+	}
+	if _, ok := t.folderByName(arg.UserID, arg.Name); ok {
+		return database.Folder{}, errUnique("folders_user_id_name_key")
+	}
+
+	folder := database.Folder(arg)
+	t.folders = append(t.folders, folder)
+	return folder, nil
+}
+
+func (s *Store) GetFoldersForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFoldersForUserRow, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	var rows []database.GetFoldersForUserRow
+	for _, folder := range t.folders {
+		if folder.UserID != userID {
+			continue
+		}
+		row := database.GetFoldersForUserRow{
+			ID:        folder.ID,
+			CreatedAt: folder.CreatedAt,
+			UpdatedAt: folder.UpdatedAt,
+			UserID:    folder.UserID,
+			Name:      folder.Name,
+		}
+		for _, follow := range t.follows {
+			if !follow.FolderID.Valid || follow.FolderID.UUID != folder.ID {
+				continue
+			}
+			row.FeedCount++
+			for _, post := range t.posts {
+				if post.FeedID == follow.FeedID && !t.isRead(userID, post.ID) {
+					row.UnreadCount++
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	slices.SortFunc(rows, func(a, b database.GetFoldersForUserRow) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return rows, nil
+}
+
+func (s *Store) GetFolderByName(ctx context.Context, arg database.GetFolderByNameParams) (database.Folder, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	folder, ok := t.folderByName(arg.UserID, arg.Name)
+	if !ok {
+		return database.Folder{}, sql.ErrNoRows
+	}
+	return folder, nil
+}
+
+func (s *Store) RenameFolder(ctx context.Context, arg database.RenameFolderParams) (database.Folder, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	i := slices.IndexFunc(t.folders, func(folder database.Folder) bool {
+		return folder.UserID == arg.UserID && folder.Name == arg.Name
+	})
+	if i == -1 {
+		return database.Folder{}, sql.ErrNoRows
+	}
+	if _, ok := t.folderByName(arg.UserID, arg.NewName); ok && arg.NewName != arg.Name {
+		return database.Folder{}, errUnique("folders_user_id_name_key")
+	}
+
+	folder := &t.folders[i]
+	folder.Name = arg.NewName
+	folder.UpdatedAt = s.now()
+	return *folder, nil
+}
+
+// DeleteFolder deletes the folder, moving its follows out of any folder.
+func (s *Store) DeleteFolder(ctx context.Context, arg database.DeleteFolderParams) (int64, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	folder, ok := t.folderByName(arg.UserID, arg.Name)
+	if !ok {
+		return 0, nil
+	}
+	for i := range t.follows {
+		if t.follows[i].FolderID.Valid && t.follows[i].FolderID.UUID == folder.ID {
+			t.follows[i].FolderID = uuid.NullUUID{}
+		}
+	}
+	t.folders = slices.DeleteFunc(t.folders, func(f database.Folder) bool {
+		return f.ID == folder.ID
+	})
+	return 1, nil
+}
+
+func (s *Store) MoveFeedFollow(ctx context.Context, arg database.MoveFeedFollowParams) (int64, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	if arg.FolderID.Valid {
+		if _, ok := t.folderByID(arg.FolderID); !ok {
+			return 0, errForeignKey("feed_follows_folder_id_fkey")
+		}
+	}
+
+	var moved int64
+	for i := range t.follows {
+		follow := &t.follows[i]
+		feed, _ := t.feedByID(follow.FeedID)
+		if follow.UserID != arg.UserID || feed.Url != arg.Url {
+			continue
+		}
+		follow.FolderID = arg.FolderID
+		follow.UpdatedAt = s.now()
+		moved++
+	}
+	return moved, nil
+}
+
+func (t *tables) folderByID(id uuid.NullUUID) (database.Folder, bool) {
+	if !id.Valid {
+		return database.Folder{}, false
+	}
+	for _, folder := range t.folders {
+		if folder.ID == id.UUID {
+			return folder, true
+		}
+	}
+	return database.Folder{}, false
+}
+
+func (t *tables) folderByName(userID uuid.UUID, name string) (database.Folder, bool) {
+	for _, folder := range t.folders {
+		if folder.UserID == userID && folder.Name == name {
+			return folder, true
+		}
+	}
+	return database.Folder{}, false
+}
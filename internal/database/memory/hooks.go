@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateHook(ctx context.Context, arg database.CreateHookParams) (database.Hook, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	if _, ok := t.userByID(arg.UserID); !ok {
+		return database.Hook{}, errForeignKey("fk_users")
+	}
+	if _, ok := t.feedByID(arg.FeedID.UUID); arg.FeedID.Valid && !ok {
+		return database.Hook{}, errForeignKey("fk_feeds")
+	}
+	if arg.Kind != "webhook" && arg.Kind != "command" {
+		return database.Hook{}, errCheck("hooks_kind_check")
+	}
+
+	hook := database.Hook{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		FeedID:    arg.FeedID,
+		Kind:      arg.Kind,
+		Target:    arg.Target,
+		Secret:    arg.Secret,
+	}
+	t.hooks = append(t.hooks, hook)
+	return hook, nil
+}
+
+func (s *Store) GetHooksForUser(ctx context.Context, userID uuid.UUID) ([]database.GetHooksForUserRow, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	var rows []database.GetHooksForUserRow
+	for _, hook := range t.hooks {
+		if hook.UserID != userID {
+			continue
+		}
+		row := database.GetHooksForUserRow{
+			ID:              hook.ID,
+			CreatedAt:       hook.CreatedAt,
+			UpdatedAt:       hook.UpdatedAt,
+			UserID:          hook.UserID,
+			FeedID:          hook.FeedID,
+			Kind:            hook.Kind,
+			Target:          hook.Target,
+			Secret:          hook.Secret,
+			LastDeliveredAt: hook.LastDeliveredAt,
+			LastError:       hook.LastError,
+		}
+		if feed, ok := t.feedByID(hook.FeedID.UUID); hook.FeedID.Valid && ok {
+			row.FeedUrl = sql.NullString{String: feed.Url, Valid: true}
+		}
+		rows = append(rows, row)
+	}
+	slices.SortStableFunc(rows, func(a, b database.GetHooksForUserRow) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return rows, nil
+}
+
+func (s *Store) GetHooksForFeed(ctx context.Context, feedID uuid.UUID) ([]database.GetHooksForFeedRow, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	var rows []database.GetHooksForFeedRow
+	for _, hook := range t.hooks {
+		if !t.isFollowing(hook.UserID, feedID) || (hook.FeedID.Valid && hook.FeedID.UUID != feedID) {
+			continue
+		}
+		user, _ := t.userByID(hook.UserID)
+		rows = append(rows, database.GetHooksForFeedRow{
+			ID:              hook.ID,
+			CreatedAt:       hook.CreatedAt,
+			UpdatedAt:       hook.UpdatedAt,
+			UserID:          hook.UserID,
+			FeedID:          hook.FeedID,
+			Kind:            hook.Kind,
+			Target:          hook.Target,
+			Secret:          hook.Secret,
+			LastDeliveredAt: hook.LastDeliveredAt,
+			LastError:       hook.LastError,
+			UserName:        user.Name,
+		})
+	}
+	return rows, nil
+}
+
+func (s *Store) DeleteHook(ctx context.Context, arg database.DeleteHookParams) (int64, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	before := len(t.hooks)
+	t.hooks = slices.DeleteFunc(t.hooks, func(hook database.Hook) bool {
+		return hook.ID == arg.ID && hook.UserID == arg.UserID
+	})
+	return int64(before - len(t.hooks)), nil
+}
+
+func (s *Store) MarkHookDelivered(ctx context.Context, id uuid.UUID) error {
+	now := s.now()
+	s.updateHook(id, func(hook *database.Hook) {
+		hook.LastDeliveredAt = sql.NullTime{Time: now, Valid: true}
+		hook.LastError = sql.NullString{}
+		hook.UpdatedAt = now
+	})
+	return nil
+}
+
+func (s *Store) MarkHookFailed(ctx context.Context, arg database.MarkHookFailedParams) error {
+	now := s.now()
+	s.updateHook(arg.ID, func(hook *database.Hook) {
+		hook.LastError = arg.LastError
+		hook.UpdatedAt = now
+	})
+	return nil
+}
+
+func (s *Store) updateHook(id uuid.UUID, update func(*database.Hook)) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	for i := range t.hooks {
+		if t.hooks[i].ID == id {
+			update(&t.hooks[i])
+		}
+	}
+}
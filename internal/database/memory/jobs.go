@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/Pizzu/gator/internal/database"
+)
+
+func (s *Store) EnsureJob(ctx context.Context, arg database.EnsureJobParams) error {
+	t, unlock := s.lock()
+	defer unlock()
+
+	for _, job := range t.jobs {
+		if job.Name == arg.Name {
+			return nil
+		}
+	}
+	t.jobs = append(t.jobs, database.Job{
+		Name:      arg.Name,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.CreatedAt,
+		Schedule:  arg.Schedule,
+	})
+	return nil
+}
+
+func (s *Store) GetJobs(ctx context.Context) ([]database.Job, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	jobs := slices.Clone(t.jobs)
+	slices.SortFunc(jobs, func(a, b database.Job) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return jobs, nil
+}
+
+func (s *Store) GetJob(ctx context.Context, name string) (database.Job, error) {
+	return s.updateJob(func(job database.Job) bool { return job.Name == name }, func(*database.Job) {})
+}
+
+func (s *Store) UpdateJobSchedule(ctx context.Context, arg database.UpdateJobScheduleParams) (database.Job, error) {
+	now := s.now()
+	return s.updateJob(func(job database.Job) bool { return job.Name == arg.Name }, func(job *database.Job) {
+		job.Schedule = arg.Schedule
+		job.UpdatedAt = now
+	})
+}
+
+func (s *Store) StartJobRun(ctx context.Context, arg database.StartJobRunParams) (database.Job, error) {
+	now := s.now()
+	return s.updateJob(func(job database.Job) bool {
+		return job.Name == arg.Name && (!job.RunningUntil.Valid || job.RunningUntil.Time.Before(now))
+	}, func(job *database.Job) {
+		job.RunningOwner = arg.RunningOwner
+		job.RunningUntil = sql.NullTime{Time: now.Add(seconds(arg.TimeoutSeconds)), Valid: true}
+		job.LastStartedAt = sql.NullTime{Time: now, Valid: true}
+	})
+}
+
+func (s *Store) FinishJobRun(ctx context.Context, arg database.FinishJobRunParams) error {
+	now := s.now()
+	_, err := s.updateJob(func(job database.Job) bool {
+		return job.Name == arg.Name && arg.RunningOwner.Valid && job.RunningOwner == arg.RunningOwner
+	}, func(job *database.Job) {
+		job.RunningOwner = sql.NullString{}
+		job.RunningUntil = sql.NullTime{}
+		job.LastFinishedAt = sql.NullTime{Time: now, Valid: true}
+		job.LastDurationMs = arg.DurationMs
+		job.LastStatus = arg.Status
+		job.LastError = arg.Error
+		job.LastRunBy = arg.RunningOwner
+	})
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+// updateJob applies update to the job matching match and returns it.
+func (s *Store) updateJob(match func(database.Job) bool, update func(*database.Job)) (database.Job, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	for i := range t.jobs {
+		if match(t.jobs[i]) {
+			update(&t.jobs[i])
+			return t.jobs[i], nil
+		}
+	}
+	return database.Job{}, sql.ErrNoRows
+}
@@ -0,0 +1,27 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// errNoAdvisoryLocks is returned by the leader election queries, which need
+// Postgres sessions.
+var errNoAdvisoryLocks = errors.New("advisory locks are not supported in memory")
+
+func (s *Store) TryAdvisoryLock(ctx context.Context, key int64) (bool, error) {
+	return false, errNoAdvisoryLocks
+}
+
+func (s *Store) AdvisoryUnlock(ctx context.Context, key int64) (bool, error) {
+	return false, errNoAdvisoryLocks
+}
+
+func (s *Store) SetApplicationName(ctx context.Context, name string) error {
+	return errNoAdvisoryLocks
+}
+
+func (s *Store) GetAdvisoryLockHolder(ctx context.Context, key int64) (sql.NullString, error) {
+	return sql.NullString{}, errNoAdvisoryLocks
+}
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) SetPostsRead(ctx context.Context, arg database.SetPostsReadParams) (int64, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	now := s.now()
+	readAt := sql.NullTime{Time: now, Valid: arg.Read}
+
+	var changed int64
+	for _, post := range t.posts {
+		published := postTime(post.PublishedAt, post.CreatedAt)
+		switch {
+		case !t.isFollowing(arg.UserID, post.FeedID),
+			arg.PostID.Valid && post.ID != arg.PostID.UUID,
+			arg.FeedID.Valid && post.FeedID != arg.FeedID.UUID,
+			!t.inFolder(arg.UserID, post.FeedID, arg.FolderID),
+			arg.Before.Valid && !published.Before(arg.Before.Time):
+			continue
+		}
+
+		i := slices.IndexFunc(t.states, func(state database.PostState) bool {
+			return state.UserID == arg.UserID && state.PostID == post.ID
+		})
+		if i == -1 {
+			// Posts without a state are unread already.
+			if !arg.Read {
+				continue
+			}
+			t.states = append(t.states, database.PostState{
+				UserID:    arg.UserID,
+				PostID:    post.ID,
+				CreatedAt: now,
+				UpdatedAt: now,
+				Read:      arg.Read,
+				ReadAt:    readAt,
+			})
+			changed++
+			continue
+		}
+
+		if state := &t.states[i]; state.Read != arg.Read {
+			state.Read = arg.Read
+			state.ReadAt = readAt
+			state.UpdatedAt = now
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+func (t *tables) isRead(userID, postID uuid.UUID) bool {
+	for _, state := range t.states {
+		if state.UserID == userID && state.PostID == postID {
+			return state.Read
+		}
+	}
+	return false
+}
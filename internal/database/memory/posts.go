@@ -0,0 +1,321 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// UpsertPosts inserts the posts, or updates the existing post with the same
+// guid when its content hash changed. Pruned posts are skipped. Like the SQL
+// query, it returns only the inserted and updated posts.
+func (s *Store) UpsertPosts(ctx context.Context, arg database.UpsertPostsParams) ([]database.Post, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	if _, ok := t.feedByID(arg.FeedID); !ok {
+		return nil, errForeignKey("fk_feeds")
+	}
+
+	var saved []database.Post
+	for i, id := range arg.Ids {
+		post := database.Post{
+			ID:          id,
+			CreatedAt:   arg.CreatedAt,
+			UpdatedAt:   arg.CreatedAt,
+			Title:       arg.Titles[i],
+			Url:         arg.Urls[i],
+			Description: sql.NullString{String: arg.Descriptions[i], Valid: true},
+			FeedID:      arg.FeedID,
+			Guid:        sql.NullString{String: arg.Guids[i], Valid: true},
+			ContentHash: sql.NullString{String: arg.ContentHashes[i], Valid: true},
+		}
+		if arg.PublishedAts[i] != "" {
+			publishedAt, err := time.Parse(time.RFC3339, arg.PublishedAts[i])
+			if err != nil {
+				return nil, err
+			}
+			post.PublishedAt = sql.NullTime{Time: publishedAt, Valid: true}
+		}
+
+		if t.isPruned(post.FeedID, post.Guid.String) {
+			continue
+		}
+
+		existing := slices.IndexFunc(t.posts, func(p database.Post) bool {
+			return p.FeedID == post.FeedID && p.Guid == post.Guid
+		})
+		if existing == -1 {
+			t.posts = append(t.posts, post)
+			saved = append(saved, post)
+			continue
+		}
+
+		old := &t.posts[existing]
+		if old.ContentHash == post.ContentHash {
+			continue
+		}
+		old.Title = post.Title
+		old.Url = post.Url
+		old.Description = post.Description
+		old.PublishedAt = post.PublishedAt
+		old.ContentHash = post.ContentHash
+		old.UpdatedAt = post.UpdatedAt
+		saved = append(saved, *old)
+	}
+	return saved, nil
+}
+
+func (s *Store) GetLegacyPostUrls(ctx context.Context, feedID uuid.UUID) ([]string, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	var urls []string
+	for _, post := range t.posts {
+		if post.FeedID == feedID && !post.Guid.Valid {
+			urls = append(urls, post.Url)
+		}
+	}
+	return urls, nil
+}
+
+func (s *Store) AdoptLegacyPost(ctx context.Context, arg database.AdoptLegacyPostParams) error {
+	t, unlock := s.lock()
+	defer unlock()
+
+	for i, post := range t.posts {
+		if post.FeedID == arg.FeedID && post.Url == arg.Url && !post.Guid.Valid {
+			t.posts[i].Guid = arg.Guid
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	return t.postsForUser(arg, false), nil
+}
+
+func (s *Store) GetNewerPostsForUser(ctx context.Context, arg database.GetNewerPostsForUserParams) ([]database.GetNewerPostsForUserRow, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	var rows []database.GetNewerPostsForUserRow
+	for _, row := range t.postsForUser(database.GetPostsForUserParams(arg), true) {
+		rows = append(rows, database.GetNewerPostsForUserRow(row))
+	}
+	return rows, nil
+}
+
+// postsForUser returns a page of the user's posts, either newest first and
+// older than the cursor, or oldest first and newer than the cursor.
+func (t *tables) postsForUser(arg database.GetPostsForUserParams, newer bool) []database.GetPostsForUserRow {
+	var rows []database.GetPostsForUserRow
+	for _, post := range t.posts {
+		published := postTime(post.PublishedAt, post.CreatedAt)
+		switch {
+		case !t.isFollowing(arg.UserID, post.FeedID),
+			!t.inFolder(arg.UserID, post.FeedID, arg.FolderID),
+			arg.Tag.Valid && !t.isTagged(arg.UserID, post.ID, arg.Tag.String),
+			arg.Since.Valid && published.Before(arg.Since.Time),
+			arg.Until.Valid && !published.Before(arg.Until.Time):
+			continue
+		}
+		if arg.CursorPublishedAt.Valid {
+			order := compareKeys(published, post.ID, arg.CursorPublishedAt.Time, arg.CursorID.UUID)
+			if (newer && order <= 0) || (!newer && order >= 0) {
+				continue
+			}
+		}
+		read := t.isRead(arg.UserID, post.ID)
+		if arg.UnreadOnly && read {
+			continue
+		}
+		feed, _ := t.feedByID(post.FeedID)
+		rows = append(rows, postRow(post, feed, read))
+	}
+	slices.SortFunc(rows, func(a, b database.GetPostsForUserRow) int {
+		order := compareKeys(postTime(a.PublishedAt, a.CreatedAt), a.ID, postTime(b.PublishedAt, b.CreatedAt), b.ID)
+		if newer {
+			return order
+		}
+		return -order
+	})
+	return rows[:min(len(rows), int(arg.PageSize))]
+}
+
+// postTime returns when the post was published, or added when it has no
+// publication date, which is how the queries sort and filter posts.
+func postTime(publishedAt sql.NullTime, createdAt time.Time) time.Time {
+	if publishedAt.Valid {
+		return publishedAt.Time
+	}
+	return createdAt
+}
+
+// compareKeys orders rows by a (time, id) keyset.
+func compareKeys(aTime time.Time, aID uuid.UUID, bTime time.Time, bID uuid.UUID) int {
+	if order := aTime.Compare(bTime); order != 0 {
+		return order
+	}
+	return bytes.Compare(aID[:], bID[:])
+}
+
+func postRow(post database.Post, feed database.Feed, read bool) database.GetPostsForUserRow {
+	return database.GetPostsForUserRow{
+		ID:          post.ID,
+		CreatedAt:   post.CreatedAt,
+		UpdatedAt:   post.UpdatedAt,
+		Title:       post.Title,
+		Url:         post.Url,
+		Description: post.Description,
+		PublishedAt: post.PublishedAt,
+		FeedID:      post.FeedID,
+		Guid:        post.Guid,
+		ContentHash: post.ContentHash,
+		FeedName:    feed.Name,
+		Read:        read,
+	}
+}
+
+// SavePrunedPostKeys remembers the keys of the posts PrunePosts would
+// delete.
+func (s *Store) SavePrunedPostKeys(ctx context.Context, before time.Time) error {
+	t, unlock := s.lock()
+	defer unlock()
+
+	now := s.now()
+	for _, post := range t.posts {
+		published := postTime(post.PublishedAt, post.CreatedAt)
+		if !post.Guid.Valid || !published.Before(before) || t.isKept(post.ID) || t.isPruned(post.FeedID, post.Guid.String) {
+			continue
+		}
+		t.pruned = append(t.pruned, database.PrunedPost{
+			FeedID:   post.FeedID,
+			Guid:     post.Guid.String,
+			PrunedAt: now,
+		})
+	}
+	return nil
+}
+
+func (t *tables) isPruned(feedID uuid.UUID, guid string) bool {
+	return slices.ContainsFunc(t.pruned, func(pruned database.PrunedPost) bool {
+		return pruned.FeedID == feedID && pruned.Guid == guid
+	})
+}
+
+// PrunePosts deletes old posts that nobody starred, saved or tagged, along
+// with their read states.
+func (s *Store) PrunePosts(ctx context.Context, before time.Time) (int64, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	kept := t.posts[:0]
+	var pruned []uuid.UUID
+	for _, post := range t.posts {
+		published := postTime(post.PublishedAt, post.CreatedAt)
+		if !published.Before(before) || t.isKept(post.ID) {
+			kept = append(kept, post)
+			continue
+		}
+		pruned = append(pruned, post.ID)
+	}
+	t.posts = kept
+	t.states = slices.DeleteFunc(t.states, func(state database.PostState) bool {
+		return slices.Contains(pruned, state.PostID)
+	})
+	return int64(len(pruned)), nil
+}
+
+func (t *tables) postByID(id uuid.UUID) (database.Post, bool) {
+	i := slices.IndexFunc(t.posts, func(post database.Post) bool { return post.ID == id })
+	if i == -1 {
+		return database.Post{}, false
+	}
+	return t.posts[i], true
+}
+
+// SearchPosts matches posts containing every word of the query, ignoring
+// case, and ranks them by how often the words occur. Words starting with -
+// exclude posts. It doesn't stem words like the databases do.
+func (s *Store) SearchPosts(ctx context.Context, arg database.SearchPostsParams) ([]database.SearchPostsRow, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	var include, exclude []string
+	for _, word := range strings.Fields(strings.ToLower(strings.ReplaceAll(arg.Query, `"`, ""))) {
+		if excluded, ok := strings.CutPrefix(word, "-"); ok {
+			exclude = append(exclude, excluded)
+		} else if word != "or" {
+			include = append(include, word)
+		}
+	}
+
+	var rows []database.SearchPostsRow
+	for _, post := range t.posts {
+		published := postTime(post.PublishedAt, post.CreatedAt)
+		switch {
+		case !t.isFollowing(arg.UserID, post.FeedID),
+			arg.FeedID.Valid && post.FeedID != arg.FeedID.UUID,
+			arg.Since.Valid && published.Before(arg.Since.Time),
+			arg.Until.Valid && !published.Before(arg.Until.Time):
+			continue
+		}
+
+		text := post.Title + " " + post.Description.String
+		lower := strings.ToLower(text)
+		rank := 0
+		for _, word := range include {
+			n := strings.Count(lower, word)
+			if n == 0 {
+				rank = 0
+				break
+			}
+			rank += n
+		}
+		if rank == 0 || slices.ContainsFunc(exclude, func(word string) bool { return strings.Contains(lower, word) }) {
+			continue
+		}
+
+		for _, word := range include {
+			if i := strings.Index(strings.ToLower(text), word); i != -1 {
+				text = text[:i] + "**" + text[i:i+len(word)] + "**" + text[i+len(word):]
+			}
+		}
+
+		feed, _ := t.feedByID(post.FeedID)
+		row := postRow(post, feed, false)
+		rows = append(rows, database.SearchPostsRow{
+			ID:          row.ID,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+			Title:       row.Title,
+			Url:         row.Url,
+			Description: row.Description,
+			PublishedAt: row.PublishedAt,
+			FeedID:      row.FeedID,
+			Guid:        row.Guid,
+			ContentHash: row.ContentHash,
+			FeedName:    row.FeedName,
+			Rank:        float64(rank),
+			Snippet:     text,
+		})
+	}
+	slices.SortStableFunc(rows, func(a, b database.SearchPostsRow) int {
+		if a.Rank != b.Rank {
+			return cmp.Compare(b.Rank, a.Rank)
+		}
+		return -compareNullTime(a.PublishedAt, b.PublishedAt, false)
+	})
+	return rows[:min(len(rows), int(arg.PageSize))], nil
+}
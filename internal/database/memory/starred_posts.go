@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) StarPost(ctx context.Context, arg database.StarPostParams) (int64, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	post, ok := t.postByID(arg.PostID)
+	if !ok || !t.isFollowing(arg.UserID, post.FeedID) {
+		return 0, nil
+	}
+	if t.isStarred(arg.UserID, arg.PostID) {
+		return 1, nil
+	}
+	t.starred = append(t.starred, database.StarredPost{
+		UserID:    arg.UserID,
+		PostID:    arg.PostID,
+		CreatedAt: arg.CreatedAt,
+	})
+	return 1, nil
+}
+
+func (s *Store) UnstarPost(ctx context.Context, arg database.UnstarPostParams) (int64, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	n := len(t.starred)
+	t.starred = slices.DeleteFunc(t.starred, func(star database.StarredPost) bool {
+		return star.UserID == arg.UserID && star.PostID == arg.PostID
+	})
+	return int64(n - len(t.starred)), nil
+}
+
+func (s *Store) GetStarredPostsForUser(ctx context.Context, arg database.GetStarredPostsForUserParams) ([]database.GetStarredPostsForUserRow, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	var rows []database.GetStarredPostsForUserRow
+	for _, star := range t.starred {
+		if star.UserID != arg.UserID {
+			continue
+		}
+		post, _ := t.postByID(star.PostID)
+		feed, _ := t.feedByID(post.FeedID)
+		row := postRow(post, feed, false)
+		rows = append(rows, database.GetStarredPostsForUserRow{
+			ID:          row.ID,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+			Title:       row.Title,
+			Url:         row.Url,
+			Description: row.Description,
+			PublishedAt: row.PublishedAt,
+			FeedID:      row.FeedID,
+			Guid:        row.Guid,
+			ContentHash: row.ContentHash,
+			FeedName:    row.FeedName,
+			StarredAt:   star.CreatedAt,
+		})
+	}
+	slices.SortStableFunc(rows, func(a, b database.GetStarredPostsForUserRow) int {
+		return b.StarredAt.Compare(a.StarredAt)
+	})
+	return rows[:min(len(rows), int(arg.Limit))], nil
+}
+
+func (s *Store) SavePost(ctx context.Context, arg database.SavePostParams) (int64, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	post, ok := t.postByID(arg.PostID)
+	if !ok || !t.isFollowing(arg.UserID, post.FeedID) {
+		return 0, nil
+	}
+	if t.isSaved(arg.UserID, arg.PostID) {
+		return 1, nil
+	}
+	t.saved = append(t.saved, database.SavedPost{
+		UserID:    arg.UserID,
+		PostID:    arg.PostID,
+		CreatedAt: arg.CreatedAt,
+	})
+	return 1, nil
+}
+
+func (s *Store) UnsavePost(ctx context.Context, arg database.UnsavePostParams) (int64, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	n := len(t.saved)
+	t.saved = slices.DeleteFunc(t.saved, func(saved database.SavedPost) bool {
+		return saved.UserID == arg.UserID && saved.PostID == arg.PostID
+	})
+	return int64(n - len(t.saved)), nil
+}
+
+func (s *Store) GetSavedPostsForUser(ctx context.Context, arg database.GetSavedPostsForUserParams) ([]database.GetSavedPostsForUserRow, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	var rows []database.GetSavedPostsForUserRow
+	for _, saved := range t.saved {
+		if saved.UserID != arg.UserID {
+			continue
+		}
+		post, _ := t.postByID(saved.PostID)
+		feed, _ := t.feedByID(post.FeedID)
+		row := postRow(post, feed, false)
+		rows = append(rows, database.GetSavedPostsForUserRow{
+			ID:          row.ID,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+			Title:       row.Title,
+			Url:         row.Url,
+			Description: row.Description,
+			PublishedAt: row.PublishedAt,
+			FeedID:      row.FeedID,
+			Guid:        row.Guid,
+			ContentHash: row.ContentHash,
+			FeedName:    row.FeedName,
+			SavedAt:     saved.CreatedAt,
+		})
+	}
+	slices.SortStableFunc(rows, func(a, b database.GetSavedPostsForUserRow) int {
+		return a.SavedAt.Compare(b.SavedAt)
+	})
+	return rows[:min(len(rows), int(arg.Limit))], nil
+}
+
+func (t *tables) isStarred(userID, postID uuid.UUID) bool {
+	return slices.ContainsFunc(t.starred, func(star database.StarredPost) bool {
+		return star.UserID == userID && star.PostID == postID
+	})
+}
+
+func (t *tables) isSaved(userID, postID uuid.UUID) bool {
+	return slices.ContainsFunc(t.saved, func(saved database.SavedPost) bool {
+		return saved.UserID == userID && saved.PostID == postID
+	})
+}
+
+// isKept reports whether any user starred, saved or tagged the post, which
+// exempts it from pruning.
+func (t *tables) isKept(postID uuid.UUID) bool {
+	return slices.ContainsFunc(t.starred, func(star database.StarredPost) bool { return star.PostID == postID }) ||
+		slices.ContainsFunc(t.saved, func(saved database.SavedPost) bool { return saved.PostID == postID }) ||
+		slices.ContainsFunc(t.postTags, func(tagged database.PostTag) bool { return tagged.PostID == postID })
+}
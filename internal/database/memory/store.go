@@ -0,0 +1,124 @@
+// Package memory implements database.Store in memory, for tests. It follows
+// the semantics of the SQL queries closely enough for the commands to
+// behave as they do against a real database, including unique and foreign
+// key constraints and cascading deletes.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+)
+
+// tables holds the rows of every table, in insertion order.
+type tables struct {
+	users    []database.User
+	feeds    []database.Feed
+	follows  []database.FeedFollow
+	folders  []database.Folder
+	posts    []database.Post
+	pruned   []database.PrunedPost
+	states   []database.PostState
+	starred  []database.StarredPost
+	saved    []database.SavedPost
+	tags     []database.Tag
+	postTags []database.PostTag
+	fetchLog []database.FetchLog
+	hooks    []database.Hook
+	jobs     []database.Job
+}
+
+func (t *tables) clone() *tables {
+	return &tables{
+		users:    slices.Clone(t.users),
+		feeds:    slices.Clone(t.feeds),
+		follows:  slices.Clone(t.follows),
+		folders:  slices.Clone(t.folders),
+		posts:    slices.Clone(t.posts),
+		pruned:   slices.Clone(t.pruned),
+		states:   slices.Clone(t.states),
+		starred:  slices.Clone(t.starred),
+		saved:    slices.Clone(t.saved),
+		tags:     slices.Clone(t.tags),
+		postTags: slices.Clone(t.postTags),
+		fetchLog: slices.Clone(t.fetchLog),
+		hooks:    slices.Clone(t.hooks),
+		jobs:     slices.Clone(t.jobs),
+	}
+}
+
+// Store is an in-memory database.Store. The zero value is not usable, create
+// one with NewStore.
+type Store struct {
+	mu   *sync.Mutex
+	data *tables
+	// Now returns the current time, used where the queries call NOW().
+	Now func() time.Time
+	// inTx is set on the store passed to InTx callbacks.
+	inTx bool
+}
+
+var _ database.Store = (*Store)(nil)
+
+// NewStore returns an empty Store.
+func NewStore() *Store {
+	return &Store{
+		mu:   &sync.Mutex{},
+		data: &tables{},
+		Now:  func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// InTx runs fn on a copy of the data, which replaces the data only if fn
+// returns nil. Transactions are serialized with all other queries.
+func (s *Store) InTx(ctx context.Context, fn func(q database.Querier) error) error {
+	if s.inTx {
+		return errors.New("transaction already in progress")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &Store{mu: &sync.Mutex{}, data: s.data.clone(), Now: s.Now, inTx: true}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.data = tx.data
+	return nil
+}
+
+// lock locks the store and returns its tables.
+func (s *Store) lock() (*tables, func()) {
+	s.mu.Lock()
+	return s.data, s.mu.Unlock
+}
+
+func (s *Store) now() time.Time {
+	return s.Now()
+}
+
+func errUnique(constraint string) error {
+	return fmt.Errorf("duplicate key value violates unique constraint %q", constraint)
+}
+
+func errForeignKey(constraint string) error {
+	return fmt.Errorf("insert or update violates foreign key constraint %q", constraint)
+}
+
+func errCheck(constraint string) error {
+	return fmt.Errorf("new row violates check constraint %q", constraint)
+}
+
+// seconds returns n seconds as a duration.
+func seconds(n int32) time.Duration {
+	return time.Duration(n) * time.Second
+}
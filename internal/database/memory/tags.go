@@ -0,0 +1,161 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) UpsertTag(ctx context.Context, arg database.UpsertTagParams) (database.Tag, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	if _, ok := t.userByID(arg.UserID); !ok {
+		return database.Tag{}, errForeignKey("fk_users")
+	}
+	for _, tag := range t.tags {
+		if tag.UserID == arg.UserID && tag.Name == arg.Name {
+			return tag, nil
+		}
+	}
+
+	tag := database.Tag(arg)
+	t.tags = append(t.tags, tag)
+	return tag, nil
+}
+
+func (s *Store) TagPost(ctx context.Context, arg database.TagPostParams) (int64, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	tag, ok := t.tagByID(arg.TagID)
+	if !ok {
+		return 0, nil
+	}
+	post, ok := t.postByID(arg.PostID)
+	if !ok || !t.isFollowing(tag.UserID, post.FeedID) {
+		return 0, nil
+	}
+	if slices.ContainsFunc(t.postTags, func(tagged database.PostTag) bool {
+		return tagged.TagID == arg.TagID && tagged.PostID == arg.PostID
+	}) {
+		return 1, nil
+	}
+	t.postTags = append(t.postTags, database.PostTag{
+		TagID:     arg.TagID,
+		PostID:    arg.PostID,
+		CreatedAt: arg.CreatedAt,
+	})
+	return 1, nil
+}
+
+func (s *Store) UntagPost(ctx context.Context, arg database.UntagPostParams) (int64, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	n := len(t.postTags)
+	t.postTags = slices.DeleteFunc(t.postTags, func(tagged database.PostTag) bool {
+		tag, _ := t.tagByID(tagged.TagID)
+		return tagged.PostID == arg.PostID && tag.UserID == arg.UserID && tag.Name == arg.Name
+	})
+	return int64(n - len(t.postTags)), nil
+}
+
+func (s *Store) DeleteUnusedTags(ctx context.Context, userID uuid.UUID) error {
+	t, unlock := s.lock()
+	defer unlock()
+
+	t.tags = slices.DeleteFunc(t.tags, func(tag database.Tag) bool {
+		return tag.UserID == userID && t.tagCount(tag.ID) == 0
+	})
+	return nil
+}
+
+func (s *Store) GetTagsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetTagsForUserRow, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	var rows []database.GetTagsForUserRow
+	for _, tag := range t.tags {
+		if tag.UserID != userID {
+			continue
+		}
+		rows = append(rows, database.GetTagsForUserRow{
+			ID:        tag.ID,
+			CreatedAt: tag.CreatedAt,
+			UserID:    tag.UserID,
+			Name:      tag.Name,
+			PostCount: t.tagCount(tag.ID),
+		})
+	}
+	slices.SortFunc(rows, func(a, b database.GetTagsForUserRow) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return rows, nil
+}
+
+func (s *Store) GetTaggedPostsForUser(ctx context.Context, arg database.GetTaggedPostsForUserParams) ([]database.GetTaggedPostsForUserRow, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	var rows []database.GetTaggedPostsForUserRow
+	for _, tagged := range t.postTags {
+		tag, _ := t.tagByID(tagged.TagID)
+		if tag.UserID != arg.UserID || (arg.Tag.Valid && tag.Name != arg.Tag.String) {
+			continue
+		}
+		post, _ := t.postByID(tagged.PostID)
+		feed, _ := t.feedByID(post.FeedID)
+		rows = append(rows, database.GetTaggedPostsForUserRow{
+			Tag:         tag.Name,
+			TaggedAt:    tagged.CreatedAt,
+			ID:          post.ID,
+			CreatedAt:   post.CreatedAt,
+			UpdatedAt:   post.UpdatedAt,
+			Title:       post.Title,
+			Url:         post.Url,
+			Description: post.Description,
+			PublishedAt: post.PublishedAt,
+			FeedID:      post.FeedID,
+			Guid:        post.Guid,
+			ContentHash: post.ContentHash,
+			FeedName:    feed.Name,
+		})
+	}
+	slices.SortFunc(rows, func(a, b database.GetTaggedPostsForUserRow) int {
+		return cmp.Or(
+			cmp.Compare(a.Tag, b.Tag),
+			compareKeys(a.TaggedAt, a.ID, b.TaggedAt, b.ID),
+		)
+	})
+	return rows, nil
+}
+
+func (t *tables) tagByID(id uuid.UUID) (database.Tag, bool) {
+	i := slices.IndexFunc(t.tags, func(tag database.Tag) bool { return tag.ID == id })
+	if i == -1 {
+		return database.Tag{}, false
+	}
+	return t.tags[i], true
+}
+
+func (t *tables) tagCount(tagID uuid.UUID) int64 {
+	var n int64
+	for _, tagged := range t.postTags {
+		if tagged.TagID == tagID {
+			n++
+		}
+	}
+	return n
+}
+
+// isTagged reports whether the user tagged the post with the named tag.
+func (t *tables) isTagged(userID, postID uuid.UUID, name string) bool {
+	return slices.ContainsFunc(t.postTags, func(tagged database.PostTag) bool {
+		tag, _ := t.tagByID(tagged.TagID)
+		return tagged.PostID == postID && tag.UserID == userID && tag.Name == name
+	})
+}
@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	for _, user := range t.users {
+		if user.Name == arg.Name {
+			return database.User{}, errUnique("users_name_key")
+		}
+	}
+
+	user := database.User(arg)
+	t.users = append(t.users, user)
+	return user, nil
+}
+
+func (s *Store) GetUserByName(ctx context.Context, name string) (database.User, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	for _, user := range t.users {
+		if user.Name == name {
+			return user, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *Store) GetUsers(ctx context.Context, arg database.GetUsersParams) ([]database.User, error) {
+	t, unlock := s.lock()
+	defer unlock()
+
+	var users []database.User
+	for _, user := range t.users {
+		if !arg.AfterCreatedAt.Valid || compareKeys(user.CreatedAt, user.ID, arg.AfterCreatedAt.Time, arg.AfterID.UUID) > 0 {
+			users = append(users, user)
+		}
+	}
+	slices.SortFunc(users, func(a, b database.User) int {
+		return compareKeys(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	return users[:min(len(users), int(arg.PageSize))], nil
+}
+
+// DeleteAllUsers deletes every user along with the rows that cascade from
+// them.
+func (s *Store) DeleteAllUsers(ctx context.Context) error {
+	t, unlock := s.lock()
+	defer unlock()
+
+	t.users = nil
+	t.feeds = nil
+	t.follows = nil
+	t.folders = nil
+	t.posts = nil
+	t.pruned = nil
+	t.states = nil
+	t.starred = nil
+	t.saved = nil
+	t.tags = nil
+	t.postTags = nil
+	t.fetchLog = nil
+	t.hooks = nil
+	return nil
+}
+
+func (t *tables) userByID(id uuid.UUID) (database.User, bool) {
+	for _, user := range t.users {
+		if user.ID == id {
+			return user, true
+		}
+	}
+	return database.User{}, false
+}
@@ -12,13 +12,24 @@ import (
 )
 
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Name                 string
+	Url                  string
+	UserID               uuid.UUID
+	LastFetchedAt        sql.NullTime
+	LeaseOwner           sql.NullString
+	LeaseExpiresAt       sql.NullTime
+	NextFetchAt          sql.NullTime
+	FetchIntervalSeconds sql.NullInt32
+	PollIntervalSeconds  sql.NullInt32
+	AdaptivePolling      bool
+	ConsecutiveFailures  int32
+	LastError            sql.NullString
+	LastSuccessAt        sql.NullTime
+	LastHttpStatus       sql.NullInt32
+	DisabledAt           sql.NullTime
 }
 
 type FeedFollow struct {
@@ -27,6 +38,55 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	FolderID  uuid.NullUUID
+}
+
+type FetchLog struct {
+	ID            uuid.UUID
+	FeedID        uuid.UUID
+	StartedAt     time.Time
+	DurationMs    int32
+	HttpStatus    sql.NullInt32
+	BytesReceived int64
+	ItemsParsed   int32
+	NewPosts      int32
+	Error         sql.NullString
+}
+
+type Folder struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
+}
+
+type Hook struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	UserID          uuid.UUID
+	FeedID          uuid.NullUUID
+	Kind            string
+	Target          string
+	Secret          sql.NullString
+	LastDeliveredAt sql.NullTime
+	LastError       sql.NullString
+}
+
+type Job struct {
+	Name           string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Schedule       string
+	RunningOwner   sql.NullString
+	RunningUntil   sql.NullTime
+	LastStartedAt  sql.NullTime
+	LastFinishedAt sql.NullTime
+	LastDurationMs sql.NullInt32
+	LastStatus     sql.NullString
+	LastError      sql.NullString
+	LastRunBy      sql.NullString
 }
 
 type Post struct {
@@ -38,6 +98,48 @@ type Post struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Guid        sql.NullString
+	ContentHash sql.NullString
+}
+
+type PostState struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Read      bool
+	ReadAt    sql.NullTime
+}
+
+type PostTag struct {
+	TagID     uuid.UUID
+	PostID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type PrunedPost struct {
+	FeedID   uuid.UUID
+	Guid     string
+	PrunedAt time.Time
+}
+
+type SavedPost struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type StarredPost struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type Tag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
 }
 
 type User struct {
@@ -11,24 +11,89 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES (
-    $1,
-    $2,
-    $3,
-    $4,
-    $5,
-    $6,
-    $7,
-    $8
-)
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+const adoptLegacyPost = `-- name: AdoptLegacyPost :exec
+UPDATE posts
+SET guid = $3
+WHERE feed_id = $1 AND url = $2 AND guid IS NULL
+`
+
+type AdoptLegacyPostParams struct {
+	FeedID uuid.UUID
+	Url    string
+	Guid   sql.NullString
+}
+
+func (q *Queries) AdoptLegacyPost(ctx context.Context, arg AdoptLegacyPostParams) error {
+	_, err := q.db.ExecContext(ctx, adoptLegacyPost, arg.FeedID, arg.Url, arg.Guid)
+	return err
+}
+
+const getLegacyPostUrls = `-- name: GetLegacyPostUrls :many
+SELECT url
+FROM posts
+WHERE feed_id = $1 AND guid IS NULL
 `
 
-type CreatePostParams struct {
+func (q *Queries) GetLegacyPostUrls(ctx context.Context, feedID uuid.UUID) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getLegacyPostUrls, feedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		items = append(items, url)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNewerPostsForUser = `-- name: GetNewerPostsForUser :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.guid, posts.content_hash, feeds.name AS feed_name, COALESCE(post_states.read, FALSE)::bool AS read
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+  AND (NOT $2::bool OR post_states.read IS NOT TRUE)
+  AND ($3::uuid IS NULL OR feed_follows.folder_id = $3)
+  AND ($4::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.user_id = feed_follows.user_id AND tags.name = $4))
+  AND ($5::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) >= $5)
+  AND ($6::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) < $6)
+  AND ($7::timestamp IS NULL
+    OR (COALESCE(posts.published_at, posts.created_at), posts.id) > ($7, $8::uuid))
+ORDER BY COALESCE(posts.published_at, posts.created_at) ASC, posts.id ASC
+LIMIT $9
+`
+
+type GetNewerPostsForUserParams struct {
+	UserID            uuid.UUID
+	UnreadOnly        bool
+	FolderID          uuid.NullUUID
+	Tag               sql.NullString
+	Since             sql.NullTime
+	Until             sql.NullTime
+	CursorPublishedAt sql.NullTime
+	CursorID          uuid.NullUUID
+	PageSize          int32
+}
+
+type GetNewerPostsForUserRow struct {
 	ID          uuid.UUID
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
@@ -37,45 +102,91 @@ type CreatePostParams struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Guid        sql.NullString
+	ContentHash sql.NullString
+	FeedName    string
+	Read        bool
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPost,
-		arg.ID,
-		arg.CreatedAt,
-		arg.UpdatedAt,
-		arg.Title,
-		arg.Url,
-		arg.Description,
-		arg.PublishedAt,
-		arg.FeedID,
-	)
-	var i Post
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Title,
-		&i.Url,
-		&i.Description,
-		&i.PublishedAt,
-		&i.FeedID,
+// Returns the page before the given cursor in GetPostsForUser order: the
+// posts newer than the cursor, oldest first.
+func (q *Queries) GetNewerPostsForUser(ctx context.Context, arg GetNewerPostsForUserParams) ([]GetNewerPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getNewerPostsForUser,
+		arg.UserID,
+		arg.UnreadOnly,
+		arg.FolderID,
+		arg.Tag,
+		arg.Since,
+		arg.Until,
+		arg.CursorPublishedAt,
+		arg.CursorID,
+		arg.PageSize,
 	)
-	return i, err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNewerPostsForUserRow
+	for rows.Next() {
+		var i GetNewerPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Guid,
+			&i.ContentHash,
+			&i.FeedName,
+			&i.Read,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, feeds.name AS feed_name FROM posts
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.guid, posts.content_hash, feeds.name AS feed_name, COALESCE(post_states.read, FALSE)::bool AS read
+FROM posts
 JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
 JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
 WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at DESC
-LIMIT $2
+  AND (NOT $2::bool OR post_states.read IS NOT TRUE)
+  AND ($3::uuid IS NULL OR feed_follows.folder_id = $3)
+  AND ($4::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.user_id = feed_follows.user_id AND tags.name = $4))
+  AND ($5::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) >= $5)
+  AND ($6::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) < $6)
+  AND ($7::timestamp IS NULL
+    OR (COALESCE(posts.published_at, posts.created_at), posts.id) < ($7, $8::uuid))
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC, posts.id DESC
+LIMIT $9
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID            uuid.UUID
+	UnreadOnly        bool
+	FolderID          uuid.NullUUID
+	Tag               sql.NullString
+	Since             sql.NullTime
+	Until             sql.NullTime
+	CursorPublishedAt sql.NullTime
+	CursorID          uuid.NullUUID
+	PageSize          int32
 }
 
 type GetPostsForUserRow struct {
@@ -87,11 +198,27 @@ type GetPostsForUserRow struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Guid        sql.NullString
+	ContentHash sql.NullString
 	FeedName    string
+	Read        bool
 }
 
+// Returns a page of the posts of the user's followed feeds, newest first,
+// older than the given (published_at, id) cursor if any. Posts without a
+// publication date are sorted by when they were added.
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.UnreadOnly,
+		arg.FolderID,
+		arg.Tag,
+		arg.Since,
+		arg.Until,
+		arg.CursorPublishedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -108,7 +235,232 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Guid,
+			&i.ContentHash,
+			&i.FeedName,
+			&i.Read,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const prunePosts = `-- name: PrunePosts :execrows
+DELETE FROM posts
+WHERE COALESCE(published_at, created_at) < $1::timestamp
+  AND NOT EXISTS (SELECT 1 FROM starred_posts WHERE starred_posts.post_id = posts.id)
+  AND NOT EXISTS (SELECT 1 FROM saved_posts WHERE saved_posts.post_id = posts.id)
+  AND NOT EXISTS (SELECT 1 FROM post_tags WHERE post_tags.post_id = posts.id)
+`
+
+// Deletes posts published before the cutoff, keeping any post that a user
+// starred, saved for later or tagged.
+func (q *Queries) PrunePosts(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, prunePosts, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const savePrunedPostKeys = `-- name: SavePrunedPostKeys :exec
+INSERT INTO pruned_posts (feed_id, guid, pruned_at)
+SELECT feed_id, guid, NOW()
+FROM posts
+WHERE guid IS NOT NULL
+  AND COALESCE(published_at, created_at) < $1::timestamp
+  AND NOT EXISTS (SELECT 1 FROM starred_posts WHERE starred_posts.post_id = posts.id)
+  AND NOT EXISTS (SELECT 1 FROM saved_posts WHERE saved_posts.post_id = posts.id)
+  AND NOT EXISTS (SELECT 1 FROM post_tags WHERE post_tags.post_id = posts.id)
+ON CONFLICT (feed_id, guid) DO NOTHING
+`
+
+// Remembers the keys of the posts PrunePosts is about to delete, so that
+// UpsertPosts skips them while they are still in their feed. Run both in
+// the same transaction.
+func (q *Queries) SavePrunedPostKeys(ctx context.Context, before time.Time) error {
+	_, err := q.db.ExecContext(ctx, savePrunedPostKeys, before)
+	return err
+}
+
+const searchPosts = `-- name: SearchPosts :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description,
+    posts.published_at, posts.feed_id, posts.guid, posts.content_hash, feeds.name AS feed_name,
+    ts_rank(post_search_document(posts.title, posts.description), query)::float8 AS rank,
+    ts_headline('english', posts.title || ' ' || COALESCE(posts.description, ''), query,
+        'StartSel="**", StopSel="**", MaxWords=30, MinWords=10')::text AS snippet
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON feeds.id = posts.feed_id
+CROSS JOIN websearch_to_tsquery('english', $1) AS query
+WHERE feed_follows.user_id = $2
+  AND post_search_document(posts.title, posts.description) @@ query
+  AND ($3::uuid IS NULL OR posts.feed_id = $3)
+  AND ($4::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) >= $4)
+  AND ($5::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) < $5)
+ORDER BY rank DESC, posts.published_at DESC NULLS LAST
+LIMIT $6
+`
+
+type SearchPostsParams struct {
+	Query    string
+	UserID   uuid.UUID
+	FeedID   uuid.NullUUID
+	Since    sql.NullTime
+	Until    sql.NullTime
+	PageSize int32
+}
+
+type SearchPostsRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+	Guid        sql.NullString
+	ContentHash sql.NullString
+	FeedName    string
+	Rank        float64
+	Snippet     string
+}
+
+// Searches the posts of the user's followed feeds, best matches first. The
+// query takes the web search syntax: quoted phrases, OR and -excluded.
+func (q *Queries) SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPosts,
+		arg.Query,
+		arg.UserID,
+		arg.FeedID,
+		arg.Since,
+		arg.Until,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsRow
+	for rows.Next() {
+		var i SearchPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Guid,
+			&i.ContentHash,
 			&i.FeedName,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertPosts = `-- name: UpsertPosts :many
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash)
+SELECT
+    item.id,
+    $1::timestamp,
+    $1::timestamp,
+    item.title,
+    item.url,
+    item.description,
+    NULLIF(item.published_at, '')::timestamp,
+    $2::uuid,
+    item.guid,
+    item.content_hash
+FROM unnest(
+    $3::uuid[],
+    $4::text[],
+    $5::text[],
+    $6::text[],
+    $7::text[],
+    $8::text[],
+    $9::text[]
+) AS item(id, title, url, description, published_at, guid, content_hash)
+WHERE NOT EXISTS (
+    SELECT 1 FROM pruned_posts
+    WHERE pruned_posts.feed_id = $2 AND pruned_posts.guid = item.guid
+)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    published_at = EXCLUDED.published_at,
+    content_hash = EXCLUDED.content_hash,
+    updated_at = EXCLUDED.updated_at
+WHERE posts.content_hash IS DISTINCT FROM EXCLUDED.content_hash
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash
+`
+
+type UpsertPostsParams struct {
+	CreatedAt     time.Time
+	FeedID        uuid.UUID
+	Ids           []uuid.UUID
+	Titles        []string
+	Urls          []string
+	Descriptions  []string
+	PublishedAts  []string
+	Guids         []string
+	ContentHashes []string
+}
+
+// Posts that were pruned are not inserted again.
+func (q *Queries) UpsertPosts(ctx context.Context, arg UpsertPostsParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, upsertPosts,
+		arg.CreatedAt,
+		arg.FeedID,
+		pq.Array(arg.Ids),
+		pq.Array(arg.Titles),
+		pq.Array(arg.Urls),
+		pq.Array(arg.Descriptions),
+		pq.Array(arg.PublishedAts),
+		pq.Array(arg.Guids),
+		pq.Array(arg.ContentHashes),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Guid,
+			&i.ContentHash,
 		); err != nil {
 			return nil, err
 		}
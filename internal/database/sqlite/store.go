@@ -0,0 +1,473 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// errNoAdvisoryLocks is returned by the Postgres specific leader election
+// queries; a SQLite database is only used by the processes of one host.
+var errNoAdvisoryLocks = errors.New("advisory locks are not supported by SQLite")
+
+// Store runs the commands against SQLite. The SQLite queries return the same
+// rows as their Postgres counterparts, so results are converted to the types
+// of package database.
+type Store struct {
+	q  *Queries
+	db *sql.DB
+}
+
+var _ database.Store = (*Store)(nil)
+
+// NewStore returns a Store for a SQLite database.
+func NewStore(db *sql.DB) *Store {
+	return &Store{q: New(utcDB{db}), db: db}
+}
+
+func (s *Store) InTx(ctx context.Context, fn func(q database.Querier) error) error {
+	if s.db == nil {
+		return errors.New("transaction already in progress")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Store{q: New(utcDB{tx})}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// utcDB converts the times passed to the queries to UTC. go-sqlite3 stores
+// times as text with their offset and SQLite compares that text as is, so
+// comparisons and ordering only hold when every time is in UTC, like the
+// ones the queries compute with strftime.
+type utcDB struct {
+	DBTX
+}
+
+func (db utcDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.DBTX.ExecContext(ctx, query, utcArgs(args)...)
+}
+
+func (db utcDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DBTX.QueryContext(ctx, query, utcArgs(args)...)
+}
+
+func (db utcDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return db.DBTX.QueryRowContext(ctx, query, utcArgs(args)...)
+}
+
+func utcArgs(args []interface{}) []interface{} {
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			args[i] = v.UTC()
+		case sql.NullTime:
+			v.Time = v.Time.UTC()
+			args[i] = v
+		}
+	}
+	return args
+}
+
+// UpsertPosts upserts the posts one by one, as SQLite has no arrays to pass
+// them all in a single statement. Callers run it inside InTx.
+func (s *Store) UpsertPosts(ctx context.Context, arg database.UpsertPostsParams) ([]database.Post, error) {
+	var items []database.Post
+	for i, id := range arg.Ids {
+		var publishedAt sql.NullTime
+		if t, err := time.Parse(time.RFC3339, arg.PublishedAts[i]); err == nil {
+			publishedAt = sql.NullTime{Time: t.UTC(), Valid: true}
+		}
+
+		saved, err := s.q.UpsertPost(ctx, UpsertPostParams{
+			ID:          id,
+			CreatedAt:   arg.CreatedAt,
+			Title:       arg.Titles[i],
+			Url:         arg.Urls[i],
+			Description: sql.NullString{String: arg.Descriptions[i], Valid: true},
+			PublishedAt: publishedAt,
+			FeedID:      arg.FeedID,
+			Guid:        sql.NullString{String: arg.Guids[i], Valid: true},
+			ContentHash: sql.NullString{String: arg.ContentHashes[i], Valid: true},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, post := range saved {
+			items = append(items, database.Post(post))
+		}
+	}
+	return items, nil
+}
+
+// SearchPosts takes the same web search syntax as Postgres, which it
+// translates to the FTS4 query syntax.
+func (s *Store) SearchPosts(ctx context.Context, arg database.SearchPostsParams) ([]database.SearchPostsRow, error) {
+	params := SearchPostsParams(arg)
+	params.Query = ftsQuery(arg.Query)
+	rows, err := s.q.SearchPosts(ctx, params)
+	return convertAll(rows, func(row SearchPostsRow) database.SearchPostsRow { return database.SearchPostsRow(row) }), err
+}
+
+// ftsQuery turns -word into NOT word and or into OR, outside of quoted
+// phrases, and closes an unterminated phrase.
+func ftsQuery(query string) string {
+	if strings.Count(query, `"`)%2 == 1 {
+		query += `"`
+	}
+
+	words := strings.Fields(query)
+	quoted := false
+	for i, word := range words {
+		if !quoted {
+			if strings.EqualFold(word, "or") {
+				words[i] = "OR"
+			} else if excluded, ok := strings.CutPrefix(word, "-"); ok && excluded != "" {
+				words[i] = "NOT " + excluded
+			}
+		}
+		if strings.Count(word, `"`)%2 == 1 {
+			quoted = !quoted
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func (s *Store) TryAdvisoryLock(ctx context.Context, key int64) (bool, error) {
+	return false, errNoAdvisoryLocks
+}
+
+func (s *Store) AdvisoryUnlock(ctx context.Context, key int64) (bool, error) {
+	return false, errNoAdvisoryLocks
+}
+
+func (s *Store) SetApplicationName(ctx context.Context, name string) error {
+	return errNoAdvisoryLocks
+}
+
+func (s *Store) GetAdvisoryLockHolder(ctx context.Context, key int64) (sql.NullString, error) {
+	return sql.NullString{}, errNoAdvisoryLocks
+}
+
+// convertAll converts rows of this package to the matching type of package
+// database.
+func convertAll[From, To any](rows []From, convert func(From) To) []To {
+	if rows == nil {
+		return nil
+	}
+	items := make([]To, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, convert(row))
+	}
+	return items
+}
+
+func (s *Store) AdoptLegacyPost(ctx context.Context, arg database.AdoptLegacyPostParams) error {
+	return s.q.AdoptLegacyPost(ctx, AdoptLegacyPostParams(arg))
+}
+
+func (s *Store) ClaimFeed(ctx context.Context, arg database.ClaimFeedParams) (database.Feed, error) {
+	row, err := s.q.ClaimFeed(ctx, ClaimFeedParams(arg))
+	return database.Feed(row), err
+}
+
+func (s *Store) ClaimNextFeedToFetch(ctx context.Context, arg database.ClaimNextFeedToFetchParams) (database.Feed, error) {
+	row, err := s.q.ClaimNextFeedToFetch(ctx, ClaimNextFeedToFetchParams(arg))
+	return database.Feed(row), err
+}
+
+func (s *Store) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	row, err := s.q.CreateFeed(ctx, CreateFeedParams(arg))
+	return database.Feed(row), err
+}
+
+func (s *Store) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	row, err := s.q.CreateFeedFollow(ctx, CreateFeedFollowParams(arg))
+	return database.CreateFeedFollowRow(row), err
+}
+
+func (s *Store) CreateFetchLog(ctx context.Context, arg database.CreateFetchLogParams) error {
+	return s.q.CreateFetchLog(ctx, CreateFetchLogParams(arg))
+}
+
+func (s *Store) CreateFolder(ctx context.Context, arg database.CreateFolderParams) (database.Folder, error) {
+	row, err := s.q.CreateFolder(ctx, CreateFolderParams(arg))
+	return database.Folder(row), err
+}
+
+func (s *Store) CreateHook(ctx context.Context, arg database.CreateHookParams) (database.Hook, error) {
+	row, err := s.q.CreateHook(ctx, CreateHookParams(arg))
+	return database.Hook(row), err
+}
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	row, err := s.q.CreateUser(ctx, CreateUserParams(arg))
+	return database.User(row), err
+}
+
+func (s *Store) DeleteAllUsers(ctx context.Context) error {
+	return s.q.DeleteAllUsers(ctx)
+}
+
+func (s *Store) DeleteFolder(ctx context.Context, arg database.DeleteFolderParams) (int64, error) {
+	return s.q.DeleteFolder(ctx, DeleteFolderParams(arg))
+}
+
+func (s *Store) DeleteHook(ctx context.Context, arg database.DeleteHookParams) (int64, error) {
+	return s.q.DeleteHook(ctx, DeleteHookParams(arg))
+}
+
+func (s *Store) DeleteUnusedTags(ctx context.Context, userID uuid.UUID) error {
+	return s.q.DeleteUnusedTags(ctx, userID)
+}
+
+func (s *Store) EnableFeed(ctx context.Context, url string) (database.Feed, error) {
+	row, err := s.q.EnableFeed(ctx, url)
+	return database.Feed(row), err
+}
+
+func (s *Store) EnsureJob(ctx context.Context, arg database.EnsureJobParams) error {
+	return s.q.EnsureJob(ctx, EnsureJobParams(arg))
+}
+
+func (s *Store) FinishJobRun(ctx context.Context, arg database.FinishJobRunParams) error {
+	return s.q.FinishJobRun(ctx, FinishJobRunParams(arg))
+}
+
+func (s *Store) GetAllFeeds(ctx context.Context, arg database.GetAllFeedsParams) ([]database.GetAllFeedsRow, error) {
+	rows, err := s.q.GetAllFeeds(ctx, GetAllFeedsParams(arg))
+	return convertAll(rows, func(row GetAllFeedsRow) database.GetAllFeedsRow { return database.GetAllFeedsRow(row) }), err
+}
+
+func (s *Store) GetFeedByUrl(ctx context.Context, url string) (database.Feed, error) {
+	row, err := s.q.GetFeedByUrl(ctx, url)
+	return database.Feed(row), err
+}
+
+func (s *Store) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsForUserRow, error) {
+	rows, err := s.q.GetFeedFollowsForUser(ctx, userID)
+	return convertAll(rows, func(row GetFeedFollowsForUserRow) database.GetFeedFollowsForUserRow {
+		return database.GetFeedFollowsForUserRow(row)
+	}), err
+}
+
+func (s *Store) GetFeedsByName(ctx context.Context, name string) ([]database.Feed, error) {
+	rows, err := s.q.GetFeedsByName(ctx, name)
+	return convertAll(rows, func(row Feed) database.Feed { return database.Feed(row) }), err
+}
+
+func (s *Store) GetFetchLogForFeed(ctx context.Context, arg database.GetFetchLogForFeedParams) ([]database.FetchLog, error) {
+	rows, err := s.q.GetFetchLogForFeed(ctx, GetFetchLogForFeedParams(arg))
+	return convertAll(rows, func(row FetchLog) database.FetchLog { return database.FetchLog(row) }), err
+}
+
+func (s *Store) GetFetchQueueStats(ctx context.Context) (database.GetFetchQueueStatsRow, error) {
+	row, err := s.q.GetFetchQueueStats(ctx)
+	return database.GetFetchQueueStatsRow(row), err
+}
+
+func (s *Store) GetFetchStatsForFeed(ctx context.Context, feedID uuid.UUID) (database.GetFetchStatsForFeedRow, error) {
+	row, err := s.q.GetFetchStatsForFeed(ctx, feedID)
+	return database.GetFetchStatsForFeedRow(row), err
+}
+
+func (s *Store) GetFolderByName(ctx context.Context, arg database.GetFolderByNameParams) (database.Folder, error) {
+	row, err := s.q.GetFolderByName(ctx, GetFolderByNameParams(arg))
+	return database.Folder(row), err
+}
+
+func (s *Store) GetFoldersForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFoldersForUserRow, error) {
+	rows, err := s.q.GetFoldersForUser(ctx, userID)
+	return convertAll(rows, func(row GetFoldersForUserRow) database.GetFoldersForUserRow {
+		return database.GetFoldersForUserRow(row)
+	}), err
+}
+
+func (s *Store) GetHooksForFeed(ctx context.Context, feedID uuid.UUID) ([]database.GetHooksForFeedRow, error) {
+	rows, err := s.q.GetHooksForFeed(ctx, feedID)
+	return convertAll(rows, func(row GetHooksForFeedRow) database.GetHooksForFeedRow { return database.GetHooksForFeedRow(row) }), err
+}
+
+func (s *Store) GetHooksForUser(ctx context.Context, userID uuid.UUID) ([]database.GetHooksForUserRow, error) {
+	rows, err := s.q.GetHooksForUser(ctx, userID)
+	return convertAll(rows, func(row GetHooksForUserRow) database.GetHooksForUserRow { return database.GetHooksForUserRow(row) }), err
+}
+
+func (s *Store) GetJob(ctx context.Context, name string) (database.Job, error) {
+	row, err := s.q.GetJob(ctx, name)
+	return database.Job(row), err
+}
+
+func (s *Store) GetJobs(ctx context.Context) ([]database.Job, error) {
+	rows, err := s.q.GetJobs(ctx)
+	return convertAll(rows, func(row Job) database.Job { return database.Job(row) }), err
+}
+
+func (s *Store) GetLastFetchWithNewPosts(ctx context.Context, feedID uuid.UUID) (database.FetchLog, error) {
+	row, err := s.q.GetLastFetchWithNewPosts(ctx, feedID)
+	return database.FetchLog(row), err
+}
+
+func (s *Store) GetLegacyPostUrls(ctx context.Context, feedID uuid.UUID) ([]string, error) {
+	return s.q.GetLegacyPostUrls(ctx, feedID)
+}
+
+func (s *Store) GetNewerPostsForUser(ctx context.Context, arg database.GetNewerPostsForUserParams) ([]database.GetNewerPostsForUserRow, error) {
+	rows, err := s.q.GetNewerPostsForUser(ctx, GetNewerPostsForUserParams(arg))
+	return convertAll(rows, func(row GetNewerPostsForUserRow) database.GetNewerPostsForUserRow {
+		return database.GetNewerPostsForUserRow(row)
+	}), err
+}
+
+func (s *Store) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	rows, err := s.q.GetPostsForUser(ctx, GetPostsForUserParams(arg))
+	return convertAll(rows, func(row GetPostsForUserRow) database.GetPostsForUserRow { return database.GetPostsForUserRow(row) }), err
+}
+
+func (s *Store) GetSavedPostsForUser(ctx context.Context, arg database.GetSavedPostsForUserParams) ([]database.GetSavedPostsForUserRow, error) {
+	rows, err := s.q.GetSavedPostsForUser(ctx, GetSavedPostsForUserParams(arg))
+	return convertAll(rows, func(row GetSavedPostsForUserRow) database.GetSavedPostsForUserRow {
+		return database.GetSavedPostsForUserRow(row)
+	}), err
+}
+
+func (s *Store) GetStarredPostsForUser(ctx context.Context, arg database.GetStarredPostsForUserParams) ([]database.GetStarredPostsForUserRow, error) {
+	rows, err := s.q.GetStarredPostsForUser(ctx, GetStarredPostsForUserParams(arg))
+	return convertAll(rows, func(row GetStarredPostsForUserRow) database.GetStarredPostsForUserRow {
+		return database.GetStarredPostsForUserRow(row)
+	}), err
+}
+
+func (s *Store) GetTaggedPostsForUser(ctx context.Context, arg database.GetTaggedPostsForUserParams) ([]database.GetTaggedPostsForUserRow, error) {
+	rows, err := s.q.GetTaggedPostsForUser(ctx, GetTaggedPostsForUserParams(arg))
+	return convertAll(rows, func(row GetTaggedPostsForUserRow) database.GetTaggedPostsForUserRow {
+		return database.GetTaggedPostsForUserRow(row)
+	}), err
+}
+
+func (s *Store) GetTagsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetTagsForUserRow, error) {
+	rows, err := s.q.GetTagsForUser(ctx, userID)
+	return convertAll(rows, func(row GetTagsForUserRow) database.GetTagsForUserRow { return database.GetTagsForUserRow(row) }), err
+}
+
+func (s *Store) GetUnhealthyFeeds(ctx context.Context) ([]database.Feed, error) {
+	rows, err := s.q.GetUnhealthyFeeds(ctx)
+	return convertAll(rows, func(row Feed) database.Feed { return database.Feed(row) }), err
+}
+
+func (s *Store) GetUserByName(ctx context.Context, name string) (database.User, error) {
+	row, err := s.q.GetUserByName(ctx, name)
+	return database.User(row), err
+}
+
+func (s *Store) GetUsers(ctx context.Context, arg database.GetUsersParams) ([]database.User, error) {
+	rows, err := s.q.GetUsers(ctx, GetUsersParams(arg))
+	return convertAll(rows, func(row User) database.User { return database.User(row) }), err
+}
+
+func (s *Store) MarkFeedFailed(ctx context.Context, arg database.MarkFeedFailedParams) (database.Feed, error) {
+	row, err := s.q.MarkFeedFailed(ctx, MarkFeedFailedParams(arg))
+	return database.Feed(row), err
+}
+
+func (s *Store) MarkFeedFetched(ctx context.Context, arg database.MarkFeedFetchedParams) (database.Feed, error) {
+	row, err := s.q.MarkFeedFetched(ctx, MarkFeedFetchedParams(arg))
+	return database.Feed(row), err
+}
+
+func (s *Store) MarkHookDelivered(ctx context.Context, id uuid.UUID) error {
+	return s.q.MarkHookDelivered(ctx, id)
+}
+
+func (s *Store) MarkHookFailed(ctx context.Context, arg database.MarkHookFailedParams) error {
+	return s.q.MarkHookFailed(ctx, MarkHookFailedParams(arg))
+}
+
+func (s *Store) MoveFeedFollow(ctx context.Context, arg database.MoveFeedFollowParams) (int64, error) {
+	return s.q.MoveFeedFollow(ctx, MoveFeedFollowParams(arg))
+}
+
+func (s *Store) PruneFetchLog(ctx context.Context, startedAt time.Time) (int64, error) {
+	return s.q.PruneFetchLog(ctx, startedAt)
+}
+
+func (s *Store) PrunePosts(ctx context.Context, before time.Time) (int64, error) {
+	return s.q.PrunePosts(ctx, before)
+}
+
+func (s *Store) ReleaseFeedLease(ctx context.Context, arg database.ReleaseFeedLeaseParams) error {
+	return s.q.ReleaseFeedLease(ctx, ReleaseFeedLeaseParams(arg))
+}
+
+func (s *Store) RenameFolder(ctx context.Context, arg database.RenameFolderParams) (database.Folder, error) {
+	row, err := s.q.RenameFolder(ctx, RenameFolderParams(arg))
+	return database.Folder(row), err
+}
+
+func (s *Store) SavePost(ctx context.Context, arg database.SavePostParams) (int64, error) {
+	return s.q.SavePost(ctx, SavePostParams(arg))
+}
+
+func (s *Store) SavePrunedPostKeys(ctx context.Context, before time.Time) error {
+	return s.q.SavePrunedPostKeys(ctx, before)
+}
+
+func (s *Store) SetPostsRead(ctx context.Context, arg database.SetPostsReadParams) (int64, error) {
+	return s.q.SetPostsRead(ctx, SetPostsReadParams(arg))
+}
+
+func (s *Store) StarPost(ctx context.Context, arg database.StarPostParams) (int64, error) {
+	return s.q.StarPost(ctx, StarPostParams(arg))
+}
+
+func (s *Store) StartJobRun(ctx context.Context, arg database.StartJobRunParams) (database.Job, error) {
+	row, err := s.q.StartJobRun(ctx, StartJobRunParams(arg))
+	return database.Job(row), err
+}
+
+func (s *Store) TagPost(ctx context.Context, arg database.TagPostParams) (int64, error) {
+	return s.q.TagPost(ctx, TagPostParams(arg))
+}
+
+func (s *Store) UnfollowFeed(ctx context.Context, arg database.UnfollowFeedParams) error {
+	return s.q.UnfollowFeed(ctx, UnfollowFeedParams(arg))
+}
+
+func (s *Store) UnsavePost(ctx context.Context, arg database.UnsavePostParams) (int64, error) {
+	return s.q.UnsavePost(ctx, UnsavePostParams(arg))
+}
+
+func (s *Store) UnstarPost(ctx context.Context, arg database.UnstarPostParams) (int64, error) {
+	return s.q.UnstarPost(ctx, UnstarPostParams(arg))
+}
+
+func (s *Store) UntagPost(ctx context.Context, arg database.UntagPostParams) (int64, error) {
+	return s.q.UntagPost(ctx, UntagPostParams(arg))
+}
+
+func (s *Store) UpdateFeedSchedule(ctx context.Context, arg database.UpdateFeedScheduleParams) (database.Feed, error) {
+	row, err := s.q.UpdateFeedSchedule(ctx, UpdateFeedScheduleParams(arg))
+	return database.Feed(row), err
+}
+
+func (s *Store) UpdateJobSchedule(ctx context.Context, arg database.UpdateJobScheduleParams) (database.Job, error) {
+	row, err := s.q.UpdateJobSchedule(ctx, UpdateJobScheduleParams(arg))
+	return database.Job(row), err
+}
+
+func (s *Store) UpsertTag(ctx context.Context, arg database.UpsertTagParams) (database.Tag, error) {
+	row, err := s.q.UpsertTag(ctx, UpsertTagParams(arg))
+	return database.Tag(row), err
+}
@@ -0,0 +1,22 @@
+package sqlite
+
+import "testing"
+
+func TestFtsQuery(t *testing.T) {
+	for query, want := range map[string]string{
+		"go":                         "go",
+		"  go   generics ":           "go generics",
+		"go -rust":                   "go NOT rust",
+		"go or rust":                 "go OR rust",
+		"go OR -rust":                "go OR NOT rust",
+		"go - rust":                  "go - rust",
+		`"go or rust" -java`:         `"go or rust" NOT java`,
+		`"-rust" go`:                 `"-rust" go`,
+		`"go generics`:               `"go generics"`,
+		`title:"go or rust" or java`: `title:"go or rust" OR java`,
+	} {
+		if got := ftsQuery(query); got != want {
+			t.Errorf("ftsQuery(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
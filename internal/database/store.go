@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Store is the storage behind the commands. Every supported database
+// implements the generated Querier and runs transactions with InTx; besides
+// Postgres there are packages sqlite and memory, the latter for tests. A new
+// query has to be added to all of them.
+type Store interface {
+	Querier
+	// InTx runs fn inside a transaction, committing only if it returns nil.
+	InTx(ctx context.Context, fn func(q Querier) error) error
+}
+
+// NewStore returns a Store for a Postgres database.
+func NewStore(db *sql.DB) Store {
+	return &postgresStore{Queries: New(db), db: db}
+}
+
+type postgresStore struct {
+	*Queries
+	db *sql.DB
+}
+
+func (s *postgresStore) InTx(ctx context.Context, fn func(q Querier) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(s.WithTx(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -75,10 +76,22 @@ func (q *Queries) GetUserByName(ctx context.Context, name string) (User, error)
 
 const getUsers = `-- name: GetUsers :many
 SELECT id, created_at, updated_at, name FROM users
+WHERE $1::timestamp IS NULL
+   OR (created_at, id) > ($1, $2::uuid)
+ORDER BY created_at, id
+LIMIT $3
 `
 
-func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, getUsers)
+type GetUsersParams struct {
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	PageSize       int32
+}
+
+// Returns a page of users in the order they registered, after the given
+// (created_at, id) cursor if any.
+func (q *Queries) GetUsers(ctx context.Context, arg GetUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsers, arg.AfterCreatedAt, arg.AfterID, arg.PageSize)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,172 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	// EventPostCreated is sent for every post inserted by the aggregator.
+	EventPostCreated = "post.created"
+
+	SignatureHeader = "X-Gator-Signature"
+	EventHeader     = "X-Gator-Event"
+	DeliveryHeader  = "X-Gator-Delivery"
+
+	maxOutput = 512
+
+	commandWaitDelay = time.Second
+)
+
+type Feed struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type Post struct {
+	ID          string     `json:"id"`
+	GUID        string     `json:"guid"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Description string     `json:"description"`
+	PublishedAt *time.Time `json:"published_at"`
+}
+
+// Payload is the JSON document sent to webhooks and written to the stdin of
+// hook commands.
+type Payload struct {
+	Event    string `json:"event"`
+	Delivery string `json:"delivery"`
+	User     string `json:"user"`
+	Feed     Feed   `json:"feed"`
+	Post     Post   `json:"post"`
+}
+
+// Sender delivers payloads to webhooks and local commands.
+type Sender struct {
+	httpClient     http.Client
+	attempts       int
+	retryDelay     time.Duration
+	commandTimeout time.Duration
+}
+
+// NewSender creates a Sender that tries each webhook up to attempts times,
+// doubling retryDelay between tries.
+func NewSender(timeout time.Duration, attempts int, retryDelay time.Duration) *Sender {
+	return &Sender{
+		httpClient: http.Client{
+			Timeout: timeout,
+		},
+		attempts:       attempts,
+		retryDelay:     retryDelay,
+		commandTimeout: timeout,
+	}
+}
+
+// Sign returns the signature of body sent in the X-Gator-Signature header,
+// so receivers can check the payload came from us.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// PostWebhook sends payload to url, retrying on network errors, 429 and 5xx
+// responses. The body is signed when secret is not empty.
+func (s *Sender) PostWebhook(ctx context.Context, url, secret string, payload Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	delay := s.retryDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := s.postOnce(ctx, url, secret, payload, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= s.attempts {
+			return fmt.Errorf("attempt %d: %w", attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("attempt %d: %w", attempt, err)
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (s *Sender) postOnce(ctx context.Context, url, secret string, payload Payload, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gator")
+	req.Header.Set(EventHeader, payload.Event)
+	req.Header.Set(DeliveryHeader, payload.Delivery)
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, maxOutput))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		retry := res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+		return retry, fmt.Errorf("webhook responded %s", res.Status)
+	}
+	return false, nil
+}
+
+// RunCommand runs command with the shell, writing payload as JSON to its
+// stdin. Commands are not retried.
+func (s *Sender) RunCommand(ctx context.Context, command string, payload Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(cmd.Environ(), "GATOR_EVENT="+payload.Event, "GATOR_DELIVERY="+payload.Delivery)
+	// Killing the shell on timeout leaves its children holding the output
+	// pipe open, so stop waiting for them shortly after.
+	cmd.WaitDelay = commandWaitDelay
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("command timed out after %s", s.commandTimeout)
+		}
+		if out := strings.TrimSpace(string(output)); out != "" {
+			if len(out) > maxOutput {
+				out = out[:maxOutput] + "..."
+			}
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
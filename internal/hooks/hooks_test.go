@@ -0,0 +1,140 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testPayload = Payload{
+	Event:    EventPostCreated,
+	Delivery: "d1",
+	User:     "alan",
+	Feed:     Feed{ID: "f1", Name: "Blog", URL: "https://example.com/feed.xml"},
+	Post:     Post{ID: "p1", GUID: "1", Title: "One", URL: "https://example.com/1"},
+}
+
+func TestSign(t *testing.T) {
+	// The HMAC-SHA256 example from Wikipedia.
+	got := Sign("key", []byte("The quick brown fox jumps over the lazy dog"))
+	if want := "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"; got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+	if Sign("other", []byte("body")) == Sign("key", []byte("body")) {
+		t.Error("Sign ignores the secret")
+	}
+}
+
+func TestPostWebhook(t *testing.T) {
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	sender := NewSender(time.Second, 3, time.Millisecond)
+	if err := sender.PostWebhook(context.Background(), server.URL, "s3cret", testPayload); err != nil {
+		t.Fatalf("PostWebhook: %v", err)
+	}
+
+	var got Payload
+	if err := json.Unmarshal(body, &got); err != nil || got.Post.Title != "One" || got.User != "alan" {
+		t.Errorf("webhook body = %s, %v", body, err)
+	}
+	if header.Get(SignatureHeader) != Sign("s3cret", body) {
+		t.Errorf("%s = %q, want the signature of the body", SignatureHeader, header.Get(SignatureHeader))
+	}
+	if header.Get(EventHeader) != EventPostCreated || header.Get(DeliveryHeader) != "d1" || header.Get("Content-Type") != "application/json" {
+		t.Errorf("webhook headers = %v", header)
+	}
+
+	if err := sender.PostWebhook(context.Background(), server.URL, "", testPayload); err != nil {
+		t.Fatalf("PostWebhook without a secret: %v", err)
+	}
+	if _, ok := header[SignatureHeader]; ok {
+		t.Error("unsigned webhook has a signature header")
+	}
+}
+
+func TestPostWebhookRetries(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		statuses []int
+		wantErr  bool
+		attempts int32
+	}{
+		{"success", []int{http.StatusOK}, false, 1},
+		{"server errors", []int{http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusNoContent}, false, 3},
+		{"rate limited", []int{http.StatusTooManyRequests, http.StatusOK}, false, 2},
+		{"client error", []int{http.StatusBadRequest, http.StatusOK}, true, 1},
+		{"out of attempts", []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK}, true, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.statuses[calls.Add(1)-1])
+			}))
+			defer server.Close()
+
+			err := NewSender(time.Second, 3, time.Millisecond).PostWebhook(context.Background(), server.URL, "", testPayload)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("PostWebhook: err = %v, want error %t", err, tc.wantErr)
+			}
+			if calls.Load() != tc.attempts {
+				t.Errorf("webhook called %d times, want %d", calls.Load(), tc.attempts)
+			}
+		})
+	}
+}
+
+func TestPostWebhookNetworkError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	err := NewSender(time.Second, 2, time.Millisecond).PostWebhook(context.Background(), server.URL, "", testPayload)
+	if err == nil || !strings.HasPrefix(err.Error(), "attempt 2:") {
+		t.Errorf("PostWebhook to a closed server: err = %v, want a retried failure", err)
+	}
+
+	// Cancelling stops the retries.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = NewSender(time.Second, 5, time.Hour).PostWebhook(ctx, server.URL, "", testPayload)
+	if err == nil || !strings.HasPrefix(err.Error(), "attempt 1:") {
+		t.Errorf("PostWebhook with a cancelled context: err = %v", err)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	sender := NewSender(time.Second, 1, time.Millisecond)
+	ctx := context.Background()
+	out := t.TempDir() + "/payload.json"
+
+	if err := sender.RunCommand(ctx, `cat > `+out+` && test "$GATOR_EVENT" = post.created && test "$GATOR_DELIVERY" = d1`, testPayload); err != nil {
+		t.Fatalf("RunCommand: %v", err)
+	}
+	var got Payload
+	data, _ := os.ReadFile(out)
+	if err := json.Unmarshal(data, &got); err != nil || got.Post.Title != "One" {
+		t.Errorf("command stdin = %s, %v", data, err)
+	}
+
+	err := sender.RunCommand(ctx, "echo something broke >&2; exit 3", testPayload)
+	if err == nil || !strings.Contains(err.Error(), "exit status 3") || !strings.Contains(err.Error(), "something broke") {
+		t.Errorf("RunCommand of a failing command: err = %v, want its status and output", err)
+	}
+
+	err = NewSender(50*time.Millisecond, 1, time.Millisecond).RunCommand(ctx, "sleep 5", testPayload)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("RunCommand of a slow command: err = %v, want a timeout", err)
+	}
+}
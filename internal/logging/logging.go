@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Options configure the logger. Empty fields keep the defaults: text
+// output at info level on stderr.
+type Options struct {
+	Format string
+	Level  string
+	File   string
+}
+
+// New creates a logger from opts. The returned closer closes the log file,
+// if any, and must be called once the logger is no longer used.
+func New(opts Options) (*log.Logger, io.Closer, error) {
+	logOptions := log.Options{Level: log.InfoLevel}
+
+	switch strings.ToLower(opts.Format) {
+	case "", "text":
+		logOptions.Formatter = log.TextFormatter
+	case "json":
+		logOptions.Formatter = log.JSONFormatter
+		logOptions.ReportTimestamp = true
+		logOptions.TimeFormat = time.RFC3339Nano
+	case "logfmt":
+		logOptions.Formatter = log.LogfmtFormatter
+		logOptions.ReportTimestamp = true
+		logOptions.TimeFormat = time.RFC3339Nano
+	default:
+		return nil, nil, fmt.Errorf("unknown log format %q, use text, json or logfmt", opts.Format)
+	}
+
+	if opts.Level != "" {
+		level, err := log.ParseLevel(opts.Level)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unknown log level %q, use debug, info, warn, error or fatal", opts.Level)
+		}
+		logOptions.Level = level
+	}
+
+	var output io.Writer = os.Stderr
+	var closer io.Closer = io.NopCloser(nil)
+	if opts.File != "" {
+		file, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("couldn't open log file: %w", err)
+		}
+		output, closer = file, file
+		// Files are read by machines, so they always get timestamps.
+		logOptions.ReportTimestamp = true
+		if logOptions.TimeFormat == "" {
+			logOptions.TimeFormat = time.RFC3339
+		}
+	}
+
+	return log.NewWithOptions(output, logOptions), closer, nil
+}
@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logToFileForTest logs a debug and an info line with opts, writing to a
+// new file, and returns the file's lines.
+func logToFileForTest(t *testing.T, opts Options) []string {
+	t.Helper()
+	opts.File = filepath.Join(t.TempDir(), "gator.log")
+
+	logger, closer, err := New(opts)
+	if err != nil {
+		t.Fatalf("New(%+v): %v", opts, err)
+	}
+	logger.Debug("Claimed feed", "feed_id", "f1")
+	logger.Info("Feed collected", "feed_id", "f1", "status", 200)
+	if err := closer.Close(); err != nil {
+		t.Fatalf("close log file: %v", err)
+	}
+
+	data, err := os.ReadFile(opts.File)
+	if err != nil {
+		t.Fatalf("read log file: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestNewJSON(t *testing.T) {
+	lines := logToFileForTest(t, Options{Format: "JSON"})
+	if len(lines) != 1 {
+		t.Fatalf("logged %d lines at the default info level, want 1:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("log line is not JSON: %v\n%s", err, lines[0])
+	}
+	if entry["msg"] != "Feed collected" || entry["level"] != "info" || entry["feed_id"] != "f1" || entry["status"] != float64(200) {
+		t.Errorf("JSON entry = %v", entry)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, entry["time"].(string)); err != nil {
+		t.Errorf("JSON time %v: %v", entry["time"], err)
+	}
+}
+
+func TestNewLogfmt(t *testing.T) {
+	lines := logToFileForTest(t, Options{Format: "logfmt", Level: "debug"})
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines at debug level, want 2:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	for _, want := range []string{"level=info", `msg="Feed collected"`, "feed_id=f1", "status=200", "time="} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("logfmt line misses %s:\n%s", want, lines[1])
+		}
+	}
+}
+
+func TestNewText(t *testing.T) {
+	lines := logToFileForTest(t, Options{Level: "warn"})
+	if len(lines) != 1 || lines[0] != "" {
+		t.Errorf("logged info lines at warn level:\n%s", strings.Join(lines, "\n"))
+	}
+
+	lines = logToFileForTest(t, Options{Format: "text"})
+	// Log files always get timestamps, even in the text format.
+	fields := strings.Fields(lines[0])
+	if len(fields) < 2 || fields[1] != "INFO" {
+		t.Fatalf("text line = %q, want a timestamp and the level", lines[0])
+	}
+	if _, err := time.Parse(time.RFC3339, fields[0]); err != nil {
+		t.Errorf("text timestamp %q: %v", fields[0], err)
+	}
+	if !strings.Contains(lines[0], "Feed collected feed_id=f1 status=200") {
+		t.Errorf("text line = %q", lines[0])
+	}
+}
+
+func TestNewAppends(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "gator.log")
+	for _, msg := range []string{"first", "second"} {
+		logger, closer, err := New(Options{File: file})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		logger.Info(msg)
+		closer.Close()
+	}
+
+	data, _ := os.ReadFile(file)
+	if !strings.Contains(string(data), "first") || !strings.Contains(string(data), "second") {
+		t.Errorf("log file should keep earlier lines:\n%s", data)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	if logger, closer, err := New(Options{}); err != nil || logger == nil || closer.Close() != nil {
+		t.Errorf("New with the defaults: %v", err)
+	}
+
+	for _, opts := range []Options{
+		{Format: "xml"},
+		{Level: "loud"},
+		{File: filepath.Join(t.TempDir(), "missing", "gator.log")},
+	} {
+		if _, _, err := New(opts); err == nil {
+			t.Errorf("New(%+v) succeeded", opts)
+		}
+	}
+}
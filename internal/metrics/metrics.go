@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "gator"
+
+// Aggregator holds the metrics reported by the agg command.
+type Aggregator struct {
+	Fetches         *prometheus.CounterVec
+	Failures        *prometheus.CounterVec
+	FetchDuration   prometheus.Histogram
+	BytesDownloaded prometheus.Counter
+	PostsInserted   prometheus.Counter
+	PostsUpdated    prometheus.Counter
+	Workers         prometheus.Gauge
+	WorkersBusy     prometheus.Gauge
+}
+
+// QueueStats reports how far behind the aggregator is: the number of feeds
+// waiting to be fetched and how long the oldest of them has been due.
+type QueueStats func() (dueFeeds float64, oldestDueSeconds float64)
+
+// NewAggregator creates the aggregator metrics and registers them with reg.
+// The queue gauges call queueStats on every scrape.
+func NewAggregator(reg prometheus.Registerer, queueStats QueueStats) *Aggregator {
+	m := &Aggregator{
+		Fetches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "feed_fetches_total",
+			Help:      "Feed fetches by result (success, failure, aborted).",
+		}, []string{"result"}),
+		Failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "feed_fetch_failures_total",
+			Help:      "Failed feed fetches by reason.",
+		}, []string{"reason"}),
+		FetchDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "feed_fetch_duration_seconds",
+			Help:      "Time taken to fetch a feed and save its posts.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		}),
+		BytesDownloaded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "feed_bytes_downloaded_total",
+			Help:      "Bytes received from feed servers.",
+		}),
+		PostsInserted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "posts_inserted_total",
+			Help:      "New posts saved.",
+		}),
+		PostsUpdated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "posts_updated_total",
+			Help:      "Existing posts updated because their content changed.",
+		}),
+		Workers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "workers",
+			Help:      "Number of aggregator workers.",
+		}),
+		WorkersBusy: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "workers_busy",
+			Help:      "Number of aggregator workers currently processing a feed.",
+		}),
+	}
+
+	reg.MustRegister(
+		m.Fetches,
+		m.Failures,
+		m.FetchDuration,
+		m.BytesDownloaded,
+		m.PostsInserted,
+		m.PostsUpdated,
+		m.Workers,
+		m.WorkersBusy,
+		&queueCollector{
+			stats: queueStats,
+			due: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "feeds_due"),
+				"Feeds whose next fetch time has passed.", nil, nil),
+			lag: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "queue_lag_seconds"),
+				"How long the oldest due feed has been waiting to be fetched.", nil, nil),
+		},
+	)
+
+	return m
+}
+
+// queueCollector reads both queue gauges with a single call to stats.
+type queueCollector struct {
+	stats QueueStats
+	due   *prometheus.Desc
+	lag   *prometheus.Desc
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.due
+	ch <- c.lag
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	due, lag := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.due, prometheus.GaugeValue, due)
+	ch <- prometheus.MustNewConstMetric(c.lag, prometheus.GaugeValue, lag)
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNewAggregator(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	calls := 0
+	m := NewAggregator(reg, func() (float64, float64) {
+		calls++
+		return 3, 42.5
+	})
+
+	m.Fetches.WithLabelValues("success").Add(2)
+	m.Failures.WithLabelValues("http_5xx").Inc()
+	m.FetchDuration.Observe(0.2)
+	m.PostsInserted.Add(5)
+	m.Workers.Set(4)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	values := make(map[string]float64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			name := family.GetName()
+			for _, label := range metric.GetLabel() {
+				name += "/" + label.GetValue()
+			}
+			switch {
+			case metric.Counter != nil:
+				values[name] = metric.GetCounter().GetValue()
+			case metric.Gauge != nil:
+				values[name] = metric.GetGauge().GetValue()
+			case metric.Histogram != nil:
+				values[name] = float64(metric.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+
+	for name, want := range map[string]float64{
+		"gator_feed_fetches_total/success":         2,
+		"gator_feed_fetch_failures_total/http_5xx": 1,
+		"gator_feed_fetch_duration_seconds":        1,
+		"gator_feed_bytes_downloaded_total":        0,
+		"gator_posts_inserted_total":               5,
+		"gator_posts_updated_total":                0,
+		"gator_workers":                            4,
+		"gator_workers_busy":                       0,
+		"gator_feeds_due":                          3,
+		"gator_queue_lag_seconds":                  42.5,
+	} {
+		if got, ok := values[name]; !ok || got != want {
+			t.Errorf("%s = %v (reported: %t), want %v", name, got, ok, want)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("queue stats read %d times per scrape, want once", calls)
+	}
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/Pizzu/gator/internal/database"
+	"github.com/Pizzu/gator/internal/database/sqlite"
+	"github.com/Pizzu/gator/sql/schema"
+	sqliteschema "github.com/Pizzu/gator/sql/sqlite/schema"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pressly/goose/v3"
+)
+
+const (
+	DialectPostgres = goose.DialectPostgres
+	DialectSQLite   = goose.DialectSQLite3
+)
+
+// sqliteOptions enable foreign keys, which SQLite leaves off by default, and
+// let concurrent writers wait for each other instead of failing.
+const sqliteOptions = "_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate"
+
+// Backend is an open database with the store and migrations matching its
+// engine.
+type Backend struct {
+	Dialect    goose.Dialect
+	DB         *sql.DB
+	Store      database.Store
+	Migrations fs.FS
+}
+
+// Open connects to the database at dbURL. The scheme selects the engine:
+// postgres:// or postgresql:// for Postgres, sqlite: followed by a file path
+// for SQLite.
+func Open(dbURL string) (*Backend, error) {
+	var backend *Backend
+	switch {
+	case strings.HasPrefix(dbURL, "postgres://"), strings.HasPrefix(dbURL, "postgresql://"):
+		db, err := sql.Open("postgres", dbURL)
+		if err != nil {
+			return nil, err
+		}
+		backend = &Backend{
+			Dialect:    DialectPostgres,
+			DB:         db,
+			Store:      database.NewStore(db),
+			Migrations: schema.FS,
+		}
+	case strings.HasPrefix(dbURL, "sqlite:"):
+		db, err := sql.Open("sqlite3", sqliteDSN(dbURL))
+		if err != nil {
+			return nil, err
+		}
+		backend = &Backend{
+			Dialect:    DialectSQLite,
+			DB:         db,
+			Store:      sqlite.NewStore(db),
+			Migrations: sqliteschema.FS,
+		}
+	default:
+		return nil, fmt.Errorf("unsupported db_url %q, use postgres://... or sqlite:<path>", redact(dbURL))
+	}
+
+	if err := backend.DB.Ping(); err != nil {
+		backend.DB.Close()
+		return nil, err
+	}
+	return backend, nil
+}
+
+// sqliteDSN turns sqlite:path, sqlite://path or sqlite:///absolute/path into
+// a go-sqlite3 file URI.
+func sqliteDSN(dbURL string) string {
+	path := strings.TrimPrefix(strings.TrimPrefix(dbURL, "sqlite:"), "//")
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return "file:" + path + separator + sqliteOptions
+}
+
+// redact hides everything after the scheme, which may contain a password.
+func redact(dbURL string) string {
+	if scheme, _, ok := strings.Cut(dbURL, ":"); ok {
+		return scheme + ":..."
+	}
+	return "..."
+}
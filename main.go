@@ -1,56 +1,79 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/Pizzu/gator/internal/cmd"
 	"github.com/Pizzu/gator/internal/config"
-	"github.com/Pizzu/gator/internal/database"
+	"github.com/Pizzu/gator/internal/logging"
+	"github.com/Pizzu/gator/internal/storage"
 	"github.com/charmbracelet/log"
-	_ "github.com/lib/pq"
 )
 
 func main() {
-	logger := log.New(os.Stderr)
+	os.Exit(run())
+}
 
+// run runs the command given on the command line and returns the exit
+// code. Exiting is left to main so the deferred cleanup here, closing the
+// database and the log file, always runs.
+func run() int {
 	cfg, err := config.Read()
 	if err != nil {
-		logger.Fatalf("error reading config: %v", err)
+		log.Error("Error reading config", "error", err)
+		return 1
 	}
 
-	db, err := openDB(cfg.DbURL)
+	logger, logFile, err := logging.New(logging.Options{
+		Format: envOr("GATOR_LOG_FORMAT", cfg.LogFormat),
+		Level:  envOr("GATOR_LOG_LEVEL", cfg.LogLevel),
+		File:   envOr("GATOR_LOG_FILE", cfg.LogFile),
+	})
+	if err != nil {
+		log.Error("Error configuring logging", "error", err)
+		return 1
+	}
+	defer logFile.Close()
+
+	backend, err := storage.Open(cfg.DbURL)
 
 	if err != nil {
-		logger.Fatal(err.Error())
+		logger.Error("Couldn't connect to the database", "error", err)
+		return 1
 	}
 
-	defer closeDB(db, logger)
+	defer closeDB(backend.DB, logger)
 
-	dbQueries := database.New(db)
+	programState := cmd.NewState(&cfg, backend, logger)
 
-	programState := cmd.NewState(&cfg, dbQueries, logger)
+	// Cancelled on Ctrl-C or when the process is asked to stop, so long
+	// running commands can wind down instead of being killed mid-query.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	if err := cmd.Execute(programState); err != nil {
-		logger.Fatal(err.Error())
+	if err := cmd.Execute(ctx, programState); err != nil {
+		logger.Error(err.Error())
+		return 1
 	}
 
+	return 0
 }
 
-func openDB(dsn string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", dsn)
+func closeDB(db *sql.DB, logger *log.Logger) {
+	err := db.Close()
 	if err != nil {
-		return nil, err
-	}
-	if err = db.Ping(); err != nil {
-		return nil, err
+		logger.Error("Error while closing DB connection", "error", err)
 	}
-	return db, nil
 }
 
-func closeDB(db *sql.DB, logger *log.Logger) {
-	err := db.Close()
-	if err != nil {
-		logger.Fatal("Error while closing DB connection")
+// envOr returns the environment variable key if it is set, or fallback.
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
 	}
+	return fallback
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, key := range []string{"GATOR_LOG_FORMAT", "GATOR_LOG_LEVEL", "GATOR_LOG_FILE"} {
+		t.Setenv(key, "")
+	}
+	logPath := filepath.Join(home, "gator.log")
+	config := fmt.Sprintf(`{"db_url": "sqlite:%s", "log_file": "%s"}`, filepath.Join(home, "gator.db"), logPath)
+	if err := os.WriteFile(filepath.Join(home, ".gatorconfig.json"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	args := os.Args
+	t.Cleanup(func() { os.Args = args })
+
+	// A failing command returns an exit code instead of exiting, so its
+	// error still reaches the log file.
+	os.Args = []string{"gator", "users"}
+	if code := run(); code != 1 {
+		t.Errorf("run users on an empty database = %d, want 1", code)
+	}
+	logs, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(logs), "migrate up") {
+		t.Errorf("log file misses the error:\n%s", logs)
+	}
+
+	os.Args = []string{"gator", "migrate", "up"}
+	if code := run(); code != 0 {
+		t.Errorf("run migrate up = %d, want 0", code)
+	}
+}
@@ -18,11 +18,18 @@ INNER JOIN users u on user_id = u.id
 INNER JOIN feeds f on feed_id = f.id;
 
 -- name: GetFeedFollowsForUser :many
-SELECT fs.*, f.name AS feed_name, u.name AS user_name
+SELECT fs.*, f.name AS feed_name, u.name AS user_name, folders.name AS folder_name, (
+    SELECT COUNT(*)
+    FROM posts p
+    LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = fs.user_id
+    WHERE p.feed_id = fs.feed_id AND ps.read IS NOT TRUE
+) AS unread_count
 FROM feed_follows fs
 INNER JOIN users u on u.id = fs.user_id
 INNER JOIN feeds f on f.id = fs.feed_id
-WHERE fs.user_id = $1;
+LEFT JOIN folders ON folders.id = fs.folder_id
+WHERE fs.user_id = $1
+ORDER BY folders.name NULLS FIRST, f.name;
 
 -- name: UnfollowFeed :exec
 DELETE FROM feed_follows fs
@@ -11,24 +11,117 @@ VALUES (
 RETURNING *;
 
 -- name: GetAllFeeds :many
-SELECT f.name, f.url, u.name as author_name
+-- Returns a page of feeds in the order they were added, after the given
+-- (created_at, id) cursor if any.
+SELECT f.id, f.created_at, f.name, f.url, u.name as author_name
 FROM feeds f
-INNER JOIN users u ON f.user_id = u.id;
+INNER JOIN users u ON f.user_id = u.id
+WHERE sqlc.narg(after_created_at)::timestamp IS NULL
+   OR (f.created_at, f.id) > (sqlc.narg(after_created_at), sqlc.narg(after_id)::uuid)
+ORDER BY f.created_at, f.id
+LIMIT sqlc.arg(page_size);
 
 -- name: GetFeedByUrl :one
 SELECT *
 FROM feeds
 WHERE url = $1;
 
+-- name: GetFeedsByName :many
+SELECT *
+FROM feeds
+WHERE name = $1;
+
 -- name: MarkFeedFetched :one
+-- Only the process holding the lease can record the outcome of a fetch, for
+-- any other no row is returned.
+UPDATE feeds
+SET last_fetched_at = NOW(),
+    updated_at = NOW(),
+    poll_interval_seconds = sqlc.arg(poll_interval_seconds)::int,
+    next_fetch_at = NOW() + (sqlc.arg(poll_interval_seconds)::int * INTERVAL '1 second'),
+    consecutive_failures = 0,
+    last_error = NULL,
+    last_success_at = NOW(),
+    disabled_at = NULL,
+    last_http_status = sqlc.arg(http_status)::int,
+    lease_owner = NULL,
+    lease_expires_at = NULL
+WHERE id = sqlc.arg(id) AND lease_owner = sqlc.arg(lease_owner)::text
+RETURNING *;
+
+-- name: MarkFeedFailed :one
+-- Like MarkFeedFetched, returns no row once the lease was lost.
 UPDATE feeds
 SET last_fetched_at = NOW(),
+    updated_at = NOW(),
+    next_fetch_at = NOW() + (sqlc.arg(backoff_seconds)::int * INTERVAL '1 second'),
+    consecutive_failures = consecutive_failures + 1,
+    last_error = sqlc.arg(last_error)::text,
+    last_http_status = sqlc.narg(http_status),
+    disabled_at = CASE WHEN sqlc.arg(disable)::bool THEN NOW() ELSE NULL END,
+    lease_owner = NULL,
+    lease_expires_at = NULL
+WHERE id = sqlc.arg(id) AND lease_owner = sqlc.arg(lease_owner)::text
+RETURNING *;
+
+-- name: ClaimNextFeedToFetch :one
+UPDATE feeds
+SET lease_owner = sqlc.arg(lease_owner)::text,
+    lease_expires_at = NOW() + (sqlc.arg(lease_seconds)::int * INTERVAL '1 second')
+WHERE id = (
+    SELECT id
+    FROM feeds
+    WHERE disabled_at IS NULL
+    AND (lease_expires_at IS NULL OR lease_expires_at < NOW())
+    AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+    ORDER BY next_fetch_at ASC NULLS FIRST
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ClaimFeed :one
+UPDATE feeds
+SET lease_owner = sqlc.arg(lease_owner)::text,
+    lease_expires_at = NOW() + (sqlc.arg(lease_seconds)::int * INTERVAL '1 second')
+WHERE id = sqlc.arg(id)
+AND (lease_expires_at IS NULL OR lease_expires_at < NOW())
+RETURNING *;
+
+-- name: ReleaseFeedLease :exec
+UPDATE feeds
+SET lease_owner = NULL,
+    lease_expires_at = NULL
+WHERE id = $1 AND lease_owner = $2;
+
+-- name: UpdateFeedSchedule :one
+UPDATE feeds
+SET fetch_interval_seconds = $3,
+    adaptive_polling = $4,
+    poll_interval_seconds = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: GetUnhealthyFeeds :many
+SELECT *
+FROM feeds
+WHERE consecutive_failures > 0 OR disabled_at IS NOT NULL
+ORDER BY disabled_at ASC NULLS LAST, consecutive_failures DESC, name;
+
+-- name: EnableFeed :one
+UPDATE feeds
+SET consecutive_failures = 0,
+    disabled_at = NULL,
+    next_fetch_at = NULL,
     updated_at = NOW()
-WHERE id = $1
+WHERE url = $1
 RETURNING *;
 
--- name: GetNextFeedToFetch :one
-SELECT * 
+-- name: GetFetchQueueStats :one
+SELECT
+    COUNT(*) AS due_feeds,
+    COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(COALESCE(next_fetch_at, created_at))), 0)::float8 AS oldest_due_seconds
 FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1; 
\ No newline at end of file
+WHERE disabled_at IS NULL
+AND (next_fetch_at IS NULL OR next_fetch_at <= NOW());
@@ -0,0 +1,41 @@
+-- name: CreateFetchLog :exec
+INSERT INTO fetch_log (id, feed_id, started_at, duration_ms, http_status, bytes_received, items_parsed, new_posts, error)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+);
+
+-- name: GetFetchLogForFeed :many
+SELECT *
+FROM fetch_log
+WHERE feed_id = $1
+ORDER BY started_at DESC
+LIMIT $2;
+
+-- name: GetFetchStatsForFeed :one
+SELECT
+    COUNT(*) AS fetches,
+    COUNT(error) AS failures,
+    COALESCE(AVG(duration_ms), 0)::float8 AS avg_duration_ms,
+    COALESCE(MAX(duration_ms), 0)::int AS max_duration_ms,
+    COALESCE(SUM(new_posts), 0)::int AS new_posts
+FROM fetch_log
+WHERE feed_id = $1;
+
+-- name: GetLastFetchWithNewPosts :one
+SELECT *
+FROM fetch_log
+WHERE feed_id = $1 AND new_posts > 0
+ORDER BY started_at DESC
+LIMIT 1;
+
+-- name: PruneFetchLog :execrows
+DELETE FROM fetch_log
+WHERE started_at < $1;
@@ -0,0 +1,41 @@
+-- name: EnsureJob :exec
+INSERT INTO jobs (name, created_at, updated_at, schedule)
+VALUES ($1, $2, $2, $3)
+ON CONFLICT (name) DO NOTHING;
+
+-- name: GetJobs :many
+SELECT *
+FROM jobs
+ORDER BY name;
+
+-- name: GetJob :one
+SELECT *
+FROM jobs
+WHERE name = $1;
+
+-- name: UpdateJobSchedule :one
+UPDATE jobs
+SET schedule = $2,
+    updated_at = NOW()
+WHERE name = $1
+RETURNING *;
+
+-- name: StartJobRun :one
+UPDATE jobs
+SET running_owner = sqlc.arg(running_owner),
+    running_until = NOW() + (sqlc.arg(timeout_seconds)::int * INTERVAL '1 second'),
+    last_started_at = NOW()
+WHERE name = sqlc.arg(name)
+  AND (running_until IS NULL OR running_until < NOW())
+RETURNING *;
+
+-- name: FinishJobRun :exec
+UPDATE jobs
+SET running_owner = NULL,
+    running_until = NULL,
+    last_finished_at = NOW(),
+    last_duration_ms = sqlc.arg(duration_ms),
+    last_status = sqlc.arg(status),
+    last_error = sqlc.narg(error),
+    last_run_by = sqlc.arg(running_owner)
+WHERE name = sqlc.arg(name) AND running_owner = sqlc.arg(running_owner);
@@ -0,0 +1,18 @@
+-- name: TryAdvisoryLock :one
+SELECT pg_try_advisory_lock(sqlc.arg(key)::bigint) AS acquired;
+
+-- name: AdvisoryUnlock :one
+SELECT pg_advisory_unlock(sqlc.arg(key)::bigint) AS released;
+
+-- name: SetApplicationName :exec
+SELECT set_config('application_name', sqlc.arg(name)::text, false);
+
+-- name: GetAdvisoryLockHolder :one
+SELECT pg_stat_activity.application_name
+FROM pg_locks
+JOIN pg_stat_activity ON pg_stat_activity.pid = pg_locks.pid
+WHERE pg_locks.locktype = 'advisory'
+  AND pg_locks.granted
+  AND pg_locks.objsubid = 1
+  AND ((pg_locks.classid::bigint << 32) | pg_locks.objid::bigint) = sqlc.arg(key)::bigint
+LIMIT 1;
@@ -1,21 +1,134 @@
--- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES (
-    $1,
-    $2,
-    $3,
-    $4,
-    $5,
-    $6,
-    $7,
-    $8
+-- name: UpsertPosts :many
+-- Posts that were pruned are not inserted again.
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash)
+SELECT
+    item.id,
+    sqlc.arg(created_at)::timestamp,
+    sqlc.arg(created_at)::timestamp,
+    item.title,
+    item.url,
+    item.description,
+    NULLIF(item.published_at, '')::timestamp,
+    sqlc.arg(feed_id)::uuid,
+    item.guid,
+    item.content_hash
+FROM unnest(
+    sqlc.arg(ids)::uuid[],
+    sqlc.arg(titles)::text[],
+    sqlc.arg(urls)::text[],
+    sqlc.arg(descriptions)::text[],
+    sqlc.arg(published_ats)::text[],
+    sqlc.arg(guids)::text[],
+    sqlc.arg(content_hashes)::text[]
+) AS item(id, title, url, description, published_at, guid, content_hash)
+WHERE NOT EXISTS (
+    SELECT 1 FROM pruned_posts
+    WHERE pruned_posts.feed_id = sqlc.arg(feed_id) AND pruned_posts.guid = item.guid
 )
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    published_at = EXCLUDED.published_at,
+    content_hash = EXCLUDED.content_hash,
+    updated_at = EXCLUDED.updated_at
+WHERE posts.content_hash IS DISTINCT FROM EXCLUDED.content_hash
 RETURNING *;
 
+-- name: GetLegacyPostUrls :many
+SELECT url
+FROM posts
+WHERE feed_id = $1 AND guid IS NULL;
+
+-- name: AdoptLegacyPost :exec
+UPDATE posts
+SET guid = $3
+WHERE feed_id = $1 AND url = $2 AND guid IS NULL;
+
 -- name: GetPostsForUser :many
-SELECT posts.*, feeds.name AS feed_name FROM posts
+-- Returns a page of the posts of the user's followed feeds, newest first,
+-- older than the given (published_at, id) cursor if any. Posts without a
+-- publication date are sorted by when they were added.
+SELECT posts.*, feeds.name AS feed_name, COALESCE(post_states.read, FALSE)::bool AS read
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+  AND (NOT sqlc.arg(unread_only)::bool OR post_states.read IS NOT TRUE)
+  AND (sqlc.narg(folder_id)::uuid IS NULL OR feed_follows.folder_id = sqlc.narg(folder_id))
+  AND (sqlc.narg(tag)::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.user_id = feed_follows.user_id AND tags.name = sqlc.narg(tag)))
+  AND (sqlc.narg(since)::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) < sqlc.narg(until))
+  AND (sqlc.narg(cursor_published_at)::timestamp IS NULL
+    OR (COALESCE(posts.published_at, posts.created_at), posts.id) < (sqlc.narg(cursor_published_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC, posts.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetNewerPostsForUser :many
+-- Returns the page before the given cursor in GetPostsForUser order: the
+-- posts newer than the cursor, oldest first.
+SELECT posts.*, feeds.name AS feed_name, COALESCE(post_states.read, FALSE)::bool AS read
+FROM posts
 JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
 JOIN feeds ON posts.feed_id = feeds.id
-WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at DESC
-LIMIT $2;
\ No newline at end of file
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+  AND (NOT sqlc.arg(unread_only)::bool OR post_states.read IS NOT TRUE)
+  AND (sqlc.narg(folder_id)::uuid IS NULL OR feed_follows.folder_id = sqlc.narg(folder_id))
+  AND (sqlc.narg(tag)::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.user_id = feed_follows.user_id AND tags.name = sqlc.narg(tag)))
+  AND (sqlc.narg(since)::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) < sqlc.narg(until))
+  AND (sqlc.narg(cursor_published_at)::timestamp IS NULL
+    OR (COALESCE(posts.published_at, posts.created_at), posts.id) > (sqlc.narg(cursor_published_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY COALESCE(posts.published_at, posts.created_at) ASC, posts.id ASC
+LIMIT sqlc.arg(page_size);
+
+-- name: SavePrunedPostKeys :exec
+-- Remembers the keys of the posts PrunePosts is about to delete, so that
+-- UpsertPosts skips them while they are still in their feed. Run both in
+-- the same transaction.
+INSERT INTO pruned_posts (feed_id, guid, pruned_at)
+SELECT feed_id, guid, NOW()
+FROM posts
+WHERE guid IS NOT NULL
+  AND COALESCE(published_at, created_at) < sqlc.arg(before)::timestamp
+  AND NOT EXISTS (SELECT 1 FROM starred_posts WHERE starred_posts.post_id = posts.id)
+  AND NOT EXISTS (SELECT 1 FROM saved_posts WHERE saved_posts.post_id = posts.id)
+  AND NOT EXISTS (SELECT 1 FROM post_tags WHERE post_tags.post_id = posts.id)
+ON CONFLICT (feed_id, guid) DO NOTHING;
+
+-- name: PrunePosts :execrows
+-- Deletes posts published before the cutoff, keeping any post that a user
+-- starred, saved for later or tagged.
+DELETE FROM posts
+WHERE COALESCE(published_at, created_at) < sqlc.arg(before)::timestamp
+  AND NOT EXISTS (SELECT 1 FROM starred_posts WHERE starred_posts.post_id = posts.id)
+  AND NOT EXISTS (SELECT 1 FROM saved_posts WHERE saved_posts.post_id = posts.id)
+  AND NOT EXISTS (SELECT 1 FROM post_tags WHERE post_tags.post_id = posts.id);
+
+-- name: SearchPosts :many
+-- Searches the posts of the user's followed feeds, best matches first. The
+-- query takes the web search syntax: quoted phrases, OR and -excluded.
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description,
+    posts.published_at, posts.feed_id, posts.guid, posts.content_hash, feeds.name AS feed_name,
+    ts_rank(post_search_document(posts.title, posts.description), query)::float8 AS rank,
+    ts_headline('english', posts.title || ' ' || COALESCE(posts.description, ''), query,
+        'StartSel="**", StopSel="**", MaxWords=30, MinWords=10')::text AS snippet
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON feeds.id = posts.feed_id
+CROSS JOIN websearch_to_tsquery('english', sqlc.arg(query)) AS query
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+  AND post_search_document(posts.title, posts.description) @@ query
+  AND (sqlc.narg(feed_id)::uuid IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+  AND (sqlc.narg(since)::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) < sqlc.narg(until))
+ORDER BY rank DESC, posts.published_at DESC NULLS LAST
+LIMIT sqlc.arg(page_size);
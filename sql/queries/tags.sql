@@ -0,0 +1,55 @@
+-- name: UpsertTag :one
+-- Returns the user's tag with the name, creating it if needed.
+INSERT INTO tags (id, created_at, user_id, name)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (user_id, name) DO UPDATE
+SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: TagPost :execrows
+-- Tags a post of one of the feeds the tag's user follows. Tagging it again
+-- keeps the original time but still counts as a row, so 0 means no such
+-- post.
+INSERT INTO post_tags (tag_id, post_id, created_at)
+SELECT tags.id, posts.id, sqlc.arg(created_at)
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN tags ON tags.user_id = feed_follows.user_id
+WHERE tags.id = sqlc.arg(tag_id) AND posts.id = sqlc.arg(post_id)
+ON CONFLICT (tag_id, post_id) DO UPDATE
+SET created_at = post_tags.created_at;
+
+-- name: UntagPost :execrows
+DELETE FROM post_tags
+WHERE post_id = sqlc.arg(post_id)
+  AND tag_id IN (SELECT id FROM tags WHERE user_id = sqlc.arg(user_id) AND name = sqlc.arg(name));
+
+-- name: DeleteUnusedTags :exec
+DELETE FROM tags
+WHERE user_id = $1
+  AND NOT EXISTS (SELECT 1 FROM post_tags WHERE post_tags.tag_id = tags.id);
+
+-- name: GetTagsForUser :many
+SELECT tags.*, COUNT(post_tags.post_id) AS post_count
+FROM tags
+LEFT JOIN post_tags ON post_tags.tag_id = tags.id
+WHERE tags.user_id = $1
+GROUP BY tags.id
+ORDER BY tags.name;
+
+-- name: GetTaggedPostsForUser :many
+-- Returns every tagging of the user's posts, optionally of a single tag, by
+-- tag and then in the order the posts were tagged.
+SELECT tags.name AS tag, post_tags.created_at AS tagged_at, posts.*, feeds.name AS feed_name
+FROM post_tags
+JOIN tags ON tags.id = post_tags.tag_id
+JOIN posts ON posts.id = post_tags.post_id
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE tags.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(tag)::text IS NULL OR tags.name = sqlc.narg(tag))
+ORDER BY tags.name, post_tags.created_at, posts.id;
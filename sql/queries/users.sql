@@ -13,7 +13,13 @@ SELECT * FROM users
 WHERE name = $1;
 
 -- name: GetUsers :many
-SELECT * FROM users;
+-- Returns a page of users in the order they registered, after the given
+-- (created_at, id) cursor if any.
+SELECT * FROM users
+WHERE sqlc.narg(after_created_at)::timestamp IS NULL
+   OR (created_at, id) > (sqlc.narg(after_created_at), sqlc.narg(after_id)::uuid)
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_size);
 
 -- name: DeleteAllUsers :exec
 DELETE FROM users;
\ No newline at end of file
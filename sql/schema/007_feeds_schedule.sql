@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN next_fetch_at TIMESTAMP;
+ALTER TABLE feeds ADD COLUMN fetch_interval_seconds INTEGER;
+ALTER TABLE feeds ADD COLUMN poll_interval_seconds INTEGER;
+ALTER TABLE feeds ADD COLUMN adaptive_polling BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX feeds_next_fetch_at_idx ON feeds (next_fetch_at NULLS FIRST);
+
+-- +goose Down
+DROP INDEX feeds_next_fetch_at_idx;
+ALTER TABLE feeds DROP COLUMN adaptive_polling;
+ALTER TABLE feeds DROP COLUMN poll_interval_seconds;
+ALTER TABLE feeds DROP COLUMN fetch_interval_seconds;
+ALTER TABLE feeds DROP COLUMN next_fetch_at;
@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE fetch_log (
+    id UUID PRIMARY KEY,
+    feed_id UUID NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    duration_ms INTEGER NOT NULL,
+    http_status INTEGER,
+    bytes_received BIGINT NOT NULL,
+    items_parsed INTEGER NOT NULL,
+    new_posts INTEGER NOT NULL,
+    error TEXT,
+
+    CONSTRAINT fk_feeds FOREIGN KEY (feed_id)
+    REFERENCES feeds(id) ON DELETE CASCADE
+);
+
+CREATE INDEX fetch_log_feed_id_started_at_idx ON fetch_log (feed_id, started_at DESC);
+CREATE INDEX fetch_log_started_at_idx ON fetch_log (started_at);
+
+-- +goose Down
+DROP TABLE fetch_log;
@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE posts DROP CONSTRAINT posts_url_key;
+-- Existing posts keep a NULL guid until the aggregator sees them again and
+-- adopts them by url.
+ALTER TABLE posts ADD COLUMN guid TEXT;
+ALTER TABLE posts ADD COLUMN content_hash TEXT;
+CREATE UNIQUE INDEX posts_feed_id_guid_idx ON posts (feed_id, guid);
+CREATE INDEX posts_feed_id_url_idx ON posts (feed_id, url);
+
+-- +goose Down
+DROP INDEX posts_feed_id_url_idx;
+DROP INDEX posts_feed_id_guid_idx;
+ALTER TABLE posts DROP COLUMN content_hash;
+ALTER TABLE posts DROP COLUMN guid;
+-- Keep the oldest post of each url, the id breaking ties between posts
+-- added at the same time.
+DELETE FROM posts a USING posts b
+WHERE a.url = b.url AND (a.created_at, a.id) > (b.created_at, b.id);
+ALTER TABLE posts ADD CONSTRAINT posts_url_key UNIQUE (url);
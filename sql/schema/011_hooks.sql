@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE hooks (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    feed_id UUID,
+    kind TEXT NOT NULL CHECK (kind IN ('webhook', 'command')),
+    target TEXT NOT NULL,
+    secret TEXT,
+    last_delivered_at TIMESTAMP,
+    last_error TEXT,
+
+    CONSTRAINT fk_users FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_feeds FOREIGN KEY (feed_id)
+    REFERENCES feeds(id) ON DELETE CASCADE
+);
+
+CREATE INDEX hooks_user_id_idx ON hooks (user_id);
+
+-- +goose Down
+DROP TABLE hooks;
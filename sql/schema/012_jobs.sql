@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE jobs (
+    name TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    schedule TEXT NOT NULL,
+    running_owner TEXT,
+    running_until TIMESTAMP,
+    last_started_at TIMESTAMP,
+    last_finished_at TIMESTAMP,
+    last_duration_ms INTEGER,
+    last_status TEXT,
+    last_error TEXT,
+    last_run_by TEXT
+);
+
+-- +goose Down
+DROP TABLE jobs;
@@ -0,0 +1,36 @@
+-- +goose Up
+-- Starred and saved posts are kept when old posts are pruned.
+CREATE TABLE starred_posts (
+    user_id UUID NOT NULL,
+    post_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (user_id, post_id),
+
+    CONSTRAINT fk_users FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_posts FOREIGN KEY (post_id)
+    REFERENCES posts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE saved_posts (
+    user_id UUID NOT NULL,
+    post_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (user_id, post_id),
+
+    CONSTRAINT fk_users FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_posts FOREIGN KEY (post_id)
+    REFERENCES posts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX starred_posts_post_id_idx ON starred_posts (post_id);
+CREATE INDEX saved_posts_post_id_idx ON saved_posts (post_id);
+
+-- +goose Down
+DROP TABLE saved_posts;
+DROP TABLE starred_posts;
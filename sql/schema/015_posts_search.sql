@@ -0,0 +1,13 @@
+-- +goose Up
+-- Titles weigh more than descriptions when ranking search results. Posts
+-- don't store their full content, so it isn't indexed.
+ALTER TABLE posts ADD COLUMN search tsvector NOT NULL GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', title), 'A') ||
+    setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+) STORED;
+
+CREATE INDEX posts_search_idx ON posts USING GIN (search);
+
+-- +goose Down
+DROP INDEX posts_search_idx;
+ALTER TABLE posts DROP COLUMN search;
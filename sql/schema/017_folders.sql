@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE folders (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    name TEXT NOT NULL,
+
+    UNIQUE (user_id, name),
+
+    CONSTRAINT fk_users FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Follows of a deleted folder are kept, outside of any folder.
+ALTER TABLE feed_follows ADD COLUMN folder_id UUID REFERENCES folders(id) ON DELETE SET NULL;
+
+CREATE INDEX feed_follows_folder_id_idx ON feed_follows (folder_id);
+
+-- +goose Down
+DROP INDEX feed_follows_folder_id_idx;
+ALTER TABLE feed_follows DROP COLUMN folder_id;
+DROP TABLE folders;
@@ -0,0 +1,8 @@
+// Package schema embeds the goose migrations that create the database
+// schema, so the binary can apply them itself.
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
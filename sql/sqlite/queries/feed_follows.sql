@@ -0,0 +1,31 @@
+-- name: CreateFeedFollow :one
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+VALUES (
+    ?1,
+    ?2,
+    ?3,
+    ?4,
+    ?5
+)
+RETURNING *,
+    (SELECT name FROM feeds WHERE feeds.id = feed_id) AS feed_name,
+    (SELECT name FROM users WHERE users.id = user_id) AS user_name;
+
+-- name: GetFeedFollowsForUser :many
+SELECT fs.*, f.name AS feed_name, u.name AS user_name, folders.name AS folder_name, (
+    SELECT COUNT(*)
+    FROM posts p
+    LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = fs.user_id
+    WHERE p.feed_id = fs.feed_id AND ps.read IS NOT TRUE
+) AS unread_count
+FROM feed_follows fs
+INNER JOIN users u on u.id = fs.user_id
+INNER JOIN feeds f on f.id = fs.feed_id
+LEFT JOIN folders ON folders.id = fs.folder_id
+WHERE fs.user_id = ?1
+ORDER BY folders.name NULLS FIRST, f.name;
+
+-- name: UnfollowFeed :exec
+DELETE FROM feed_follows
+WHERE user_id = ?1
+AND feed_id IN (SELECT id FROM feeds WHERE url = ?2);
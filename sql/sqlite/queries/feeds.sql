@@ -0,0 +1,128 @@
+-- name: CreateFeed :one
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+VALUES (
+    ?1,
+    ?2,
+    ?3,
+    ?4,
+    ?5,
+    ?6
+)
+RETURNING *;
+
+-- name: GetAllFeeds :many
+-- Returns a page of feeds in the order they were added, after the given
+-- (created_at, id) cursor if any.
+SELECT f.id, f.created_at, f.name, f.url, u.name as author_name
+FROM feeds f
+INNER JOIN users u ON f.user_id = u.id
+WHERE sqlc.narg(after_created_at) IS NULL
+   OR (f.created_at, f.id) > (sqlc.narg(after_created_at), sqlc.narg(after_id))
+ORDER BY f.created_at, f.id
+LIMIT sqlc.arg(page_size);
+
+-- name: GetFeedByUrl :one
+SELECT *
+FROM feeds
+WHERE url = ?1;
+
+-- name: GetFeedsByName :many
+SELECT *
+FROM feeds
+WHERE name = ?1;
+
+-- name: MarkFeedFetched :one
+-- Only the process holding the lease can record the outcome of a fetch, for
+-- any other no row is returned.
+UPDATE feeds
+SET last_fetched_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    poll_interval_seconds = sqlc.arg(poll_interval_seconds),
+    next_fetch_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now', '+' || sqlc.arg(poll_interval_seconds) || ' seconds'),
+    consecutive_failures = 0,
+    last_error = NULL,
+    last_success_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    disabled_at = NULL,
+    last_http_status = sqlc.arg(http_status),
+    lease_owner = NULL,
+    lease_expires_at = NULL
+WHERE id = sqlc.arg(id) AND lease_owner = sqlc.arg(lease_owner)
+RETURNING *;
+
+-- name: MarkFeedFailed :one
+-- Like MarkFeedFetched, returns no row once the lease was lost.
+UPDATE feeds
+SET last_fetched_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    next_fetch_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now', '+' || sqlc.arg(backoff_seconds) || ' seconds'),
+    consecutive_failures = consecutive_failures + 1,
+    last_error = sqlc.arg(last_error),
+    last_http_status = sqlc.narg(http_status),
+    disabled_at = CASE WHEN sqlc.arg(disable) THEN strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') ELSE NULL END,
+    lease_owner = NULL,
+    lease_expires_at = NULL
+WHERE id = sqlc.arg(id) AND lease_owner = sqlc.arg(lease_owner)
+RETURNING *;
+
+-- name: ClaimNextFeedToFetch :one
+-- SQLite runs one write at a time, so the subquery cannot hand the same
+-- feed to two workers and needs no row locks.
+UPDATE feeds
+SET lease_owner = sqlc.arg(lease_owner),
+    lease_expires_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now', '+' || sqlc.arg(lease_seconds) || ' seconds')
+WHERE id = (
+    SELECT id
+    FROM feeds
+    WHERE disabled_at IS NULL
+    AND (lease_expires_at IS NULL OR lease_expires_at < strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+    AND (next_fetch_at IS NULL OR next_fetch_at <= strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+    ORDER BY next_fetch_at ASC NULLS FIRST
+    LIMIT 1
+)
+RETURNING *;
+
+-- name: ClaimFeed :one
+UPDATE feeds
+SET lease_owner = sqlc.arg(lease_owner),
+    lease_expires_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now', '+' || sqlc.arg(lease_seconds) || ' seconds')
+WHERE id = sqlc.arg(id)
+AND (lease_expires_at IS NULL OR lease_expires_at < strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+RETURNING *;
+
+-- name: ReleaseFeedLease :exec
+UPDATE feeds
+SET lease_owner = NULL,
+    lease_expires_at = NULL
+WHERE id = ?1 AND lease_owner = ?2;
+
+-- name: UpdateFeedSchedule :one
+UPDATE feeds
+SET fetch_interval_seconds = ?3,
+    adaptive_polling = ?4,
+    poll_interval_seconds = NULL,
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE id = ?1 AND user_id = ?2
+RETURNING *;
+
+-- name: GetUnhealthyFeeds :many
+SELECT *
+FROM feeds
+WHERE consecutive_failures > 0 OR disabled_at IS NOT NULL
+ORDER BY disabled_at ASC NULLS LAST, consecutive_failures DESC, name;
+
+-- name: EnableFeed :one
+UPDATE feeds
+SET consecutive_failures = 0,
+    disabled_at = NULL,
+    next_fetch_at = NULL,
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE url = ?1
+RETURNING *;
+
+-- name: GetFetchQueueStats :one
+SELECT
+    COUNT(*) AS due_feeds,
+    CAST(COALESCE((julianday('now') - julianday(MIN(COALESCE(next_fetch_at, created_at)))) * 86400, 0) AS REAL) AS oldest_due_seconds
+FROM feeds
+WHERE disabled_at IS NULL
+AND (next_fetch_at IS NULL OR next_fetch_at <= strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'));
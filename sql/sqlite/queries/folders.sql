@@ -0,0 +1,50 @@
+-- name: CreateFolder :one
+INSERT INTO folders (id, created_at, updated_at, user_id, name)
+VALUES (
+    ?1,
+    ?2,
+    ?3,
+    ?4,
+    ?5
+)
+RETURNING *;
+
+-- name: GetFoldersForUser :many
+SELECT folders.*, (
+    SELECT COUNT(*)
+    FROM feed_follows
+    WHERE feed_follows.folder_id = folders.id
+) AS feed_count, (
+    SELECT COUNT(*)
+    FROM posts p
+    JOIN feed_follows ff ON ff.feed_id = p.feed_id
+    LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+    WHERE ff.folder_id = folders.id AND ps.read IS NOT TRUE
+) AS unread_count
+FROM folders
+WHERE user_id = ?1
+ORDER BY name;
+
+-- name: GetFolderByName :one
+SELECT * FROM folders
+WHERE user_id = ?1 AND name = ?2;
+
+-- name: RenameFolder :one
+UPDATE folders
+SET name = sqlc.arg(new_name),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE user_id = sqlc.arg(user_id) AND name = sqlc.arg(name)
+RETURNING *;
+
+-- name: DeleteFolder :execrows
+DELETE FROM folders
+WHERE user_id = ?1 AND name = ?2;
+
+-- name: MoveFeedFollow :execrows
+-- Moves the user's follow of the feed into a folder, or out of any folder
+-- when folder_id is null.
+UPDATE feed_follows
+SET folder_id = sqlc.narg(folder_id),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE user_id = sqlc.arg(user_id)
+  AND feed_id IN (SELECT id FROM feeds WHERE url = sqlc.arg(url));
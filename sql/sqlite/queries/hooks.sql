@@ -0,0 +1,44 @@
+-- name: CreateHook :one
+INSERT INTO hooks (id, created_at, updated_at, user_id, feed_id, kind, target, secret)
+VALUES (
+    ?1,
+    ?2,
+    ?3,
+    ?4,
+    ?5,
+    ?6,
+    ?7,
+    ?8
+)
+RETURNING *;
+
+-- name: GetHooksForUser :many
+SELECT hooks.*, feeds.url AS feed_url
+FROM hooks
+LEFT JOIN feeds ON feeds.id = hooks.feed_id
+WHERE hooks.user_id = ?1
+ORDER BY hooks.created_at;
+
+-- name: GetHooksForFeed :many
+SELECT hooks.*, users.name AS user_name
+FROM hooks
+JOIN users ON users.id = hooks.user_id
+JOIN feed_follows ON feed_follows.user_id = hooks.user_id AND feed_follows.feed_id = ?1
+WHERE hooks.feed_id IS NULL OR hooks.feed_id = ?1;
+
+-- name: DeleteHook :execrows
+DELETE FROM hooks
+WHERE id = ?1 AND user_id = ?2;
+
+-- name: MarkHookDelivered :exec
+UPDATE hooks
+SET last_delivered_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    last_error = NULL,
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE id = ?1;
+
+-- name: MarkHookFailed :exec
+UPDATE hooks
+SET last_error = ?2,
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE id = ?1;
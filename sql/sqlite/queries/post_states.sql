@@ -0,0 +1,28 @@
+-- name: SetPostsRead :execrows
+-- Marks the posts of the user's followed feeds matching every given filter
+-- as read or unread, returning how many changed. Posts without a state are
+-- unread already, so marking them unread adds no row.
+INSERT INTO post_states (user_id, post_id, created_at, updated_at, read, read_at)
+SELECT
+    feed_follows.user_id,
+    posts.id,
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    sqlc.arg(read),
+    CASE WHEN sqlc.arg(read) THEN strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') END
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(post_id) IS NULL OR posts.id = sqlc.narg(post_id))
+  AND (sqlc.narg(feed_id) IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+  AND (sqlc.narg(folder_id) IS NULL OR feed_follows.folder_id = sqlc.narg(folder_id))
+  AND (sqlc.narg(before) IS NULL OR COALESCE(posts.published_at, posts.created_at) < sqlc.narg(before))
+  AND (sqlc.arg(read) OR EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.user_id = feed_follows.user_id AND post_states.post_id = posts.id
+  ))
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET read = excluded.read,
+    read_at = excluded.read_at,
+    updated_at = excluded.updated_at
+WHERE post_states.read IS NOT excluded.read;
@@ -0,0 +1,128 @@
+-- name: UpsertPost :many
+-- SQLite has no arrays, so posts are upserted one at a time. No row is
+-- returned when the post exists and its content is unchanged, or when it
+-- was pruned.
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash)
+SELECT
+    ?1,
+    ?2,
+    ?2,
+    ?3,
+    ?4,
+    ?5,
+    ?6,
+    ?7,
+    ?8,
+    ?9
+WHERE NOT EXISTS (
+    SELECT 1 FROM pruned_posts
+    WHERE pruned_posts.feed_id = ?7 AND pruned_posts.guid = ?8
+)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = excluded.title,
+    url = excluded.url,
+    description = excluded.description,
+    published_at = excluded.published_at,
+    content_hash = excluded.content_hash,
+    updated_at = excluded.updated_at
+WHERE posts.content_hash IS NOT excluded.content_hash
+RETURNING *;
+
+-- name: GetLegacyPostUrls :many
+SELECT url
+FROM posts
+WHERE feed_id = ?1 AND guid IS NULL;
+
+-- name: AdoptLegacyPost :exec
+UPDATE posts
+SET guid = ?3
+WHERE feed_id = ?1 AND url = ?2 AND guid IS NULL;
+
+-- name: GetPostsForUser :many
+-- Returns a page of the posts of the user's followed feeds, newest first,
+-- older than the given (published_at, id) cursor if any. Posts without a
+-- publication date are sorted by when they were added.
+SELECT posts.*, feeds.name AS feed_name, COALESCE(post_states.read, FALSE) AS read
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+  AND (NOT sqlc.arg(unread_only) OR post_states.read IS NOT TRUE)
+  AND (sqlc.narg(folder_id) IS NULL OR feed_follows.folder_id = sqlc.narg(folder_id))
+  AND (sqlc.narg(tag) IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.user_id = feed_follows.user_id AND tags.name = sqlc.narg(tag)))
+  AND (sqlc.narg(since) IS NULL OR COALESCE(posts.published_at, posts.created_at) >= sqlc.narg(since))
+  AND (sqlc.narg(until) IS NULL OR COALESCE(posts.published_at, posts.created_at) < sqlc.narg(until))
+  AND (sqlc.narg(cursor_published_at) IS NULL
+    OR (COALESCE(posts.published_at, posts.created_at), posts.id) < (sqlc.narg(cursor_published_at), sqlc.narg(cursor_id)))
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC, posts.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetNewerPostsForUser :many
+-- Returns the page before the given cursor in GetPostsForUser order: the
+-- posts newer than the cursor, oldest first.
+SELECT posts.*, feeds.name AS feed_name, COALESCE(post_states.read, FALSE) AS read
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+  AND (NOT sqlc.arg(unread_only) OR post_states.read IS NOT TRUE)
+  AND (sqlc.narg(folder_id) IS NULL OR feed_follows.folder_id = sqlc.narg(folder_id))
+  AND (sqlc.narg(tag) IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.user_id = feed_follows.user_id AND tags.name = sqlc.narg(tag)))
+  AND (sqlc.narg(since) IS NULL OR COALESCE(posts.published_at, posts.created_at) >= sqlc.narg(since))
+  AND (sqlc.narg(until) IS NULL OR COALESCE(posts.published_at, posts.created_at) < sqlc.narg(until))
+  AND (sqlc.narg(cursor_published_at) IS NULL
+    OR (COALESCE(posts.published_at, posts.created_at), posts.id) > (sqlc.narg(cursor_published_at), sqlc.narg(cursor_id)))
+ORDER BY COALESCE(posts.published_at, posts.created_at) ASC, posts.id ASC
+LIMIT sqlc.arg(page_size);
+
+-- name: SavePrunedPostKeys :exec
+-- Remembers the keys of the posts PrunePosts is about to delete, so that
+-- UpsertPost skips them while they are still in their feed. Run both in
+-- the same transaction.
+INSERT INTO pruned_posts (feed_id, guid, pruned_at)
+SELECT feed_id, guid, strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+FROM posts
+WHERE guid IS NOT NULL
+  AND COALESCE(published_at, created_at) < sqlc.arg(before)
+  AND NOT EXISTS (SELECT 1 FROM starred_posts WHERE starred_posts.post_id = posts.id)
+  AND NOT EXISTS (SELECT 1 FROM saved_posts WHERE saved_posts.post_id = posts.id)
+  AND NOT EXISTS (SELECT 1 FROM post_tags WHERE post_tags.post_id = posts.id)
+ON CONFLICT (feed_id, guid) DO NOTHING;
+
+-- name: PrunePosts :execrows
+-- Deletes posts published before the cutoff, keeping any post that a user
+-- starred, saved for later or tagged.
+DELETE FROM posts
+WHERE COALESCE(published_at, created_at) < sqlc.arg(before)
+  AND NOT EXISTS (SELECT 1 FROM starred_posts WHERE starred_posts.post_id = posts.id)
+  AND NOT EXISTS (SELECT 1 FROM saved_posts WHERE saved_posts.post_id = posts.id)
+  AND NOT EXISTS (SELECT 1 FROM post_tags WHERE post_tags.post_id = posts.id);
+
+-- name: SearchPosts :many
+-- Searches the posts of the user's followed feeds, best matches first. The
+-- query takes the FTS4 syntax: quoted phrases, OR and NOT. FTS4 has no
+-- ranking function, so the rank is the number of matched terms.
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description,
+    posts.published_at, posts.feed_id, posts.guid, posts.content_hash, feeds.name AS feed_name,
+    (length(offsets(posts_fts)) - length(replace(offsets(posts_fts), ' ', '')) + 1) / 4.0 AS rank,
+    snippet(posts_fts, '**', '**', '...', -1, 30) AS snippet
+FROM posts_fts
+JOIN post_docids ON post_docids.docid = posts_fts.docid
+JOIN posts ON posts.id = post_docids.post_id
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE posts_fts MATCH sqlc.arg(query)
+  AND feed_follows.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(feed_id) IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+  AND (sqlc.narg(since) IS NULL OR COALESCE(posts.published_at, posts.created_at) >= sqlc.narg(since))
+  AND (sqlc.narg(until) IS NULL OR COALESCE(posts.published_at, posts.created_at) < sqlc.narg(until))
+ORDER BY rank DESC, posts.published_at DESC NULLS LAST
+LIMIT sqlc.arg(page_size);
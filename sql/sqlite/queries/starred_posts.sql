@@ -0,0 +1,49 @@
+-- name: StarPost :execrows
+-- Stars a post of one of the user's followed feeds. Starring it again keeps
+-- the original time but still counts as a row, so 0 means no such post.
+INSERT INTO starred_posts (user_id, post_id, created_at)
+SELECT feed_follows.user_id, posts.id, sqlc.arg(created_at)
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg(user_id) AND posts.id = sqlc.arg(post_id)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET created_at = starred_posts.created_at;
+
+-- name: UnstarPost :execrows
+DELETE FROM starred_posts
+WHERE user_id = ?1 AND post_id = ?2;
+
+-- name: GetStarredPostsForUser :many
+SELECT posts.*, feeds.name AS feed_name, starred_posts.created_at AS starred_at
+FROM starred_posts
+JOIN posts ON posts.id = starred_posts.post_id
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE starred_posts.user_id = ?1
+ORDER BY starred_posts.created_at DESC
+LIMIT ?2;
+
+-- name: SavePost :execrows
+-- Adds a post of one of the user's followed feeds to their read later list.
+-- Saving it again keeps its place but still counts as a row, so 0 means no
+-- such post.
+INSERT INTO saved_posts (user_id, post_id, created_at)
+SELECT feed_follows.user_id, posts.id, sqlc.arg(created_at)
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg(user_id) AND posts.id = sqlc.arg(post_id)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET created_at = saved_posts.created_at;
+
+-- name: UnsavePost :execrows
+DELETE FROM saved_posts
+WHERE user_id = ?1 AND post_id = ?2;
+
+-- name: GetSavedPostsForUser :many
+-- Returns the read later list, oldest first.
+SELECT posts.*, feeds.name AS feed_name, saved_posts.created_at AS saved_at
+FROM saved_posts
+JOIN posts ON posts.id = saved_posts.post_id
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE saved_posts.user_id = ?1
+ORDER BY saved_posts.created_at ASC
+LIMIT ?2;
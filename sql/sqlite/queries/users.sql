@@ -0,0 +1,25 @@
+-- name: CreateUser :one
+INSERT INTO users (id, created_at, updated_at, name)
+VALUES (
+    ?1,
+    ?2,
+    ?3,
+    ?4
+)
+RETURNING *;
+
+-- name: GetUserByName :one
+SELECT * FROM users
+WHERE name = ?1;
+
+-- name: GetUsers :many
+-- Returns a page of users in the order they registered, after the given
+-- (created_at, id) cursor if any.
+SELECT * FROM users
+WHERE sqlc.narg(after_created_at) IS NULL
+   OR (created_at, id) > (sqlc.narg(after_created_at), sqlc.narg(after_id))
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_size);
+
+-- name: DeleteAllUsers :exec
+DELETE FROM users;
@@ -0,0 +1,131 @@
+-- +goose Up
+-- The SQLite schema starts out equivalent to version 012 of the Postgres
+-- schema in sql/schema. Changes to one must be mirrored in the other.
+CREATE TABLE users (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    name TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE feeds (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    name TEXT NOT NULL,
+    url TEXT UNIQUE NOT NULL,
+    user_id UUID NOT NULL,
+    last_fetched_at TIMESTAMP,
+    lease_owner TEXT,
+    lease_expires_at TIMESTAMP,
+    next_fetch_at TIMESTAMP,
+    fetch_interval_seconds INTEGER,
+    poll_interval_seconds INTEGER,
+    adaptive_polling BOOLEAN NOT NULL DEFAULT FALSE,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    last_success_at TIMESTAMP,
+    last_http_status INTEGER,
+    disabled_at TIMESTAMP,
+
+    CONSTRAINT fk_users FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX feeds_next_fetch_at_idx ON feeds (next_fetch_at);
+
+CREATE TABLE feed_follows (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    feed_id UUID NOT NULL,
+
+    UNIQUE (user_id, feed_id),
+
+    CONSTRAINT fk_users FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_feeds FOREIGN KEY (feed_id)
+    REFERENCES feeds(id) ON DELETE CASCADE
+);
+
+CREATE TABLE posts (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL,
+    description TEXT,
+    published_at TIMESTAMP,
+    feed_id UUID NOT NULL,
+    guid TEXT,
+    content_hash TEXT,
+
+    CONSTRAINT fk_feeds FOREIGN KEY (feed_id) REFERENCES feeds (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX posts_feed_id_guid_idx ON posts (feed_id, guid);
+CREATE INDEX posts_feed_id_url_idx ON posts (feed_id, url);
+
+CREATE TABLE fetch_log (
+    id UUID PRIMARY KEY,
+    feed_id UUID NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    duration_ms INTEGER NOT NULL,
+    http_status INTEGER,
+    bytes_received BIGINT NOT NULL,
+    items_parsed INTEGER NOT NULL,
+    new_posts INTEGER NOT NULL,
+    error TEXT,
+
+    CONSTRAINT fk_feeds FOREIGN KEY (feed_id)
+    REFERENCES feeds(id) ON DELETE CASCADE
+);
+
+CREATE INDEX fetch_log_feed_id_started_at_idx ON fetch_log (feed_id, started_at DESC);
+CREATE INDEX fetch_log_started_at_idx ON fetch_log (started_at);
+
+CREATE TABLE hooks (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    feed_id UUID,
+    kind TEXT NOT NULL CHECK (kind IN ('webhook', 'command')),
+    target TEXT NOT NULL,
+    secret TEXT,
+    last_delivered_at TIMESTAMP,
+    last_error TEXT,
+
+    CONSTRAINT fk_users FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_feeds FOREIGN KEY (feed_id)
+    REFERENCES feeds(id) ON DELETE CASCADE
+);
+
+CREATE INDEX hooks_user_id_idx ON hooks (user_id);
+
+CREATE TABLE jobs (
+    name TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    schedule TEXT NOT NULL,
+    running_owner TEXT,
+    running_until TIMESTAMP,
+    last_started_at TIMESTAMP,
+    last_finished_at TIMESTAMP,
+    last_duration_ms INTEGER,
+    last_status TEXT,
+    last_error TEXT,
+    last_run_by TEXT
+);
+
+-- +goose Down
+DROP TABLE jobs;
+DROP TABLE hooks;
+DROP TABLE fetch_log;
+DROP TABLE posts;
+DROP TABLE feed_follows;
+DROP TABLE feeds;
+DROP TABLE users;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- A post without a row here is unread by the user.
+CREATE TABLE post_states (
+    user_id UUID NOT NULL,
+    post_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    read BOOLEAN NOT NULL DEFAULT FALSE,
+    read_at TIMESTAMP,
+
+    PRIMARY KEY (user_id, post_id),
+
+    CONSTRAINT fk_users FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_posts FOREIGN KEY (post_id)
+    REFERENCES posts(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE post_states;
@@ -0,0 +1,44 @@
+-- +goose Up
+-- SQLite has no tsvector: posts.search holds the searchable text, so that
+-- posts have the same columns as in Postgres, and the posts_fts full-text
+-- index over it is kept in sync by triggers.
+ALTER TABLE posts ADD COLUMN search TEXT NOT NULL GENERATED ALWAYS AS (
+    title || ' ' || COALESCE(description, '')
+) VIRTUAL;
+
+CREATE VIRTUAL TABLE posts_fts USING fts4(content="posts", title, description, tokenize=porter);
+
+INSERT INTO posts_fts (docid, title, description)
+SELECT rowid, title, description FROM posts;
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_insert AFTER INSERT ON posts BEGIN
+    INSERT INTO posts_fts (docid, title, description) VALUES (new.rowid, new.title, new.description);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_before_update BEFORE UPDATE OF title, description ON posts BEGIN
+    DELETE FROM posts_fts WHERE docid = old.rowid;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_after_update AFTER UPDATE OF title, description ON posts BEGIN
+    INSERT INTO posts_fts (docid, title, description) VALUES (new.rowid, new.title, new.description);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_delete BEFORE DELETE ON posts BEGIN
+    DELETE FROM posts_fts WHERE docid = old.rowid;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER posts_fts_delete;
+DROP TRIGGER posts_fts_after_update;
+DROP TRIGGER posts_fts_before_update;
+DROP TRIGGER posts_fts_insert;
+DROP TABLE posts_fts;
+ALTER TABLE posts DROP COLUMN search;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Listings are paginated with keyset cursors, which these indexes serve.
+CREATE INDEX posts_feed_id_published_idx ON posts (feed_id, (COALESCE(published_at, created_at)), id);
+CREATE INDEX feeds_created_at_id_idx ON feeds (created_at, id);
+CREATE INDEX users_created_at_id_idx ON users (created_at, id);
+
+-- +goose Down
+DROP INDEX users_created_at_id_idx;
+DROP INDEX feeds_created_at_id_idx;
+DROP INDEX posts_feed_id_published_idx;
@@ -0,0 +1,44 @@
+-- +goose Up
+CREATE TABLE folders (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    name TEXT NOT NULL,
+
+    UNIQUE (user_id, name),
+
+    CONSTRAINT fk_users FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Follows of a deleted folder are kept, outside of any folder.
+ALTER TABLE feed_follows ADD COLUMN folder_id UUID REFERENCES folders(id) ON DELETE SET NULL;
+
+CREATE INDEX feed_follows_folder_id_idx ON feed_follows (folder_id);
+
+-- +goose Down
+-- SQLite can't drop a column with a foreign key, so rebuild the table.
+DROP INDEX feed_follows_folder_id_idx;
+
+CREATE TABLE feed_follows_old (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    feed_id UUID NOT NULL,
+
+    UNIQUE (user_id, feed_id),
+
+    CONSTRAINT fk_users FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_feeds FOREIGN KEY (feed_id)
+    REFERENCES feeds(id) ON DELETE CASCADE
+);
+
+INSERT INTO feed_follows_old (id, created_at, updated_at, user_id, feed_id)
+SELECT id, created_at, updated_at, user_id, feed_id FROM feed_follows;
+
+DROP TABLE feed_follows;
+ALTER TABLE feed_follows_old RENAME TO feed_follows;
+DROP TABLE folders;
@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE tags (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    name TEXT NOT NULL,
+
+    UNIQUE (user_id, name),
+
+    CONSTRAINT fk_users FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Tagged posts are kept when old posts are pruned.
+CREATE TABLE post_tags (
+    tag_id UUID NOT NULL,
+    post_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (tag_id, post_id),
+
+    CONSTRAINT fk_tags FOREIGN KEY (tag_id)
+    REFERENCES tags(id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_posts FOREIGN KEY (post_id)
+    REFERENCES posts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX post_tags_post_id_idx ON post_tags (post_id);
+
+-- +goose Down
+DROP TABLE post_tags;
+DROP TABLE tags;
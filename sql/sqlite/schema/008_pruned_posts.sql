@@ -0,0 +1,16 @@
+-- +goose Up
+-- The keys of pruned posts, so that posts still in their feed aren't
+-- inserted again as new ones on the next fetch.
+CREATE TABLE pruned_posts (
+    feed_id UUID NOT NULL,
+    guid TEXT NOT NULL,
+    pruned_at TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (feed_id, guid),
+
+    CONSTRAINT fk_feeds FOREIGN KEY (feed_id)
+    REFERENCES feeds(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE pruned_posts;
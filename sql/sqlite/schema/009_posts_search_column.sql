@@ -0,0 +1,9 @@
+-- +goose Up
+-- The posts_fts index reads titles and descriptions on its own, the search
+-- column only existed to match a Postgres column that is gone.
+ALTER TABLE posts DROP COLUMN search;
+
+-- +goose Down
+ALTER TABLE posts ADD COLUMN search TEXT NOT NULL GENERATED ALWAYS AS (
+    title || ' ' || COALESCE(description, '')
+) VIRTUAL;
@@ -0,0 +1,94 @@
+-- +goose Up
+-- posts_fts was keyed on the rowid of posts, which VACUUM may renumber as
+-- posts have no INTEGER PRIMARY KEY. post_docids gives every post a docid
+-- that stays put, and posts_fts reads its content through a view that
+-- exposes it as the rowid.
+DROP TRIGGER posts_fts_delete;
+DROP TRIGGER posts_fts_after_update;
+DROP TRIGGER posts_fts_before_update;
+DROP TRIGGER posts_fts_insert;
+DROP TABLE posts_fts;
+
+CREATE TABLE post_docids (
+    docid INTEGER PRIMARY KEY,
+    post_id UUID NOT NULL UNIQUE
+    REFERENCES posts(id) ON DELETE CASCADE
+);
+
+INSERT INTO post_docids (post_id)
+SELECT id FROM posts;
+
+CREATE VIEW posts_fts_content AS
+SELECT post_docids.docid AS rowid, posts.title, posts.description
+FROM post_docids
+JOIN posts ON posts.id = post_docids.post_id;
+
+CREATE VIRTUAL TABLE posts_fts USING fts4(content="posts_fts_content", title, description, tokenize=porter);
+
+INSERT INTO posts_fts (posts_fts) VALUES ('rebuild');
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_insert AFTER INSERT ON posts BEGIN
+    INSERT INTO post_docids (post_id) VALUES (new.id);
+    INSERT INTO posts_fts (docid, title, description)
+    VALUES ((SELECT docid FROM post_docids WHERE post_id = new.id), new.title, new.description);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_before_update BEFORE UPDATE OF title, description ON posts BEGIN
+    DELETE FROM posts_fts WHERE docid = (SELECT docid FROM post_docids WHERE post_id = old.id);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_after_update AFTER UPDATE OF title, description ON posts BEGIN
+    INSERT INTO posts_fts (docid, title, description)
+    VALUES ((SELECT docid FROM post_docids WHERE post_id = new.id), new.title, new.description);
+END;
+-- +goose StatementEnd
+
+-- The post_docids row goes with the post, the index entry has to go first
+-- while its content can still be read.
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_delete BEFORE DELETE ON posts BEGIN
+    DELETE FROM posts_fts WHERE docid = (SELECT docid FROM post_docids WHERE post_id = old.id);
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER posts_fts_delete;
+DROP TRIGGER posts_fts_after_update;
+DROP TRIGGER posts_fts_before_update;
+DROP TRIGGER posts_fts_insert;
+DROP TABLE posts_fts;
+DROP VIEW posts_fts_content;
+DROP TABLE post_docids;
+
+CREATE VIRTUAL TABLE posts_fts USING fts4(content="posts", title, description, tokenize=porter);
+
+INSERT INTO posts_fts (posts_fts) VALUES ('rebuild');
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_insert AFTER INSERT ON posts BEGIN
+    INSERT INTO posts_fts (docid, title, description) VALUES (new.rowid, new.title, new.description);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_before_update BEFORE UPDATE OF title, description ON posts BEGIN
+    DELETE FROM posts_fts WHERE docid = old.rowid;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_after_update AFTER UPDATE OF title, description ON posts BEGIN
+    INSERT INTO posts_fts (docid, title, description) VALUES (new.rowid, new.title, new.description);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_delete BEFORE DELETE ON posts BEGIN
+    DELETE FROM posts_fts WHERE docid = old.rowid;
+END;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- Times written with the offset of a zone other than UTC compared wrongly
+-- as text against the UTC times of strftime('now'); they are rewritten in
+-- UTC, which is how they are stored from now on.
+UPDATE users SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at) WHERE created_at NOT LIKE '%+00:00';
+UPDATE users SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at) WHERE updated_at NOT LIKE '%+00:00';
+UPDATE feeds SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at) WHERE created_at NOT LIKE '%+00:00';
+UPDATE feeds SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at) WHERE updated_at NOT LIKE '%+00:00';
+UPDATE feeds SET last_fetched_at = strftime('%Y-%m-%d %H:%M:%f+00:00', last_fetched_at) WHERE last_fetched_at NOT LIKE '%+00:00';
+UPDATE feeds SET lease_expires_at = strftime('%Y-%m-%d %H:%M:%f+00:00', lease_expires_at) WHERE lease_expires_at NOT LIKE '%+00:00';
+UPDATE feeds SET next_fetch_at = strftime('%Y-%m-%d %H:%M:%f+00:00', next_fetch_at) WHERE next_fetch_at NOT LIKE '%+00:00';
+UPDATE feeds SET last_success_at = strftime('%Y-%m-%d %H:%M:%f+00:00', last_success_at) WHERE last_success_at NOT LIKE '%+00:00';
+UPDATE feeds SET disabled_at = strftime('%Y-%m-%d %H:%M:%f+00:00', disabled_at) WHERE disabled_at NOT LIKE '%+00:00';
+UPDATE feed_follows SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at) WHERE created_at NOT LIKE '%+00:00';
+UPDATE feed_follows SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at) WHERE updated_at NOT LIKE '%+00:00';
+UPDATE posts SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at) WHERE created_at NOT LIKE '%+00:00';
+UPDATE posts SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at) WHERE updated_at NOT LIKE '%+00:00';
+UPDATE posts SET published_at = strftime('%Y-%m-%d %H:%M:%f+00:00', published_at) WHERE published_at NOT LIKE '%+00:00';
+UPDATE fetch_log SET started_at = strftime('%Y-%m-%d %H:%M:%f+00:00', started_at) WHERE started_at NOT LIKE '%+00:00';
+UPDATE hooks SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at) WHERE created_at NOT LIKE '%+00:00';
+UPDATE hooks SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at) WHERE updated_at NOT LIKE '%+00:00';
+UPDATE hooks SET last_delivered_at = strftime('%Y-%m-%d %H:%M:%f+00:00', last_delivered_at) WHERE last_delivered_at NOT LIKE '%+00:00';
+UPDATE jobs SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at) WHERE created_at NOT LIKE '%+00:00';
+UPDATE jobs SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at) WHERE updated_at NOT LIKE '%+00:00';
+UPDATE jobs SET running_until = strftime('%Y-%m-%d %H:%M:%f+00:00', running_until) WHERE running_until NOT LIKE '%+00:00';
+UPDATE jobs SET last_started_at = strftime('%Y-%m-%d %H:%M:%f+00:00', last_started_at) WHERE last_started_at NOT LIKE '%+00:00';
+UPDATE jobs SET last_finished_at = strftime('%Y-%m-%d %H:%M:%f+00:00', last_finished_at) WHERE last_finished_at NOT LIKE '%+00:00';
+UPDATE post_states SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at) WHERE created_at NOT LIKE '%+00:00';
+UPDATE post_states SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at) WHERE updated_at NOT LIKE '%+00:00';
+UPDATE post_states SET read_at = strftime('%Y-%m-%d %H:%M:%f+00:00', read_at) WHERE read_at NOT LIKE '%+00:00';
+UPDATE starred_posts SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at) WHERE created_at NOT LIKE '%+00:00';
+UPDATE saved_posts SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at) WHERE created_at NOT LIKE '%+00:00';
+UPDATE folders SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at) WHERE created_at NOT LIKE '%+00:00';
+UPDATE folders SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at) WHERE updated_at NOT LIKE '%+00:00';
+UPDATE tags SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at) WHERE created_at NOT LIKE '%+00:00';
+UPDATE post_tags SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at) WHERE created_at NOT LIKE '%+00:00';
+UPDATE pruned_posts SET pruned_at = strftime('%Y-%m-%d %H:%M:%f+00:00', pruned_at) WHERE pruned_at NOT LIKE '%+00:00';
+
+-- +goose Down
+-- Times in UTC read the same to older builds, there is nothing to undo.
@@ -0,0 +1,8 @@
+// Package schema embeds the goose migrations that create the SQLite
+// database schema.
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS